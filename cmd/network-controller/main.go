@@ -73,9 +73,24 @@ func main() {
 		},
 		cli.BoolFlag{
 			Name:   "enable-vip-controller",
-			Usage:  "The bool flag to enable the vip controller in the manager network controller",
+			Usage:  "The bool flag to enable the vip controller in the manager and agent network controllers",
 			EnvVar: "ENABLE_VIP_CONTROLLER",
 		},
+		cli.StringFlag{
+			Name:   "host-resolv-conf",
+			EnvVar: "HOST_RESOLV_CONF",
+			Usage:  "The path of the node resolv.conf mounted into the agent, the DNS settings of the host networks are written to it if specified",
+		},
+		cli.StringFlag{
+			Name:   "dhcp-lease-dir",
+			EnvVar: "DHCP_LEASE_DIR",
+			Usage:  "The node-local directory mounted into the agent to persist the DHCP leases of the host networks across the restarts",
+		},
+		cli.BoolFlag{
+			Name:   "enable-l3-discovery-job",
+			Usage:  "The bool flag of the manager to discover the layer 3 network of the VLAN networks by the network helper jobs rather than by the agents, the agents follow the manager",
+			EnvVar: "ENABLE_L3_DISCOVERY_JOB",
+		},
 		cli.StringFlag{
 			Name:   "helper-image",
 			EnvVar: "HELPER_IMAGE",
@@ -123,13 +138,18 @@ func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection
 	threadiness := c.Int("threads")
 	nodeName := c.String("node-name")
 	helperImage := c.String("helper-image")
+	enableVipController := c.Bool("enable-vip-controller")
+	hostResolvConf := c.String("host-resolv-conf")
+	dhcpLeaseDir := c.String("dhcp-lease-dir")
+	enableL3DiscoveryJob := c.Bool("enable-l3-discovery-job")
 
 	if threadiness <= 0 {
 		logrus.Infof("Thread count of %d is invalid, fallback to default value %v.", threadiness, defaultThreadCount)
 		threadiness = defaultThreadCount
 	}
 
-	logrus.Infof("namespace %v threadiness %v nodeName %v helper-image %v", namespace, threadiness, nodeName, helperImage)
+	logrus.Infof("namespace %v threadiness %v nodeName %v helper-image %v enable-vip-controller %v host-resolv-conf %v dhcp-lease-dir %v enable-l3-discovery-job %v", namespace, threadiness, nodeName, helperImage,
+		enableVipController, hostResolvConf, dhcpLeaseDir, enableL3DiscoveryJob)
 
 	ctx := signals.SetupSignalContext()
 
@@ -144,9 +164,13 @@ func run(c *cli.Context, registerFuncList []config.RegisterFunc, leaderelection
 	}
 
 	options := &config.Options{
-		Namespace:   namespace,
-		NodeName:    nodeName,
-		HelperImage: helperImage,
+		Namespace:            namespace,
+		NodeName:             nodeName,
+		HelperImage:          helperImage,
+		EnableVipController:  enableVipController,
+		HostResolvConf:       hostResolvConf,
+		DHCPLeaseDir:         dhcpLeaseDir,
+		EnableL3DiscoveryJob: enableL3DiscoveryJob,
 	}
 
 	management, err := config.SetupManagement(ctx, cfg, options)
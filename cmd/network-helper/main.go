@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/urfave/cli"
 	"k8s.io/client-go/tools/clientcmd"
 
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/nad"
 	ctlcni "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io"
 	"github.com/harvester/harvester-network-controller/pkg/helper"
@@ -61,6 +63,18 @@ func main() {
 			Value:  "",
 			Usage:  "DHCP server IP address",
 		},
+		cli.StringFlag{
+			Name:   "dhcpoptions",
+			EnvVar: nad.JobEnvDHCPOptions,
+			Value:  "",
+			Usage:  "DHCP options of the host network on the same VLAN to identify the node in the DHCP requests",
+		},
+		cli.StringFlag{
+			Name:   "nodename",
+			EnvVar: nad.JobEnvNodeName,
+			Value:  "",
+			Usage:  "Name of the node the helper runs on",
+		},
 	}
 	app.Action = func(c *cli.Context) {
 		utils.SetLogLevel(logLevel)
@@ -81,6 +95,7 @@ func run(c *cli.Context) error {
 	kubeconfig := c.String("kubeconfig")
 	networks := c.String("nadnetworks")
 	dhcpServerIPAddr := c.String("dhcpserver")
+	nodeName := c.String("nodename")
 
 	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
 	if err != nil {
@@ -95,7 +110,14 @@ func run(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create nad selected network, networks: %s, error: %w", networks, err)
 	}
-	netHelper := helper.New(cni)
+	var dhcpOptions *networkv1.DHCPOptions
+	if options := c.String("dhcpoptions"); options != "" {
+		dhcpOptions = &networkv1.DHCPOptions{}
+		if err := json.Unmarshal([]byte(options), dhcpOptions); err != nil {
+			return fmt.Errorf("failed to parse dhcp options %s, error: %w", options, err)
+		}
+	}
+	netHelper := helper.New(cni, dhcpOptions, nodeName)
 
 	for i := range selectedNetworks {
 		networkConf := netHelper.GetVLANLayer3Network(&selectedNetworks[i], dhcpServerIPAddr)
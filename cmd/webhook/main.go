@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"syscall"
 	"time"
 
@@ -16,7 +17,12 @@ import (
 	"github.com/rancher/wrangler/v3/pkg/start"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	"github.com/harvester/webhook/pkg/server/admission"
@@ -24,6 +30,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcni "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	kubeovnnetwork "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubeovn.io"
@@ -37,6 +44,7 @@ import (
 	"github.com/harvester/harvester-network-controller/pkg/webhook/hostnetworkconfig"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/nad"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/subnet"
+	"github.com/harvester/harvester-network-controller/pkg/webhook/virtualip"
 	"github.com/harvester/harvester-network-controller/pkg/webhook/vlanconfig"
 )
 
@@ -44,6 +52,10 @@ const (
 	name = "harvester-network-webhook"
 
 	subnetsCRDName = "subnets.kubeovn.io"
+
+	// the default pod and service CIDRs of the harvester cluster
+	defaultClusterCIDR = "10.52.0.0/16"
+	defaultServiceCIDR = "10.53.0.0/16"
 )
 
 var (
@@ -53,6 +65,7 @@ var (
 func main() {
 	var options config.Options
 	logLevel := utils.GetDefaultLogLevel()
+	var clusterCIDR, serviceCIDR string
 
 	flags := []cli.Flag{
 		cli.StringFlag{
@@ -97,6 +110,20 @@ func main() {
 			Usage:       "The system username that performs garbage collection",
 			Value:       "system:serviceaccount:kube-system:generic-garbage-collector",
 		},
+		cli.StringFlag{
+			Name:        "cluster-cidr",
+			EnvVar:      "CLUSTER_CIDR",
+			Destination: &clusterCIDR,
+			Usage:       "The comma separated pod CIDRs of the cluster",
+			Value:       defaultClusterCIDR,
+		},
+		cli.StringFlag{
+			Name:        "service-cidr",
+			EnvVar:      "SERVICE_CIDR",
+			Destination: &serviceCIDR,
+			Usage:       "The comma separated service CIDRs of the cluster",
+			Value:       defaultServiceCIDR,
+		},
 	}
 
 	logrus.Infof("Starting %v version %v", name, VERSION)
@@ -112,7 +139,11 @@ func main() {
 	app.Flags = flags
 	app.Action = func(_ *cli.Context) {
 		utils.SetLogLevel(logLevel)
-		if err := run(ctx, cfg, &options); err != nil {
+		clusterCIDRs := hostnetworkconfig.ClusterCIDRs{
+			PodCIDRs:     splitCIDRs(clusterCIDR),
+			ServiceCIDRs: splitCIDRs(serviceCIDR),
+		}
+		if err := run(ctx, cfg, &options, clusterCIDRs); err != nil {
 			logrus.Fatalf("run webhook server failed: %v", err)
 		}
 	}
@@ -122,7 +153,7 @@ func main() {
 	}
 }
 
-func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
+func run(ctx context.Context, cfg *rest.Config, options *config.Options, clusterCIDRs hostnetworkconfig.ClusterCIDRs) error {
 	// check if subnet crd exists
 	crdExists, err := isSubnetsCRDPresent(ctx, cfg)
 	if err != nil {
@@ -134,6 +165,11 @@ func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
 		return err
 	}
 
+	recorder, err := newRecorder(cfg)
+	if err != nil {
+		return err
+	}
+
 	webhookServer := server.NewWebhookServer(ctx, cfg, name, options)
 
 	if err := webhookServer.RegisterMutators(
@@ -146,8 +182,9 @@ func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
 	validators := []admission.Validator{
 		clusternetwork.NewCnValidator(c.nadCache, c.vmiCache, c.vcCache),
 		nad.NewNadValidator(c.vmCache, c.vmiCache, c.cnCache, c.vcCache, c.kubeovnsubnetCache, crdExists, c.hostNetworkConfigCache, c.nadCache),
-		vlanconfig.NewVlanConfigValidator(c.nadCache, c.vcCache, c.vsCache, c.vmiCache, c.cnCache),
-		hostnetworkconfig.NewHostNetworkConfigValidator(c.nadCache, c.cnCache, c.hostNetworkConfigCache, c.vcCache, c.vsCache, c.nodeCache, c.vmCache),
+		vlanconfig.NewVlanConfigValidator(c.nadCache, c.vcCache, c.vsCache, c.vmiCache, c.cnCache, c.lmCache, c.nodeCache),
+		hostnetworkconfig.NewHostNetworkConfigValidator(c.nadCache, c.cnCache, c.hostNetworkConfigCache, c.vcCache, c.vsCache, c.nodeCache, c.vmCache, c.kubeovnsubnetCache, clusterCIDRs, recorder),
+		virtualip.NewVirtualIPValidator(c.hostNetworkConfigCache, c.virtualIPCache),
 	}
 
 	if crdExists {
@@ -171,6 +208,34 @@ func run(ctx context.Context, cfg *rest.Config, options *config.Options) error {
 	return nil
 }
 
+// newRecorder returns the recorder of the events about the validated objects, e.g. the warnings which the admission
+// response can't carry
+func newRecorder(cfg *rest.Config) (record.EventRecorder, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := networkv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme, corev1.EventSource{Component: name}), nil
+}
+
+func splitCIDRs(s string) []string {
+	var cidrs []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cidrs = append(cidrs, c)
+		}
+	}
+	return cidrs
+}
+
 type caches struct {
 	nadCache               ctlcniv1.NetworkAttachmentDefinitionCache
 	vmCache                ctlkubevirtv1.VirtualMachineCache
@@ -178,10 +243,12 @@ type caches struct {
 	vcCache                ctlnetworkv1.VlanConfigCache
 	vsCache                ctlnetworkv1.VlanStatusCache
 	cnCache                ctlnetworkv1.ClusterNetworkCache
+	lmCache                ctlnetworkv1.LinkMonitorCache
 	nodeCache              ctlcorev1.NodeCache
 	kubeovnsubnetCache     kubeovnnetworkv1.SubnetCache
 	kubeovnvpcCache        kubeovnnetworkv1.VpcCache
 	hostNetworkConfigCache ctlnetworkv1.HostNetworkConfigCache
+	virtualIPCache         ctlnetworkv1.VirtualIPCache
 }
 
 func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists bool) (*caches, error) {
@@ -204,8 +271,10 @@ func newCaches(ctx context.Context, cfg *rest.Config, threadiness int, crdExists
 		vcCache:                harvesterNetworkFactory.Network().V1beta1().VlanConfig().Cache(),
 		vsCache:                harvesterNetworkFactory.Network().V1beta1().VlanStatus().Cache(),
 		cnCache:                harvesterNetworkFactory.Network().V1beta1().ClusterNetwork().Cache(),
+		lmCache:                harvesterNetworkFactory.Network().V1beta1().LinkMonitor().Cache(),
 		nodeCache:              coreFactory.Core().V1().Node().Cache(),
 		hostNetworkConfigCache: harvesterNetworkFactory.Network().V1beta1().HostNetworkConfig().Cache(),
+		virtualIPCache:         harvesterNetworkFactory.Network().V1beta1().VirtualIP().Cache(),
 	}
 
 	if crdExists {
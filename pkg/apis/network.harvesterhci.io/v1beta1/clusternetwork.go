@@ -6,14 +6,46 @@ import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=cn;cns,scope=Cluster
+// +kubebuilder:printcolumn:name="MTU",type=integer,JSONPath=`.spec.mtu`
+// +kubebuilder:printcolumn:name="DESCRIPTION",type=string,JSONPath=`.spec.description`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
 
 type ClusterNetwork struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// +optional
+	Spec ClusterNetworkSpec `json:"spec,omitempty"`
+	// +optional
 	Status ClusterNetworkStatus `json:"status"`
 }
 
+type ClusterNetworkSpec struct {
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// MTU of the uplink, 0 means the default MTU is used
+	// for none-mgmt cluster network, it is synced from the vlanconfigs by controller
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MTU int `json:"mtu,omitempty"`
+
+	// VLAN ranges which the nads on this cluster network are allowed to use
+	// empty means all VLANs are allowed
+	// +optional
+	AllowedVlanRanges []VlanRange `json:"allowedVlanRanges,omitempty"`
+}
+
+type VlanRange struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	Start uint16 `json:"start"`
+	// End is included in the range, 0 means the range has only the Start VLAN
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4094
+	End uint16 `json:"end,omitempty"`
+}
+
 type ClusterNetworkStatus struct {
 	// +optional
 	Conditions []Condition `json:"conditions,omitempty"`
@@ -14,6 +14,8 @@ type HostNetworkConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// +kubebuilder:validation:XValidation:rule="self.mode != 'static' || (has(self.ips) && size(self.ips) > 0)",message="spec.ips must be specified and non-empty when mode is static"
+	// +kubebuilder:validation:XValidation:rule="!has(self.ipv6Mode) || self.ipv6Mode != 'static' || (has(self.ipv6s) && size(self.ipv6s) > 0)",message="spec.ipv6s must be specified and non-empty when ipv6Mode is static"
+	// +kubebuilder:validation:XValidation:rule="self.mode != 'pool' || has(self.pool)",message="spec.pool must be specified when mode is pool"
 	Spec HostNetworkConfigSpec `json:"spec"`
 	// +optional
 	Status HostNetworkConfigStatus `json:"status,omitempty"`
@@ -43,8 +45,8 @@ type HostNetworkConfigSpec struct {
 	// +kubebuilder:validation:Maximum=4094
 	VlanID uint16 `json:"vlanID"`
 
-	// Required, non-empty, only 'static' or 'dhcp'
-	// +kubebuilder:validation:Enum=static;dhcp
+	// Required, non-empty, only 'static', 'dhcp' or 'pool'
+	// +kubebuilder:validation:Enum=static;dhcp;pool
 	// +kubebuilder:validation:MinLength=1
 	Mode string `json:"mode"`
 
@@ -52,6 +54,114 @@ type HostNetworkConfigSpec struct {
 	// +optional
 	// +kubebuilder:validation:MaxProperties=500
 	HostIPs map[string]IPAddr `json:"ips,omitempty"`
+
+	// Optional, the pool to allocate the IPs of the nodes from, validated if mode=pool
+	// +optional
+	Pool *IPPool `json:"pool,omitempty"`
+
+	// Optional, only 'static', 'slaac' or 'dhcp'
+	// IPv6 is not configured if empty
+	// +optional
+	// +kubebuilder:validation:Enum=static;slaac;dhcp
+	IPv6Mode string `json:"ipv6Mode,omitempty"`
+
+	// Optional map of IPv6 addresses, validated if ipv6Mode=static
+	// +optional
+	// +kubebuilder:validation:MaxProperties=500
+	HostIPv6s map[string]IPAddr `json:"ipv6s,omitempty"`
+
+	// Optional, routes to the remote subnets via the gateways on the host network
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Routes []Route `json:"routes,omitempty"`
+
+	// Optional, DNS settings of the host network
+	// In dhcp mode the DNS settings from the lease are used if empty
+	// +optional
+	DNS *DNSConfig `json:"dns,omitempty"`
+
+	// Optional, route the traffic from the host addresses through a routing table dedicated to the host network,
+	// so that the replies leave through the host network instead of the default route of the node
+	// +optional
+	PolicyRouting bool `json:"policyRouting,omitempty"`
+
+	// Optional, the identity of the nodes in the DHCP requests, used in dhcp mode and by the network helper probing
+	// the VLAN networks on the same cluster network and VLAN
+	// +optional
+	DHCPOptions *DHCPOptions `json:"dhcpOptions,omitempty"`
+}
+
+type DHCPOptions struct {
+	// Optional, only 'nodeName' or 'mac', the source of the client identifier (option 61)
+	// The client identifier is not sent if empty
+	// +optional
+	// +kubebuilder:validation:Enum=nodeName;mac
+	ClientID string `json:"clientID,omitempty"`
+
+	// Optional, send the node name in the hostname option (option 12) to register it in DNS
+	// +optional
+	SendHostname bool `json:"sendHostname,omitempty"`
+
+	// Optional, the vendor class identifier (option 60)
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	VendorClass string `json:"vendorClass,omitempty"`
+
+	// Optional, the codes of the options to request besides the default ones
+	// +optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:Minimum=1
+	// +kubebuilder:validation:items:Maximum=254
+	RequestedOptions []int `json:"requestedOptions,omitempty"`
+}
+
+type IPPool struct {
+	// Required, the subnet of the pool, the allocated IPs take its prefix length
+	CIDR IPAddr `json:"cidr"`
+
+	// Optional, the first IP to allocate, the first IP of the subnet if empty
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="Invalid IP format"
+	RangeStart string `json:"rangeStart,omitempty"`
+
+	// Optional, the last IP to allocate, the last IP of the subnet if empty
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="Invalid IP format"
+	RangeEnd string `json:"rangeEnd,omitempty"`
+
+	// Optional, the IPs or the subnets not to allocate, e.g. the gateway
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+type Route struct {
+	// Required, the destination subnet, 0.0.0.0/0 or ::/0 for the default route
+	// The default route requires a metric to keep the default route of the node, or it's installed only in the
+	// routing table of the host network if policyRouting is enabled
+	Destination IPAddr `json:"destination"`
+
+	// Optional, the next hop on the host network
+	// The destination is reachable on the link directly if empty
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="isIP(self)",message="Invalid IP format"
+	Gateway string `json:"gateway,omitempty"`
+
+	// Optional, the metric of the route, the kernel default is used if zero
+	// +optional
+	Metric uint32 `json:"metric,omitempty"`
+}
+
+type DNSConfig struct {
+	// Optional, the IP addresses of the name servers
+	// +optional
+	// +kubebuilder:validation:MaxItems=3
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// Optional, the search domains
+	// +optional
+	// +kubebuilder:validation:MaxItems=6
+	Searches []string `json:"searches,omitempty"`
 }
 
 type HostNetworkConfigStatus struct {
@@ -62,6 +172,10 @@ type HostNetworkConfigStatus struct {
 	// key = node name
 	// +optional
 	NodeStatus map[string]HostNetworkConfigNodeStatus `json:"nodeStatus,omitempty"`
+	// IPs allocated from the pool in pool mode
+	// key = node name
+	// +optional
+	Allocations map[string]IPAddr `json:"allocations,omitempty"`
 }
 
 type HostNetworkConfigNodeStatus struct {
@@ -71,9 +185,52 @@ type HostNetworkConfigNodeStatus struct {
 	//vlan id
 	VlanID uint16 `json:"vlanID"`
 
-	//mode static or dhcp
+	//mode static, dhcp or pool
 	Mode string `json:"mode"`
 
+	//ipv6 mode static, slaac or dhcp, empty if IPv6 is not configured
+	// +optional
+	IPv6Mode string `json:"ipv6Mode,omitempty"`
+
+	//IPv4 addresses assigned to the host network interface
+	// +optional
+	IPv4Addresses []string `json:"ipv4Addresses,omitempty"`
+
+	//IPv6 global addresses assigned to the host network interface, link-local addresses are excluded
+	// +optional
+	IPv6Addresses []string `json:"ipv6Addresses,omitempty"`
+
+	//DHCP lease of the host network interface in dhcp mode
+	// +optional
+	DHCPLease *DHCPLeaseStatus `json:"dhcpLease,omitempty"`
+
 	// Node-specific conditions
 	Conditions []Condition `json:"conditions,omitempty"`
 }
+
+type DHCPLeaseStatus struct {
+	//state of the DHCP client, Init, Bound, Renewing or Rebinding
+	State string `json:"state"`
+
+	//leased IP address in CIDR notation, empty in Init state
+	// +optional
+	IP string `json:"ip,omitempty"`
+
+	//identifier of the leasing server
+	// +optional
+	Server string `json:"server,omitempty"`
+
+	// +optional
+	LeaseStart *metav1.Time `json:"leaseStart,omitempty"`
+
+	//time to renew the lease with the leasing server (T1)
+	// +optional
+	RenewalTime *metav1.Time `json:"renewalTime,omitempty"`
+
+	//time to rebind the lease with any server (T2)
+	// +optional
+	RebindingTime *metav1.Time `json:"rebindingTime,omitempty"`
+
+	// +optional
+	Expiry *metav1.Time `json:"expiry,omitempty"`
+}
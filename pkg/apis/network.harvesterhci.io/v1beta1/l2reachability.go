@@ -0,0 +1,74 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=l2r;l2rs,scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="CLUSTERNETWORK",type=string,JSONPath=`.spec.clusterNetwork`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// L2Reachability probes the VLANs of a cluster network between every pair of nodes periodically
+// and reports the node-by-node reachability matrix
+type L2Reachability struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              L2ReachabilitySpec `json:"spec"`
+	// +optional
+	Status L2ReachabilityStatus `json:"status,omitempty"`
+}
+
+type L2ReachabilitySpec struct {
+	// +kubebuilder:validation:MinLength=1
+	ClusterNetwork string `json:"clusterNetwork"`
+
+	// VLANs to probe, empty means all VLANs of the nads on the cluster network
+	// +optional
+	// +kubebuilder:validation:items:Minimum=2
+	// +kubebuilder:validation:items:Maximum=4094
+	VlanIDs []uint16 `json:"vlanIDs,omitempty"`
+
+	// Interval between two probe rounds in seconds, 0 means the default 300 seconds, the minimum is 30 seconds
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:XValidation:rule="self == 0 || self >= 30",message="intervalSeconds must be 0 or at least 30"
+	IntervalSeconds int `json:"intervalSeconds,omitempty"`
+
+	// Count of the probes sent on every VLAN in a round, 0 means the default 5
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Count int `json:"count,omitempty"`
+}
+
+type L2ReachabilityStatus struct {
+	// Per-node probe results
+	// key = the name of the node which sends the probes
+	// +optional
+	NodeStatus map[string]L2ReachabilityNodeStatus `json:"nodeStatus,omitempty"`
+}
+
+type L2ReachabilityNodeStatus struct {
+	// +optional
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+	// Message is the error of the last probe round
+	// +optional
+	Message string `json:"message,omitempty"`
+	// +optional
+	Results []L2ReachabilityResult `json:"results,omitempty"`
+}
+
+type L2ReachabilityResult struct {
+	VlanID uint16 `json:"vlanID"`
+	// Node is the peer node which replies the probes
+	Node     string `json:"node"`
+	Sent     int    `json:"sent"`
+	Received int    `json:"received"`
+	// LossPercent is the percentage of the probes without reply
+	LossPercent int `json:"lossPercent"`
+	// AverageRTT is the average round trip time of the replied probes, empty if no probe is replied
+	// +optional
+	AverageRTT string `json:"averageRTT,omitempty"`
+}
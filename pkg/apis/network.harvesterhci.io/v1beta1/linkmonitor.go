@@ -60,4 +60,32 @@ type LinkStatus struct {
 	State LinkState `json:"state,omitempty"`
 	// +optional
 	MasterIndex int `json:"masterIndex,omitempty"`
+	// +optional
+	PCIAddress string `json:"pciAddress,omitempty"`
+	// +optional
+	Driver string `json:"driver,omitempty"`
+	// LLDPNeighbor is the switch port which the link is connected to, discovered by LLDP
+	// +optional
+	LLDPNeighbor *LLDPNeighbor `json:"lldpNeighbor,omitempty"`
+}
+
+type LLDPNeighbor struct {
+	ChassisID string `json:"chassisID"`
+	PortID    string `json:"portID"`
+	// +optional
+	PortDescription string `json:"portDescription,omitempty"`
+	// +optional
+	SystemName string `json:"systemName,omitempty"`
+	// PortVlanID is the untagged VLAN of the switch port, 0 means it is not advertised
+	// +optional
+	PortVlanID uint16 `json:"portVlanID,omitempty"`
+	// Vlans are the VLANs advertised by the 802.1 VLAN name TLVs
+	// +optional
+	Vlans []LLDPVlan `json:"vlans,omitempty"`
+}
+
+type LLDPVlan struct {
+	ID uint16 `json:"id"`
+	// +optional
+	Name string `json:"name,omitempty"`
 }
@@ -0,0 +1,45 @@
+package v1beta1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:shortName=vip;vips,scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="HOSTNETWORKCONFIG",type=string,JSONPath=`.spec.hostNetworkConfig`
+// +kubebuilder:printcolumn:name="ADDRESS",type=string,JSONPath=`.spec.address`
+// +kubebuilder:printcolumn:name="OWNER",type=string,JSONPath=`.status.owner`
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
+
+// VirtualIP is a floating IP address on the VLAN interface of a HostNetworkConfig. The agents on the eligible nodes
+// elect the owner through a Lease, the owner assigns the address and sends gratuitous ARP.
+type VirtualIP struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              VirtualIPSpec `json:"spec"`
+	// +optional
+	Status VirtualIPStatus `json:"status,omitempty"`
+}
+
+type VirtualIPSpec struct {
+	// HostNetworkConfig whose VLAN interface carries the virtual IP
+	// +kubebuilder:validation:MinLength=1
+	HostNetworkConfig string `json:"hostNetworkConfig"`
+
+	// Address in CIDR format, e.g. 172.16.100.10/24, the prefix length is the same as the subnet of the host network
+	Address IPAddr `json:"address"`
+
+	// Optional: select the eligible nodes by labels in addition to the node selector of the HostNetworkConfig
+	// If empty, all nodes where the HostNetworkConfig is ready are eligible
+	// +optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+}
+
+type VirtualIPStatus struct {
+	// Owner is the node which holds the virtual IP
+	// +optional
+	Owner string `json:"owner,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+}
@@ -10,7 +10,11 @@ import (
 // +genclient:nonNamespaced
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:resource:shortName=vc;vcs,scope=Cluster
+// +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="CLUSTERNETWORK",type=string,JSONPath=`.spec.clusterNetwork`
+// +kubebuilder:printcolumn:name="MATCHED",type=integer,JSONPath=`.status.matchedNodeCount`
+// +kubebuilder:printcolumn:name="READY",type=integer,JSONPath=`.status.readyNodeCount`
+// +kubebuilder:printcolumn:name="FAILED",type=integer,JSONPath=`.status.failedNodeCount`
 // +kubebuilder:printcolumn:name="DESCRIPTION",type=string,JSONPath=`.spec.description`
 // +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=`.metadata.creationTimestamp`
 
@@ -18,6 +22,8 @@ type VlanConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	Spec              VlanConfigSpec `json:"spec"`
+	// +optional
+	Status VlanConfigStatus `json:"status,omitempty"`
 }
 
 type VlanConfigSpec struct {
@@ -26,16 +32,86 @@ type VlanConfigSpec struct {
 	ClusterNetwork string            `json:"clusterNetwork"`
 	NodeSelector   map[string]string `json:"nodeSelector,omitempty"`
 	Uplink         Uplink            `json:"uplink"`
+	// NodeOverrides replace parts of the uplink on the selected nodes, the first matched override is used
+	// +optional
+	NodeOverrides []NodeOverride `json:"nodeOverrides,omitempty"`
+}
+
+// NodeOverride selects nodes by either the node name or the node labels
+type NodeOverride struct {
+	Name string `json:"name"`
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// replace the NICs and NICSelector of the uplink if any of them is set
+	// +optional
+	NICs []string `json:"nics,omitempty"`
+	// +optional
+	NICSelector *NICSelector `json:"nicSelector,omitempty"`
+	// replace the LinkAttrs of the uplink if set, the MTU must be the same as the uplink
+	// +optional
+	LinkAttrs *LinkAttrs `json:"linkAttributes,omitempty"`
+	// replace the BondOptions of the uplink if set
+	// +optional
+	BondOptions *BondOptions `json:"bondOptions,omitempty"`
+}
+
+// VlanConfigStatus aggregates the per-node vlanstatus of the vlanconfig
+type VlanConfigStatus struct {
+	// the generation of the vlanconfig which this status is computed from
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// the nodes matched by the node selector
+	// +optional
+	MatchedNodes []string `json:"matchedNodes,omitempty"`
+	// +optional
+	MatchedNodeCount int `json:"matchedNodeCount"`
+	// the number of matched nodes whose vlanstatus is ready
+	// +optional
+	ReadyNodeCount int `json:"readyNodeCount"`
+	// the number of matched nodes whose vlanstatus is not ready
+	// +optional
+	FailedNodeCount int `json:"failedNodeCount"`
+	// key = node name, value = the failure message of the node
+	// +optional
+	FailedNodes map[string]string `json:"failedNodes,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
 }
 
 type Uplink struct {
 	NICs []string `json:"nics,omitempty"`
+	// NICSelector selects the NICs on each node instead of the NIC names, it can't be used together with NICs
+	// +optional
+	NICSelector *NICSelector `json:"nicSelector,omitempty"`
 	// +optional
 	LinkAttrs *LinkAttrs `json:"linkAttributes,omitempty"`
 	// +optional
 	BondOptions *BondOptions `json:"bondOptions,omitempty"`
 }
 
+// NICSelector matches the NICs which meet all the given criteria, a criterion is skipped if it is empty
+type NICSelector struct {
+	// the NIC matches any of the MAC addresses, the permanent MAC address of the NIC is matched as the current one is
+	// rewritten once the NIC is enslaved to the bond
+	// +optional
+	MACs []string `json:"macs,omitempty"`
+	// the NIC matches any of the PCI bus addresses, e.g. 0000:41:00.0
+	// +optional
+	PCIAddresses []string `json:"pciAddresses,omitempty"`
+	// the NIC matches any of the kernel driver names, e.g. ixgbe
+	// +optional
+	Drivers []string `json:"drivers,omitempty"`
+	// the NIC name matches the regular expression
+	// +optional
+	NameRegex string `json:"nameRegex,omitempty"`
+	// the maximum number of the selected NICs, the NICs are sorted by name, 0 means no limit
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MaxCount int `json:"maxCount,omitempty"`
+}
+
 type LinkAttrs struct {
 	// +optional
 	// +kubebuilder:validation:Minimum:=0
@@ -57,6 +133,44 @@ type BondOptions struct {
 	// +kubebuilder:validation:Minimum:=-1
 	// +kubebuilder:default:=-1
 	Miimon int `json:"miimon,omitempty"`
+	// the options below are omitted when they are empty or 0, and the kernel default values are used
+
+	// LACPDU sending rate, only for 802.3ad mode
+	// +optional
+	LacpRate BondLacpRate `json:"lacpRate,omitempty"`
+	// transmit hash policy, only for balance-xor, 802.3ad and balance-tlb modes
+	// +optional
+	XmitHashPolicy BondXmitHashPolicy `json:"xmitHashPolicy,omitempty"`
+	// aggregation selection logic, only for 802.3ad mode
+	// +optional
+	AdSelect BondAdSelect `json:"adSelect,omitempty"`
+	// minimum number of links that must be active before asserting carrier
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	MinLinks int `json:"minLinks,omitempty"`
+	// in milliseconds, must be a multiple of miimon
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	UpDelay int `json:"updelay,omitempty"`
+	// in milliseconds, must be a multiple of miimon
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	DownDelay int `json:"downdelay,omitempty"`
+	// the NIC which is preferred to be active, only for active-backup, balance-tlb and balance-alb modes
+	// +optional
+	Primary string `json:"primary,omitempty"`
+	// +optional
+	PrimaryReselect BondPrimaryReselect `json:"primaryReselect,omitempty"`
+	// only for active-backup mode
+	// +optional
+	FailOverMac BondFailOverMac `json:"failOverMac,omitempty"`
+	// ARP monitoring interval in milliseconds, it can't be used together with miimon
+	// +optional
+	// +kubebuilder:validation:Minimum:=0
+	ArpInterval int `json:"arpInterval,omitempty"`
+	// IPv4 addresses of the ARP monitoring targets, up to 16
+	// +optional
+	ArpIPTargets []string `json:"arpIPTargets,omitempty"`
 }
 
 // +kubebuilder:validation:Enum={"balance-rr","active-backup","balance-xor","broadcast","802.3ad","balance-tlb","balance-alb"}
@@ -72,3 +186,23 @@ const (
 	BondModeBalanceTlb   BondMode = "balance-tlb"
 	BondModeBalanceAlb   BondMode = "balance-alb"
 )
+
+// +kubebuilder:validation:Enum={"slow","fast"}
+
+type BondLacpRate string
+
+// +kubebuilder:validation:Enum={"layer2","layer3+4","layer2+3","encap2+3","encap3+4","vlan+srcmac"}
+
+type BondXmitHashPolicy string
+
+// +kubebuilder:validation:Enum={"stable","bandwidth","count"}
+
+type BondAdSelect string
+
+// +kubebuilder:validation:Enum={"always","better","failure"}
+
+type BondPrimaryReselect string
+
+// +kubebuilder:validation:Enum={"none","active","follow"}
+
+type BondFailOverMac string
@@ -31,6 +31,9 @@ type VlStatus struct {
 	LinkMonitor string `json:"linkMonitor"`
 
 	Node string `json:"node"`
+	// the name of the node override of the vlanconfig which is applied on this node
+	// +optional
+	UplinkOverride string `json:"uplinkOverride,omitempty"`
 	// +optional
 	LocalAreas []LocalArea `json:"localAreas,omitempty"`
 	// +optional
@@ -59,4 +62,12 @@ type Condition struct {
 
 var (
 	Ready condition.Cond = "ready"
+	// LocalAreasSynced is true when the VLANs programmed on the node uplink match the VLANs computed from the nads
+	LocalAreasSynced condition.Cond = "localAreasSynced"
+	// TrunkVlansReachable is true when the VLANs of the cluster network are carried by the switch port of the node uplink,
+	// it is checked by the LLDP VLAN TLVs of the switch or by the L2 probes between the nodes
+	TrunkVlansReachable condition.Cond = "trunkVlansReachable"
+	// IPv4Ready and IPv6Ready are true when the address family is configured on the host network interface of the node
+	IPv4Ready condition.Cond = "ipv4Ready"
+	IPv6Ready condition.Cond = "ipv6Ready"
 )
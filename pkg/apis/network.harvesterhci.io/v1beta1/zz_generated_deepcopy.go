@@ -31,6 +31,11 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BondOptions) DeepCopyInto(out *BondOptions) {
 	*out = *in
+	if in.ArpIPTargets != nil {
+		in, out := &in.ArpIPTargets, &out.ArpIPTargets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -49,6 +54,7 @@ func (in *ClusterNetwork) DeepCopyInto(out *ClusterNetwork) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -104,6 +110,27 @@ func (in *ClusterNetworkList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterNetworkSpec) DeepCopyInto(out *ClusterNetworkSpec) {
+	*out = *in
+	if in.AllowedVlanRanges != nil {
+		in, out := &in.AllowedVlanRanges, &out.AllowedVlanRanges
+		*out = make([]VlanRange, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterNetworkSpec.
+func (in *ClusterNetworkSpec) DeepCopy() *ClusterNetworkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterNetworkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterNetworkStatus) DeepCopyInto(out *ClusterNetworkStatus) {
 	*out = *in
@@ -141,6 +168,85 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPLeaseStatus) DeepCopyInto(out *DHCPLeaseStatus) {
+	*out = *in
+	if in.LeaseStart != nil {
+		in, out := &in.LeaseStart, &out.LeaseStart
+		*out = (*in).DeepCopy()
+	}
+	if in.RenewalTime != nil {
+		in, out := &in.RenewalTime, &out.RenewalTime
+		*out = (*in).DeepCopy()
+	}
+	if in.RebindingTime != nil {
+		in, out := &in.RebindingTime, &out.RebindingTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Expiry != nil {
+		in, out := &in.Expiry, &out.Expiry
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPLeaseStatus.
+func (in *DHCPLeaseStatus) DeepCopy() *DHCPLeaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPLeaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DHCPOptions) DeepCopyInto(out *DHCPOptions) {
+	*out = *in
+	if in.RequestedOptions != nil {
+		in, out := &in.RequestedOptions, &out.RequestedOptions
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DHCPOptions.
+func (in *DHCPOptions) DeepCopy() *DHCPOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(DHCPOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSConfig) DeepCopyInto(out *DNSConfig) {
+	*out = *in
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Searches != nil {
+		in, out := &in.Searches, &out.Searches
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSConfig.
+func (in *DNSConfig) DeepCopy() *DNSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostNetworkConfig) DeepCopyInto(out *HostNetworkConfig) {
 	*out = *in
@@ -205,6 +311,21 @@ func (in *HostNetworkConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HostNetworkConfigNodeStatus) DeepCopyInto(out *HostNetworkConfigNodeStatus) {
 	*out = *in
+	if in.IPv4Addresses != nil {
+		in, out := &in.IPv4Addresses, &out.IPv4Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPv6Addresses != nil {
+		in, out := &in.IPv6Addresses, &out.IPv6Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DHCPLease != nil {
+		in, out := &in.DHCPLease, &out.DHCPLease
+		*out = new(DHCPLeaseStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]Condition, len(*in))
@@ -238,6 +359,33 @@ func (in *HostNetworkConfigSpec) DeepCopyInto(out *HostNetworkConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Pool != nil {
+		in, out := &in.Pool, &out.Pool
+		*out = new(IPPool)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HostIPv6s != nil {
+		in, out := &in.HostIPv6s, &out.HostIPv6s
+		*out = make(map[string]IPAddr, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]Route, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(DNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DHCPOptions != nil {
+		in, out := &in.DHCPOptions, &out.DHCPOptions
+		*out = new(DHCPOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -266,6 +414,13 @@ func (in *HostNetworkConfigStatus) DeepCopyInto(out *HostNetworkConfigStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make(map[string]IPAddr, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -279,6 +434,207 @@ func (in *HostNetworkConfigStatus) DeepCopy() *HostNetworkConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPPool) DeepCopyInto(out *IPPool) {
+	*out = *in
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPPool.
+func (in *IPPool) DeepCopy() *IPPool {
+	if in == nil {
+		return nil
+	}
+	out := new(IPPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2Reachability) DeepCopyInto(out *L2Reachability) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2Reachability.
+func (in *L2Reachability) DeepCopy() *L2Reachability {
+	if in == nil {
+		return nil
+	}
+	out := new(L2Reachability)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *L2Reachability) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2ReachabilityList) DeepCopyInto(out *L2ReachabilityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]L2Reachability, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2ReachabilityList.
+func (in *L2ReachabilityList) DeepCopy() *L2ReachabilityList {
+	if in == nil {
+		return nil
+	}
+	out := new(L2ReachabilityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *L2ReachabilityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2ReachabilityNodeStatus) DeepCopyInto(out *L2ReachabilityNodeStatus) {
+	*out = *in
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+	if in.Results != nil {
+		in, out := &in.Results, &out.Results
+		*out = make([]L2ReachabilityResult, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2ReachabilityNodeStatus.
+func (in *L2ReachabilityNodeStatus) DeepCopy() *L2ReachabilityNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(L2ReachabilityNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2ReachabilityResult) DeepCopyInto(out *L2ReachabilityResult) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2ReachabilityResult.
+func (in *L2ReachabilityResult) DeepCopy() *L2ReachabilityResult {
+	if in == nil {
+		return nil
+	}
+	out := new(L2ReachabilityResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2ReachabilitySpec) DeepCopyInto(out *L2ReachabilitySpec) {
+	*out = *in
+	if in.VlanIDs != nil {
+		in, out := &in.VlanIDs, &out.VlanIDs
+		*out = make([]uint16, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2ReachabilitySpec.
+func (in *L2ReachabilitySpec) DeepCopy() *L2ReachabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(L2ReachabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *L2ReachabilityStatus) DeepCopyInto(out *L2ReachabilityStatus) {
+	*out = *in
+	if in.NodeStatus != nil {
+		in, out := &in.NodeStatus, &out.NodeStatus
+		*out = make(map[string]L2ReachabilityNodeStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new L2ReachabilityStatus.
+func (in *L2ReachabilityStatus) DeepCopy() *L2ReachabilityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(L2ReachabilityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLDPNeighbor) DeepCopyInto(out *LLDPNeighbor) {
+	*out = *in
+	if in.Vlans != nil {
+		in, out := &in.Vlans, &out.Vlans
+		*out = make([]LLDPVlan, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLDPNeighbor.
+func (in *LLDPNeighbor) DeepCopy() *LLDPNeighbor {
+	if in == nil {
+		return nil
+	}
+	out := new(LLDPNeighbor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LLDPVlan) DeepCopyInto(out *LLDPVlan) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LLDPVlan.
+func (in *LLDPVlan) DeepCopy() *LLDPVlan {
+	if in == nil {
+		return nil
+	}
+	out := new(LLDPVlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinkAttrs) DeepCopyInto(out *LinkAttrs) {
 	*out = *in
@@ -403,7 +759,9 @@ func (in *LinkMonitorStatus) DeepCopyInto(out *LinkMonitorStatus) {
 			} else {
 				in, out := &val, &outVal
 				*out = make([]LinkStatus, len(*in))
-				copy(*out, *in)
+				for i := range *in {
+					(*in)[i].DeepCopyInto(&(*out)[i])
+				}
 			}
 			(*out)[key] = outVal
 		}
@@ -424,6 +782,11 @@ func (in *LinkMonitorStatus) DeepCopy() *LinkMonitorStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinkStatus) DeepCopyInto(out *LinkStatus) {
 	*out = *in
+	if in.LLDPNeighbor != nil {
+		in, out := &in.LLDPNeighbor, &out.LLDPNeighbor
+		*out = new(LLDPNeighbor)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -453,6 +816,96 @@ func (in *LocalArea) DeepCopy() *LocalArea {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NICSelector) DeepCopyInto(out *NICSelector) {
+	*out = *in
+	if in.MACs != nil {
+		in, out := &in.MACs, &out.MACs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PCIAddresses != nil {
+		in, out := &in.PCIAddresses, &out.PCIAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drivers != nil {
+		in, out := &in.Drivers, &out.Drivers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NICSelector.
+func (in *NICSelector) DeepCopy() *NICSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(NICSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeOverride) DeepCopyInto(out *NodeOverride) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NICs != nil {
+		in, out := &in.NICs, &out.NICs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NICSelector != nil {
+		in, out := &in.NICSelector, &out.NICSelector
+		*out = new(NICSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LinkAttrs != nil {
+		in, out := &in.LinkAttrs, &out.LinkAttrs
+		*out = new(LinkAttrs)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BondOptions != nil {
+		in, out := &in.BondOptions, &out.BondOptions
+		*out = new(BondOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeOverride.
+func (in *NodeOverride) DeepCopy() *NodeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Route) DeepCopyInto(out *Route) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
+func (in *Route) DeepCopy() *Route {
+	if in == nil {
+		return nil
+	}
+	out := new(Route)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetLinkRule) DeepCopyInto(out *TargetLinkRule) {
 	*out = *in
@@ -477,6 +930,11 @@ func (in *Uplink) DeepCopyInto(out *Uplink) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.NICSelector != nil {
+		in, out := &in.NICSelector, &out.NICSelector
+		*out = new(NICSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.LinkAttrs != nil {
 		in, out := &in.LinkAttrs, &out.LinkAttrs
 		*out = new(LinkAttrs)
@@ -485,7 +943,7 @@ func (in *Uplink) DeepCopyInto(out *Uplink) {
 	if in.BondOptions != nil {
 		in, out := &in.BondOptions, &out.BondOptions
 		*out = new(BondOptions)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	return
 }
@@ -500,6 +958,109 @@ func (in *Uplink) DeepCopy() *Uplink {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualIP) DeepCopyInto(out *VirtualIP) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualIP.
+func (in *VirtualIP) DeepCopy() *VirtualIP {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualIP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VirtualIP) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualIPList) DeepCopyInto(out *VirtualIPList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VirtualIP, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualIPList.
+func (in *VirtualIPList) DeepCopy() *VirtualIPList {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualIPList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VirtualIPList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualIPSpec) DeepCopyInto(out *VirtualIPSpec) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualIPSpec.
+func (in *VirtualIPSpec) DeepCopy() *VirtualIPSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualIPSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VirtualIPStatus) DeepCopyInto(out *VirtualIPStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualIPStatus.
+func (in *VirtualIPStatus) DeepCopy() *VirtualIPStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VirtualIPStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VlStatus) DeepCopyInto(out *VlStatus) {
 	*out = *in
@@ -532,6 +1093,7 @@ func (in *VlanConfig) DeepCopyInto(out *VlanConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -597,6 +1159,13 @@ func (in *VlanConfigSpec) DeepCopyInto(out *VlanConfigSpec) {
 		}
 	}
 	in.Uplink.DeepCopyInto(&out.Uplink)
+	if in.NodeOverrides != nil {
+		in, out := &in.NodeOverrides, &out.NodeOverrides
+		*out = make([]NodeOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -610,6 +1179,55 @@ func (in *VlanConfigSpec) DeepCopy() *VlanConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanConfigStatus) DeepCopyInto(out *VlanConfigStatus) {
+	*out = *in
+	if in.MatchedNodes != nil {
+		in, out := &in.MatchedNodes, &out.MatchedNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedNodes != nil {
+		in, out := &in.FailedNodes, &out.FailedNodes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanConfigStatus.
+func (in *VlanConfigStatus) DeepCopy() *VlanConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VlanRange) DeepCopyInto(out *VlanRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VlanRange.
+func (in *VlanRange) DeepCopy() *VlanRange {
+	if in == nil {
+		return nil
+	}
+	out := new(VlanRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VlanStatus) DeepCopyInto(out *VlanStatus) {
 	*out = *in
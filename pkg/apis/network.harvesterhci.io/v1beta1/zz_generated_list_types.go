@@ -108,3 +108,37 @@ func NewHostNetworkConfig(namespace, name string, obj HostNetworkConfig) *HostNe
 	obj.Namespace = namespace
 	return &obj
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// L2ReachabilityList is a list of L2Reachability resources
+type L2ReachabilityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []L2Reachability `json:"items"`
+}
+
+func NewL2Reachability(namespace, name string, obj L2Reachability) *L2Reachability {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("L2Reachability").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// VirtualIPList is a list of VirtualIP resources
+type VirtualIPList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []VirtualIP `json:"items"`
+}
+
+func NewVirtualIP(namespace, name string, obj VirtualIP) *VirtualIP {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("VirtualIP").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
@@ -30,7 +30,9 @@ import (
 var (
 	ClusterNetworkResourceName    = "clusternetworks"
 	HostNetworkConfigResourceName = "hostnetworkconfigs"
+	L2ReachabilityResourceName    = "l2reachabilities"
 	LinkMonitorResourceName       = "linkmonitors"
+	VirtualIPResourceName         = "virtualips"
 	VlanConfigResourceName        = "vlanconfigs"
 	VlanStatusResourceName        = "vlanstatuses"
 )
@@ -60,8 +62,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterNetworkList{},
 		&HostNetworkConfig{},
 		&HostNetworkConfigList{},
+		&L2Reachability{},
+		&L2ReachabilityList{},
 		&LinkMonitor{},
 		&LinkMonitorList{},
+		&VirtualIP{},
+		&VirtualIPList{},
 		&VlanConfig{},
 		&VlanConfigList{},
 		&VlanStatus{},
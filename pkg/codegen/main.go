@@ -30,6 +30,8 @@ func main() {
 					networkv1.VlanStatus{},
 					networkv1.LinkMonitor{},
 					networkv1.HostNetworkConfig{},
+					networkv1.L2Reachability{},
+					networkv1.VirtualIP{},
 				},
 				GenerateTypes:   true,
 				GenerateClients: true,
@@ -44,9 +44,20 @@ func init() {
 type RegisterFunc func(context.Context, *Management) error
 
 type Options struct {
-	Namespace   string
-	HelperImage string
-	NodeName    string
+	Namespace           string
+	HelperImage         string
+	NodeName            string
+	EnableVipController bool
+	// HostResolvConf is the path of the resolv.conf of the node mounted into the agent, the DNS settings of the host
+	// networks are written to it if it's not empty
+	HostResolvConf string
+	// DHCPLeaseDir is the node-local directory to persist the DHCP leases of the host networks, the leases are
+	// verified rather than obtained again after the agent restarts if it's not empty
+	DHCPLeaseDir string
+	// EnableL3DiscoveryJob keeps discovering the layer 3 network of the VLAN nads by the network helper jobs created by
+	// the manager rather than by the agents. It's only read by the manager, which publishes it on the mgmt cluster
+	// network for the agents to follow.
+	EnableL3DiscoveryJob bool
 }
 
 type Management struct {
@@ -3,8 +3,12 @@ package clusternetwork
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 
+	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/vishvananda/netlink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/sirupsen/logrus"
 
@@ -23,28 +27,49 @@ const (
 )
 
 type Handler struct {
-	cnCache  ctlnetworkv1.ClusterNetworkCache
-	cnClient ctlnetworkv1.ClusterNetworkClient
-	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
+	nodeName     string
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	cnClient     ctlnetworkv1.ClusterNetworkClient
+	cnController ctlnetworkv1.ClusterNetworkController
+	vsCache      ctlnetworkv1.VlanStatusCache
+	vsClient     ctlnetworkv1.VlanStatusClient
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+	lmCache      ctlnetworkv1.LinkMonitorCache
+
+	probers *probers
 }
 
 func Register(ctx context.Context, management *config.Management) error {
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	lms := management.HarvesterNetworkFactory.Network().V1beta1().LinkMonitor()
 	handler := Handler{
-		cnCache:  cns.Cache(),
-		cnClient: cns,
-		nadCache: nads.Cache(),
+		nodeName:     management.Options.NodeName,
+		cnCache:      cns.Cache(),
+		cnClient:     cns,
+		cnController: cns,
+		vsCache:      vss.Cache(),
+		vsClient:     vss,
+		nadCache:     nads.Cache(),
+		lmCache:      lms.Cache(),
+		probers:      newProbers(ctx, management.Options.NodeName),
 	}
 
 	cns.OnChange(ctx, controllerName, handler.OnChange)
+	nads.OnChange(ctx, controllerName, handler.EnqueueClusterNetworkByNad)
+	vss.OnChange(ctx, controllerName, handler.EnqueueClusterNetworkByVlanStatus)
+
+	go handler.checkTrunkVlansPeriodically(ctx)
+
 	return nil
 }
 
 // to support vlan trunk mode nad
 // the vlan set of a specific cluster network is computed dynamically via the nad list
-func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+func (h Handler) OnChange(key string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
 	if cn == nil || cn.DeletionTimestamp != nil {
+		h.probers.stop(key)
 		return nil, nil
 	}
 	logrus.Infof("cluster network %s has been changed, vid hash: %v", cn.Name, cn.Annotations[utils.KeyVlanIDSetStrHash])
@@ -54,6 +79,7 @@ func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.Cl
 		// vlanconfig controller sets up the non-mgmt cn; mgmt cn is setup by wicked daemon service
 		if errors.As(err, &netlink.LinkNotFoundError{}) {
 			logrus.Infof("cluster network %s is not set on this node, skip", cn.Name)
+			h.probers.stop(cn.Name)
 			return nil, nil
 		}
 		return nil, err
@@ -101,5 +127,98 @@ func (h Handler) OnChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.Cl
 		return nil, err
 	}
 
+	if err := h.updateVlanStatus(cn.Name, v, cnVlans); err != nil {
+		return nil, err
+	}
+
 	return cn, nil
 }
+
+// the CIDR of a local area comes from the nad route annotation, which is not reflected on the cluster network
+func (h Handler) EnqueueClusterNetworkByNad(_ string, nad *nadv1.NetworkAttachmentDefinition) (*nadv1.NetworkAttachmentDefinition, error) {
+	if nad == nil {
+		return nil, nil
+	}
+	if cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel); cnName != "" {
+		h.cnController.Enqueue(cnName)
+	}
+	return nad, nil
+}
+
+// the vlanstatus is created by the vlanconfig controller after the cluster network is set up
+// enqueue the cluster network to fill in the local areas
+func (h Handler) EnqueueClusterNetworkByVlanStatus(_ string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil || vs.DeletionTimestamp != nil || vs.Status.Node != h.nodeName {
+		return nil, nil
+	}
+	h.cnController.Enqueue(vs.Status.ClusterNetwork)
+	return vs, nil
+}
+
+// updateVlanStatus reports the vlans effectively programmed on the uplink and whether the switch port carries them
+// to the vlanstatus of this node
+func (h Handler) updateVlanStatus(cnName string, v *vlan.Vlan, desired *utils.VlanIDSet) error {
+	vs, err := h.vsCache.Get(utils.Name("", cnName, h.nodeName))
+	if err != nil {
+		// the mgmt network has no vlanstatus, and the vlanconfig controller has not created it yet otherwise
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if vs.DeletionTimestamp != nil {
+		return nil
+	}
+
+	effective, err := v.ToVlanIDSet()
+	if err != nil {
+		return err
+	}
+	if effective == nil {
+		effective = utils.NewVlanIDSet()
+	}
+
+	nads, err := utils.NewNadGetter(h.nadCache).ListNadsOnClusterNetwork(cnName)
+	if err != nil {
+		return err
+	}
+	cidrs := utils.GetVlanCIDRsFromNadList(nads)
+
+	var localAreas []networkv1.LocalArea
+	if err := effective.WalkVIDs(cnName, func(vid uint16) error {
+		localAreas = append(localAreas, networkv1.LocalArea{VID: vid, CIDR: cidrs[vid]})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	missing, unexpected, err := desired.Diff(effective)
+	if err != nil {
+		return err
+	}
+
+	vsCopy := vs.DeepCopy()
+	vsCopy.Status.LocalAreas = localAreas
+	if missing.GetVlanCount() == 0 && unexpected.GetVlanCount() == 0 {
+		networkv1.LocalAreasSynced.True(vsCopy)
+		networkv1.LocalAreasSynced.Message(vsCopy, "")
+	} else {
+		networkv1.LocalAreasSynced.False(vsCopy)
+		networkv1.LocalAreasSynced.Message(vsCopy, fmt.Sprintf("vlans [%s] are missing on the uplink, vlans [%s] are unexpected on the uplink",
+			missing.VidSetToString(), unexpected.VidSetToString()))
+	}
+
+	// the trunk vlans are checked by checkTrunkVlansPeriodically, the failure to start the prober doesn't block the
+	// local areas
+	if _, err := h.probers.ensure(cnName, desired); err != nil {
+		setTrunkVlansCheckError(vsCopy, fmt.Errorf("start probing vlans failed, error: %w", err))
+	}
+
+	if reflect.DeepEqual(vs, vsCopy) {
+		return nil
+	}
+	if _, err := h.vsClient.Update(vsCopy); err != nil {
+		return fmt.Errorf("failed to update vlanstatus %s local areas, error: %w", vs.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package clusternetwork
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/probe"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	probeInterval = 30 * time.Second
+	// a VLAN is regarded as unreachable if no probe of it is received from the other nodes in the window of the
+	// cycles, in each cycle the probes are sent on every VLAN once
+	probeWindowCycles = 3
+
+	reasonLLDP  = "LLDP"
+	reasonProbe = "Probe"
+	reasonError = "Error"
+)
+
+// trunkCheck is the prober of a cluster network and the VLANs expected to be carried by the trunk
+type trunkCheck struct {
+	prober  *probe.Prober
+	desired *utils.VlanIDSet
+}
+
+// probers keeps a prober on the uplink bond of every cluster network set up on this node
+type probers struct {
+	ctx      context.Context
+	nodeName string
+
+	mu      sync.Mutex
+	probers map[string]*probe.Prober
+	desired map[string]*utils.VlanIDSet
+}
+
+func newProbers(ctx context.Context, nodeName string) *probers {
+	return &probers{
+		ctx:      ctx,
+		nodeName: nodeName,
+		probers:  make(map[string]*probe.Prober),
+		desired:  make(map[string]*utils.VlanIDSet),
+	}
+}
+
+// ensure starts the prober of the cluster network if it isn't started or the uplink bond is recreated
+func (ps *probers) ensure(cnName string, vids *utils.VlanIDSet) (*probe.Prober, error) {
+	bondName := utils.GenerateBondName(cnName)
+	ifi, err := net.InterfaceByName(bondName)
+	if err != nil {
+		return nil, fmt.Errorf("get uplink %s failed, error: %w", bondName, err)
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.desired[cnName] = vids
+	p, ok := ps.probers[cnName]
+	if ok && p.IfIndex() != ifi.Index {
+		p.Stop()
+		ok = false
+	}
+	if !ok {
+		p = probe.NewProber(cnName, ps.nodeName, bondName, probeInterval)
+		if err := p.SetVIDs(vids); err != nil {
+			return nil, err
+		}
+		if err := p.Start(ps.ctx); err != nil {
+			return nil, err
+		}
+		ps.probers[cnName] = p
+		return p, nil
+	}
+
+	return p, p.SetVIDs(vids)
+}
+
+func (ps *probers) stop(cnName string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if p, ok := ps.probers[cnName]; ok {
+		p.Stop()
+		delete(ps.probers, cnName)
+	}
+	delete(ps.desired, cnName)
+}
+
+// checks returns the trunk checks of the cluster networks whose probers are started
+func (ps *probers) checks() map[string]trunkCheck {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	checks := make(map[string]trunkCheck, len(ps.probers))
+	for cnName, p := range ps.probers {
+		checks[cnName] = trunkCheck{prober: p, desired: ps.desired[cnName]}
+	}
+	return checks
+}
+
+// checkTrunkVlansPeriodically checks the trunk VLANs of the cluster networks and records the results in the
+// vlanstatus of this node, the results of LLDP and probes change without any event of the cluster networks
+func (h Handler) checkTrunkVlansPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		for cnName, check := range h.probers.checks() {
+			if err := h.updateTrunkVlansReachable(cnName, check); err != nil {
+				logrus.Errorf("update trunk vlans of cluster network %s failed, error: %s", cnName, err.Error())
+			}
+		}
+	}
+}
+
+func (h Handler) updateTrunkVlansReachable(cnName string, check trunkCheck) error {
+	vs, err := h.vsCache.Get(utils.Name("", cnName, h.nodeName))
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if vs.DeletionTimestamp != nil {
+		return nil
+	}
+
+	vsCopy := vs.DeepCopy()
+	if err := h.checkTrunkVlans(vsCopy, cnName, check.desired, check.prober); err != nil {
+		setTrunkVlansCheckError(vsCopy, err)
+	}
+	if reflect.DeepEqual(vs, vsCopy) {
+		return nil
+	}
+	_, err = h.vsClient.Update(vsCopy)
+	return err
+}
+
+// setTrunkVlansCheckError records the failure of the check, the reachability of the VLANs is unknown then
+func setTrunkVlansCheckError(vs *networkv1.VlanStatus, err error) {
+	networkv1.TrunkVlansReachable.Unknown(vs)
+	networkv1.TrunkVlansReachable.Reason(vs, reasonError)
+	networkv1.TrunkVlansReachable.Message(vs, err.Error())
+}
+
+// checkTrunkVlans checks whether the VLANs of the cluster network are carried by the switch port of the uplink.
+// The LLDP VLAN TLVs are preferred, the L2 probes between the nodes are used if the switch doesn't advertise them.
+func (h Handler) checkTrunkVlans(vs *networkv1.VlanStatus, cnName string, desired *utils.VlanIDSet, prober *probe.Prober) error {
+	checked, err := h.checkTrunkVlansByLLDP(vs, cnName, desired)
+	if err != nil || checked {
+		return err
+	}
+
+	return h.checkTrunkVlansByProbe(vs, cnName, desired, prober)
+}
+
+// checkTrunkVlansByLLDP returns false if no uplink NIC has LLDP VLAN information
+func (h Handler) checkTrunkVlansByLLDP(vs *networkv1.VlanStatus, cnName string, desired *utils.VlanIDSet) (bool, error) {
+	lm, err := h.lmCache.Get(utils.NICLinkMonitorName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	bond, err := net.InterfaceByName(utils.GenerateBondName(cnName))
+	if err != nil {
+		return false, err
+	}
+
+	checked := false
+	var messages []string
+	for _, ls := range lm.Status.LinkStatus[h.nodeName] {
+		if ls.MasterIndex != bond.Index || ls.LLDPNeighbor == nil || len(ls.LLDPNeighbor.Vlans) == 0 {
+			continue
+		}
+		checked = true
+
+		carried := utils.NewVlanIDSet()
+		for _, vlan := range ls.LLDPNeighbor.Vlans {
+			if err := carried.SetUint16VID(vlan.ID); err != nil {
+				logrus.Debugf("ignore the LLDP vlan %d of %s, error: %s", vlan.ID, ls.Name, err.Error())
+			}
+		}
+		missing, _, err := desired.Diff(carried)
+		if err != nil {
+			return false, err
+		}
+		if missing.GetVlanCount() > 0 {
+			messages = append(messages, fmt.Sprintf("vlans [%s] are not carried by port %s of switch %s connected to %s",
+				missing.VidSetToString(), ls.LLDPNeighbor.PortID, switchName(ls.LLDPNeighbor), ls.Name))
+		}
+	}
+	if !checked {
+		return false, nil
+	}
+
+	networkv1.TrunkVlansReachable.SetStatusBool(vs, len(messages) == 0)
+	networkv1.TrunkVlansReachable.Reason(vs, reasonLLDP)
+	networkv1.TrunkVlansReachable.Message(vs, strings.Join(messages, "; "))
+
+	return true, nil
+}
+
+func (h Handler) checkTrunkVlansByProbe(vs *networkv1.VlanStatus, cnName string, desired *utils.VlanIDSet, prober *probe.Prober) error {
+	vss, err := h.vsCache.List(labels.Set{utils.KeyClusterNetworkLabel: cnName}.AsSelector())
+	if err != nil {
+		return err
+	}
+	var peers []string
+	for _, peer := range vss {
+		if peer.Status.Node != h.nodeName && peer.DeletionTimestamp == nil {
+			peers = append(peers, peer.Status.Node)
+		}
+	}
+	sort.Strings(peers)
+
+	networkv1.TrunkVlansReachable.Reason(vs, reasonProbe)
+	if len(peers) == 0 {
+		networkv1.TrunkVlansReachable.Unknown(vs)
+		networkv1.TrunkVlansReachable.Message(vs, "no LLDP vlan information on the uplink and no other nodes to probe")
+		return nil
+	}
+	probeWindow := probeWindowCycles * prober.Cycle()
+	if time.Since(prober.StartedAt()) < probeWindow {
+		networkv1.TrunkVlansReachable.Unknown(vs)
+		networkv1.TrunkVlansReachable.Message(vs, fmt.Sprintf("probing vlans with nodes %v", peers))
+		return nil
+	}
+
+	heard := prober.Heard(time.Now().Add(-probeWindow))
+	unreachable := utils.NewVlanIDSet()
+	if err := desired.WalkVIDs(cnName, func(vid uint16) error {
+		if len(heard[vid]) == 0 {
+			return unreachable.SetUint16VID(vid)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if unreachable.GetVlanCount() == 0 {
+		networkv1.TrunkVlansReachable.True(vs)
+		networkv1.TrunkVlansReachable.Message(vs, "")
+	} else {
+		networkv1.TrunkVlansReachable.False(vs)
+		networkv1.TrunkVlansReachable.Message(vs, fmt.Sprintf("vlans [%s] are unreachable, no probe is received from nodes %v",
+			unreachable.VidSetToString(), peers))
+	}
+
+	return nil
+}
+
+func switchName(n *networkv1.LLDPNeighbor) string {
+	if n.SystemName != "" {
+		return n.SystemName
+	}
+	return n.ChassisID
+}
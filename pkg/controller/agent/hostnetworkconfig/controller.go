@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/rancher/wrangler/pkg/condition"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -16,7 +20,9 @@ import (
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/dhcp"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/resolvconf"
 	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
@@ -27,20 +33,29 @@ const (
 	ControllerName = "harvester-network-hostnetworkconfig-controller"
 	IPModeDHCP     = "dhcp"
 	IPModeStatic   = "static"
+	IPModeSLAAC    = "slaac"
+	IPModePool     = "pool"
+
+	// the addresses configured by SLAAC or DHCPv6 appear asynchronously, recheck the status until they are assigned
+	addressCheckInterval = 10 * time.Second
 )
 
 type Handler struct {
-	nodeName          string
-	nodeClient        ctlcorev1.NodeClient
-	nodeCache         ctlcorev1.NodeCache
-	hostNetworkClient ctlnetworkv1.HostNetworkConfigClient
-	hostNetworkCache  ctlnetworkv1.HostNetworkConfigCache
-	cnCache           ctlnetworkv1.ClusterNetworkCache
-	cnController      ctlnetworkv1.ClusterNetworkController
-
-	mu            sync.Mutex
-	leaseManagers map[string]*LeaseManager
-	mgmtIntfName  string
+	nodeName              string
+	nodeClient            ctlcorev1.NodeClient
+	nodeCache             ctlcorev1.NodeCache
+	hostNetworkClient     ctlnetworkv1.HostNetworkConfigClient
+	hostNetworkCache      ctlnetworkv1.HostNetworkConfigCache
+	hostNetworkController ctlnetworkv1.HostNetworkConfigController
+	cnCache               ctlnetworkv1.ClusterNetworkCache
+	cnController          ctlnetworkv1.ClusterNetworkController
+
+	mu                  sync.Mutex
+	leaseManagers       map[string]*LeaseManager
+	dhcpv6LeaseManagers map[string]*DHCPv6LeaseManager
+	mgmtIntfName        string
+	resolvConf          string
+	leaseStore          *dhcp.Store
 }
 
 func Register(ctx context.Context, management *config.Management) error {
@@ -51,14 +66,18 @@ func Register(ctx context.Context, management *config.Management) error {
 	var err error
 
 	handler := &Handler{
-		nodeName:          management.Options.NodeName,
-		nodeClient:        nodes,
-		nodeCache:         nodes.Cache(),
-		hostNetworkClient: hns,
-		hostNetworkCache:  hns.Cache(),
-		cnCache:           cns.Cache(),
-		cnController:      cns,
-		leaseManagers:     make(map[string]*LeaseManager),
+		nodeName:              management.Options.NodeName,
+		nodeClient:            nodes,
+		nodeCache:             nodes.Cache(),
+		hostNetworkClient:     hns,
+		hostNetworkCache:      hns.Cache(),
+		hostNetworkController: hns,
+		cnCache:               cns.Cache(),
+		cnController:          cns,
+		leaseManagers:         make(map[string]*LeaseManager),
+		dhcpv6LeaseManagers:   make(map[string]*DHCPv6LeaseManager),
+		resolvConf:            management.Options.HostResolvConf,
+		leaseStore:            dhcp.NewStore(management.Options.DHCPLeaseDir),
 	}
 
 	if mgmtIntf, err = iface.GetMgmtInterface(); err != nil {
@@ -138,14 +157,43 @@ func (h *Handler) OnChange(_ string, hnc *networkv1.HostNetworkConfig) (*network
 			return nil, fmt.Errorf("add node annotation to node %s for host network config %s failed, error: %w", h.nodeName, hnc.Name, err)
 		}
 
-		err := h.setHostNetworkPerNodeStatus(hnc, true, nil)
+		// the addresses, the routes and the DNS settings may be changed after the interface is set up
+		vlanIntfName := utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID)
+		if err := h.setupDNS(hnc, vlanIntfName); err != nil {
+			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		}
+
+		v, err := vlan.GetVlan(hnc.Spec.ClusterNetwork)
 		if err != nil {
+			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		}
+		bridgelink, err := v.GetBridgelink()
+		if err != nil {
+			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		}
+
+		ipv4Err := h.setupIPv4Address(hnc, bridgelink)
+		if ipv4Err == nil {
+			ipv4Err = h.setupIPv4Routes(hnc, vlanIntfName)
+		}
+		if ipv4Err == nil {
+			ipv4Err = h.setupPolicyRouting(hnc, vlanIntfName, netlink.FAMILY_V4)
+		}
+		// the DHCPv6 lease manager is started again after the agent restarts, and the mode or the static address may be
+		// changed
+		ipv6Err := h.setupIPv6Address(hnc, bridgelink, vlanIntfName)
+		if ipv6Err == nil {
+			ipv6Err = h.setupIPv6Routes(hnc, vlanIntfName)
+		}
+		if ipv6Err == nil && hnc.Spec.IPv6Mode != "" {
+			ipv6Err = h.setupPolicyRouting(hnc, vlanIntfName, netlink.FAMILY_V6)
+		}
+		if err := h.updateHostNetworkFamilyStatus(hnc, ipv4Err, ipv6Err); err != nil {
 			return nil, err
 		}
 		return hnc, nil
 	}
 
-	var addr string
 	var bridgelink *iface.Link
 
 	v, err := vlan.GetVlan(hnc.Spec.ClusterNetwork)
@@ -154,6 +202,10 @@ func (h *Handler) OnChange(_ string, hnc *networkv1.HostNetworkConfig) (*network
 			logrus.Infof("cluster network %s is not set on this node, skip", hnc.Spec.ClusterNetwork)
 			//stop and delete all lease manaagers assosciated with the cluster network (if uplink removed due to vlanconfig changes/deletion)
 			h.stopLeaseManager(utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID))
+			h.stopDHCPv6LeaseManager(utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID))
+			if err := resolvconf.Update(h.resolvConf, utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID), nil); err != nil {
+				return nil, err
+			}
 			return nil, nil
 		}
 		return hnc, h.updateHostNetworkReadyStatus(hnc, err)
@@ -177,44 +229,197 @@ func (h *Handler) OnChange(_ string, hnc *networkv1.HostNetworkConfig) (*network
 		return nil, fmt.Errorf("wake up cluster network %s failed, error: %w", hnc.Spec.ClusterNetwork, err)
 	}
 
+	if err := h.setupDNS(hnc, utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID)); err != nil {
+		return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+	}
+
+	vlanIntfName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID)
+	ipv4Err := h.setupIPv4(hnc, bridgelink)
+	if ipv4Err == nil {
+		ipv4Err = h.setupPolicyRouting(hnc, vlanIntfName, netlink.FAMILY_V4)
+	}
+	ipv6Err := h.setupIPv6(hnc, bridgelink)
+	if ipv6Err == nil && hnc.Spec.IPv6Mode != "" {
+		ipv6Err = h.setupPolicyRouting(hnc, vlanIntfName, netlink.FAMILY_V6)
+	}
+
+	//update host network config status of each address family, it's ready only if all the families are set up
+	if updateErr := h.updateHostNetworkFamilyStatus(hnc, ipv4Err, ipv6Err); updateErr != nil {
+		return hnc, updateErr
+	}
+
+	// update node annotation to set the vlan sub interface to be used as underlay (if underlay is enabled)
+	// and set to default mgmt interface if underlay is not enabled
+	if err := h.addNodeAnnotation(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID), hnc.Spec.Underlay); err != nil {
+		return nil, fmt.Errorf("add node annotation to node %s for host network config %s failed, error: %w", h.nodeName, hnc.Name, err)
+	}
+
+	return hnc, nil
+}
+
+func (h *Handler) setupIPv4(hnc *networkv1.HostNetworkConfig, bridgelink *iface.Link) error {
 	switch hnc.Spec.Mode {
 	case IPModeDHCP:
-		if err = h.startLeaseManager(bridgelink, hnc.Spec.VlanID); err != nil {
-			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		routes, err := hostRoutesOfFamily(hnc.Spec.Routes, netlink.FAMILY_V4)
+		if err != nil {
+			return err
 		}
+		// the vlan sub-interface takes the MAC address of the bridge
+		identity := dhcp.NewIdentity(hnc.Spec.DHCPOptions, h.nodeName, bridgelink.Attrs().HardwareAddr)
+		return h.startLeaseManager(hnc.Name, bridgelink, hnc.Spec.VlanID, identity, routes, dnsFromSpec(hnc.Spec.DNS),
+			hnc.Spec.PolicyRouting)
 
-	case IPModeStatic:
+	case IPModeStatic, IPModePool:
 		// stop lease manager if exists (previously in dhcp mode)
 		h.stopLeaseManager(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID))
 
-		if addr, err = findMatchingIPfromNode(h.nodeName, hnc.Spec.HostIPs); err != nil {
-			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		addr, err := h.assignedIP(hnc)
+		if err != nil {
+			return err
 		}
 
 		if err := bridgelink.SetIPAddress(addr, hnc.Spec.VlanID); err != nil {
-			return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+			return err
 		}
+
+		return h.setupIPv4Routes(hnc, utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID))
 	default:
-		err = fmt.Errorf("unsupported ip assignment mode %s for host network config %s", hnc.Spec.Mode, hnc.Name)
-		return hnc, h.updateHostNetworkReadyStatus(hnc, err)
+		return fmt.Errorf("unsupported ip assignment mode %s for host network config %s", hnc.Spec.Mode, hnc.Name)
 	}
+}
 
-	//success case, update host network config status to ready
-	if updateErr := h.updateHostNetworkReadyStatus(hnc, nil); updateErr != nil {
-		return hnc, updateErr
+// setupIPv6 configures the IPv6 address of the host network interface. The router advertisements are accepted in
+// slaac and dhcp mode to learn the on-link prefix and the default route, but only in slaac mode the address is
+// generated from the advertised prefix.
+func (h *Handler) setupIPv6(hnc *networkv1.HostNetworkConfig, bridgelink *iface.Link) error {
+	vlanIntfName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID)
+
+	if err := h.setupIPv6Address(hnc, bridgelink, vlanIntfName); err != nil {
+		return err
 	}
 
-	// update node annotation to set the vlan sub interface to be used as underlay (if underlay is enabled)
-	// and set to default mgmt interface if underlay is not enabled
-	if err := h.addNodeAnnotation(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID), hnc.Spec.Underlay); err != nil {
-		return nil, fmt.Errorf("add node annotation to node %s for host network config %s failed, error: %w", h.nodeName, hnc.Name, err)
+	return h.setupIPv6Routes(hnc, vlanIntfName)
+}
+
+func (h *Handler) setupIPv6Address(hnc *networkv1.HostNetworkConfig, bridgelink *iface.Link, vlanIntfName string) error {
+
+	if hnc.Spec.IPv6Mode != IPModeDHCP {
+		h.stopDHCPv6LeaseManager(vlanIntfName)
 	}
 
-	return hnc, nil
+	switch hnc.Spec.IPv6Mode {
+	case "":
+		return iface.SetIPv6AutoConf(vlanIntfName, false, false)
+
+	case IPModeSLAAC:
+		return iface.SetIPv6AutoConf(vlanIntfName, true, true)
+
+	case IPModeDHCP:
+		if err := iface.SetIPv6AutoConf(vlanIntfName, true, false); err != nil {
+			return err
+		}
+		return h.startDHCPv6LeaseManager(hnc.Name, bridgelink, hnc.Spec.VlanID)
+
+	case IPModeStatic:
+		if err := iface.SetIPv6AutoConf(vlanIntfName, false, false); err != nil {
+			return err
+		}
+
+		addr, err := findMatchingIPfromNode(h.nodeName, hnc.Spec.HostIPv6s)
+		if err != nil {
+			return err
+		}
+
+		return bridgelink.SetIPAddress(addr, hnc.Spec.VlanID)
+	default:
+		return fmt.Errorf("unsupported ipv6 assignment mode %s for host network config %s", hnc.Spec.IPv6Mode, hnc.Name)
+	}
+}
+
+// setupIPv4Routes installs the IPv4 routes of the host network. In dhcp mode the routes are handed to the lease
+// manager which installs them together with the routes from the lease.
+func (h *Handler) setupIPv4Routes(hnc *networkv1.HostNetworkConfig, vlanIntfName string) error {
+	routes, err := hostRoutesOfFamily(hnc.Spec.Routes, netlink.FAMILY_V4)
+	if err != nil {
+		return err
+	}
+
+	if hnc.Spec.Mode == IPModeDHCP {
+		lm := h.getLeaseManager(vlanIntfName)
+		if lm == nil {
+			return nil
+		}
+		return lm.SetStaticConfig(routes, dnsFromSpec(hnc.Spec.DNS), hnc.Spec.PolicyRouting)
+	}
+
+	if hnc.Spec.PolicyRouting {
+		routes, _ = splitDefaultRoutes(routes)
+	}
+
+	return iface.EnsureHostRoutes(vlanIntfName, netlink.FAMILY_V4, routes)
+}
+
+// setupIPv6Routes installs the IPv6 routes of the host network, the routes learnt from the router advertisements are
+// managed by the kernel
+func (h *Handler) setupIPv6Routes(hnc *networkv1.HostNetworkConfig, vlanIntfName string) error {
+	var routes []iface.HostRoute
+	if hnc.Spec.IPv6Mode != "" {
+		var err error
+		if routes, err = hostRoutesOfFamily(hnc.Spec.Routes, netlink.FAMILY_V6); err != nil {
+			return err
+		}
+	}
+
+	if hnc.Spec.PolicyRouting {
+		routes, _ = splitDefaultRoutes(routes)
+	}
+
+	return iface.EnsureHostRoutes(vlanIntfName, netlink.FAMILY_V6, routes)
+}
+
+// setupPolicyRouting copies the routes of the family into the routing table dedicated to the host network together
+// with the default routes kept out of the main table, and routes the traffic from the host addresses through it, or
+// removes the table and the rules if policy routing is disabled
+func (h *Handler) setupPolicyRouting(hnc *networkv1.HostNetworkConfig, vlanIntfName string, family int) error {
+	if !hnc.Spec.PolicyRouting {
+		return iface.DelPolicyRouting(vlanIntfName)
+	}
+
+	if family == netlink.FAMILY_V4 && hnc.Spec.Mode == IPModeDHCP {
+		lm := h.getLeaseManager(vlanIntfName)
+		if lm == nil {
+			return nil
+		}
+		return iface.EnsurePolicyRouting(vlanIntfName, family, lm.DefaultRoutes())
+	}
+
+	routes, err := hostRoutesOfFamily(hnc.Spec.Routes, family)
+	if err != nil {
+		return err
+	}
+	_, defaults := splitDefaultRoutes(routes)
+
+	return iface.EnsurePolicyRouting(vlanIntfName, family, defaults)
+}
+
+// setupDNS writes the DNS settings of the host network to the node resolv.conf. In dhcp mode the DNS settings from
+// the lease are written by the lease manager if they aren't specified.
+func (h *Handler) setupDNS(hnc *networkv1.HostNetworkConfig, vlanIntfName string) error {
+	if hnc.Spec.DNS == nil && hnc.Spec.Mode == IPModeDHCP {
+		return nil
+	}
+
+	return resolvconf.Update(h.resolvConf, vlanIntfName, dnsFromSpec(hnc.Spec.DNS))
 }
 
 func (h *Handler) updateHostNetworkReadyStatus(hnc *networkv1.HostNetworkConfig, l3setupErr error) error {
-	if statusUpdateErr := h.setHostNetworkStatus(hnc, l3setupErr); statusUpdateErr != nil {
+	return h.updateHostNetworkFamilyStatus(hnc, l3setupErr, l3setupErr)
+}
+
+func (h *Handler) updateHostNetworkFamilyStatus(hnc *networkv1.HostNetworkConfig, ipv4Err, ipv6Err error) error {
+	l3setupErr := joinFamilyErrors(hnc, ipv4Err, ipv6Err)
+
+	if statusUpdateErr := h.setHostNetworkPerNodeStatus(hnc, ipv4Err, ipv6Err); statusUpdateErr != nil {
 		return fmt.Errorf("set host network %s unready failed, error: %w configErr: %v", hnc.Name, statusUpdateErr, l3setupErr)
 	}
 
@@ -249,6 +454,20 @@ func (h *Handler) removeHostNetworkInterface(hnc *networkv1.HostNetworkConfig, o
 	}
 
 	h.stopLeaseManager(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID))
+	h.stopDHCPv6LeaseManager(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID))
+
+	vlanIntfName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID)
+	if err := iface.DelHostRoutes(vlanIntfName); err != nil {
+		return nil, fmt.Errorf("del routes of %s failed, error: %w", vlanIntfName, err)
+	}
+
+	if err := iface.DelPolicyRouting(vlanIntfName); err != nil {
+		return nil, fmt.Errorf("del policy routing of %s failed, error: %w", vlanIntfName, err)
+	}
+
+	if err := resolvconf.Update(h.resolvConf, vlanIntfName, nil); err != nil {
+		return nil, fmt.Errorf("del dns settings of %s failed, error: %w", vlanIntfName, err)
+	}
 
 	if err := bridgelink.DelVlanSubInterface(hnc.Spec.VlanID); err != nil {
 		return nil, fmt.Errorf("del vlan subinterface %d failed for %s, error: %w", hnc.Spec.VlanID, v.Bridge().Name, err)
@@ -290,6 +509,39 @@ func (h *Handler) wakeUpClusterNetwork(clusterNetwork string) error {
 	return err
 }
 
+// assignedIP returns the IP specified for the node in static mode or allocated to the node by the manager in pool mode
+func (h *Handler) assignedIP(hnc *networkv1.HostNetworkConfig) (string, error) {
+	if hnc.Spec.Mode != IPModePool {
+		return findMatchingIPfromNode(h.nodeName, hnc.Spec.HostIPs)
+	}
+
+	if addr := hnc.Status.Allocations[h.nodeName]; addr != "" {
+		return string(addr), nil
+	}
+
+	return "", fmt.Errorf("no IP allocated from the pool for node %s yet", h.nodeName)
+}
+
+// setupIPv4Address keeps the address of the existing host network interface, the IP allocated from the pool may be
+// changed, the address refused for a conflict is detected again and the DHCP lease has to be verified again after
+// the agent restarts
+func (h *Handler) setupIPv4Address(hnc *networkv1.HostNetworkConfig, bridgelink *iface.Link) error {
+	// the lease manager is started only if it's not running yet
+	if hnc.Spec.Mode == IPModeDHCP {
+		return h.setupIPv4(hnc, bridgelink)
+	}
+
+	// stop lease manager if exists (previously in dhcp mode), its renewals would replace the address
+	h.stopLeaseManager(utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, hnc.Spec.VlanID))
+
+	addr, err := h.assignedIP(hnc)
+	if err != nil {
+		return err
+	}
+
+	return bridgelink.SetIPAddress(addr, hnc.Spec.VlanID)
+}
+
 func findMatchingIPfromNode(nodeName string, hostIPs map[string]networkv1.IPAddr) (string, error) {
 	addr := hostIPs[nodeName]
 	if addr != "" {
@@ -335,7 +587,42 @@ func (h *Handler) removeHostNetworkPerNodeStatus(hnc *networkv1.HostNetworkConfi
 	return nil
 }
 
-func (h *Handler) setHostNetworkPerNodeStatus(hnc *networkv1.HostNetworkConfig, ready bool, setupErr error) error {
+// setHostNetworkPerNodeStatus reports the addresses and the readiness of each address family on this node. A family is
+// ready if it's set up without error and the interface has an address of the family.
+func (h *Handler) setHostNetworkPerNodeStatus(hnc *networkv1.HostNetworkConfig, ipv4Err, ipv6Err error) error {
+	vlanIntfName := utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID)
+
+	ipv4Addrs, ipv4Ready, ipv4Message := getFamilyStatus(vlanIntfName, netlink.FAMILY_V4, ipv4Err)
+	conditions := []map[string]interface{}{
+		familyCondition(networkv1.IPv4Ready, ipv4Ready, ipv4Message),
+	}
+	ready, messages := ipv4Ready, []string{}
+	if !ipv4Ready {
+		messages = append(messages, "ipv4: "+ipv4Message)
+	}
+
+	var ipv6Addrs []string
+	if hnc.Spec.IPv6Mode != "" {
+		var ipv6Ready bool
+		var ipv6Message string
+		ipv6Addrs, ipv6Ready, ipv6Message = getFamilyStatus(vlanIntfName, netlink.FAMILY_V6, ipv6Err)
+		conditions = append(conditions, familyCondition(networkv1.IPv6Ready, ipv6Ready, ipv6Message))
+		ready = ready && ipv6Ready
+		if !ipv6Ready {
+			messages = append(messages, "ipv6: "+ipv6Message)
+		}
+	}
+
+	readyMessage := ""
+	if !ready {
+		readyMessage = fmt.Sprintf("setup l3 connectivity failed: %s", strings.Join(messages, "; "))
+	}
+	conditions = append([]map[string]interface{}{familyCondition(networkv1.Ready, ready, readyMessage)}, conditions...)
+
+	h.mu.Lock()
+	dhcpLease := h.leaseManagers[vlanIntfName].Status()
+	h.mu.Unlock()
+
 	patchPayload := map[string]interface{}{
 		"status": map[string]interface{}{
 			"nodeStatus": map[string]interface{}{
@@ -343,25 +630,11 @@ func (h *Handler) setHostNetworkPerNodeStatus(hnc *networkv1.HostNetworkConfig,
 					"clusterNetwork": hnc.Spec.ClusterNetwork,
 					"vlanID":         hnc.Spec.VlanID,
 					"mode":           hnc.Spec.Mode,
-					"conditions": []map[string]interface{}{
-						{
-							"type": networkv1.Ready,
-							"status": func() string {
-								if ready {
-									return "True"
-								} else {
-									return "False"
-								}
-							}(),
-							"message": func() string {
-								if ready {
-									return ""
-								} else {
-									return fmt.Sprintf("setup l3 connectivity failed: %v", setupErr)
-								}
-							}(),
-						},
-					},
+					"ipv6Mode":       hnc.Spec.IPv6Mode,
+					"ipv4Addresses":  ipv4Addrs,
+					"ipv6Addresses":  ipv6Addrs,
+					"conditions":     conditions,
+					"dhcpLease":      dhcpLease,
 				},
 			},
 		},
@@ -382,15 +655,60 @@ func (h *Handler) setHostNetworkPerNodeStatus(hnc *networkv1.HostNetworkConfig,
 		return fmt.Errorf("failed to patch HostNetworkConfig status for node %s: %w", h.nodeName, err)
 	}
 
+	// the addresses assigned by SLAAC or DHCPv6 may not be there yet, while the setup errors are retried by the caller
+	if !ready && ipv4Err == nil && ipv6Err == nil {
+		h.hostNetworkController.EnqueueAfter(hnc.Name, addressCheckInterval)
+	}
+
 	return nil
 }
 
-func (h *Handler) setHostNetworkStatus(hnc *networkv1.HostNetworkConfig, setupErr error) error {
-	if setupErr != nil {
-		return h.setHostNetworkPerNodeStatus(hnc, false, setupErr)
-	} else {
-		return h.setHostNetworkPerNodeStatus(hnc, true, nil)
+// getFamilyStatus returns the addresses of the family on the interface, whether the family is ready and the reason
+// if it isn't ready
+func getFamilyStatus(vlanIntfName string, family int, setupErr error) ([]string, bool, string) {
+	addrs, err := iface.GetHostAddresses(vlanIntfName, family)
+	if err != nil && !errors.As(err, &netlink.LinkNotFoundError{}) {
+		logrus.Warnf("get addresses of %s failed, error: %s", vlanIntfName, err.Error())
 	}
+
+	switch {
+	case setupErr != nil:
+		return addrs, false, setupErr.Error()
+	case len(addrs) == 0:
+		return addrs, false, "no address is assigned yet"
+	default:
+		return addrs, true, ""
+	}
+}
+
+func familyCondition(conditionType condition.Cond, ready bool, message string) map[string]interface{} {
+	status := "False"
+	if ready {
+		status = "True"
+	}
+
+	return map[string]interface{}{
+		"type":    conditionType,
+		"status":  status,
+		"message": message,
+	}
+}
+
+// joinFamilyErrors returns the setup error of the host network, the IPv6 error is ignored if IPv6 isn't configured
+// and the error shared by both families is reported once
+func joinFamilyErrors(hnc *networkv1.HostNetworkConfig, ipv4Err, ipv6Err error) error {
+	if hnc.Spec.IPv6Mode == "" || ipv6Err == ipv4Err {
+		return ipv4Err
+	}
+
+	return errors.Join(ipv4Err, ipv6Err)
+}
+
+func (h *Handler) getLeaseManager(vlanIntfName string) *LeaseManager {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.leaseManagers[vlanIntfName]
 }
 
 func (h *Handler) stopLeaseManager(vlanIntfName string) {
@@ -407,7 +725,7 @@ func (h *Handler) stopLeaseManager(vlanIntfName string) {
 	lm.Stop()
 }
 
-func (h *Handler) getOrCreateLeaseManager(bridgelink *iface.Link, vlanID uint16) (*LeaseManager, error) {
+func (h *Handler) getOrCreateLeaseManager(hncName string, bridgelink *iface.Link, vlanID uint16, identity *dhcp.Identity) (*LeaseManager, error) {
 	vlanIntfName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, vlanID)
 
 	h.mu.Lock()
@@ -415,10 +733,17 @@ func (h *Handler) getOrCreateLeaseManager(bridgelink *iface.Link, vlanID uint16)
 	h.mu.Unlock()
 
 	if lm != nil {
-		return lm, nil
+		if reflect.DeepEqual(lm.identity, identity) {
+			return lm, nil
+		}
+		// the lease is bound to the identity, request a new lease with the new identity
+		logrus.Infof("dhcp options of %s are changed, restart the lease manager", vlanIntfName)
+		h.stopLeaseManager(vlanIntfName)
 	}
 
-	newLM, err := NewLeaseManager(vlanIntfName, bridgelink, vlanID)
+	// the state transitions of the lease are reported in the status by the next sync
+	notify := func() { h.hostNetworkController.Enqueue(hncName) }
+	newLM, err := NewLeaseManager(vlanIntfName, bridgelink, vlanID, identity, h.resolvConf, h.leaseStore, notify)
 	if err != nil {
 		return nil, err
 	}
@@ -431,17 +756,75 @@ func (h *Handler) getOrCreateLeaseManager(bridgelink *iface.Link, vlanID uint16)
 	return newLM, nil
 }
 
-func (h *Handler) startLeaseManager(bridgelink *iface.Link, vlanID uint16) (err error) {
-	lm, err := h.getOrCreateLeaseManager(bridgelink, vlanID)
+func (h *Handler) startLeaseManager(hncName string, bridgelink *iface.Link, vlanID uint16, identity *dhcp.Identity,
+	routes []iface.HostRoute, dns *resolvconf.Config, policyRouting bool) (err error) {
+	lm, err := h.getOrCreateLeaseManager(hncName, bridgelink, vlanID, identity)
 	if err != nil {
 		return err
 	}
 
+	if err := lm.SetStaticConfig(routes, dns, policyRouting); err != nil {
+		return err
+	}
+
 	if err := lm.Start(context.Background()); err != nil {
 		return err
 	}
 
-	return nil
+	return lm.Err()
+}
+
+func (h *Handler) stopDHCPv6LeaseManager(vlanIntfName string) {
+	h.mu.Lock()
+	lm := h.dhcpv6LeaseManagers[vlanIntfName]
+	if lm != nil {
+		delete(h.dhcpv6LeaseManagers, vlanIntfName)
+	}
+	h.mu.Unlock()
+
+	if lm == nil {
+		return
+	}
+	lm.Stop()
+}
+
+func (h *Handler) getOrCreateDHCPv6LeaseManager(hncName string, bridgelink *iface.Link, vlanID uint16) (*DHCPv6LeaseManager, error) {
+	vlanIntfName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, vlanID)
+
+	h.mu.Lock()
+	lm := h.dhcpv6LeaseManagers[vlanIntfName]
+	h.mu.Unlock()
+
+	if lm != nil {
+		return lm, nil
+	}
+
+	// the expiry of the lease is reported in the status by the next sync
+	notify := func() { h.hostNetworkController.Enqueue(hncName) }
+	newLM, err := NewDHCPv6LeaseManager(vlanIntfName, bridgelink, vlanID, notify)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.dhcpv6LeaseManagers[vlanIntfName] = newLM
+
+	return newLM, nil
+}
+
+func (h *Handler) startDHCPv6LeaseManager(hncName string, bridgelink *iface.Link, vlanID uint16) error {
+	lm, err := h.getOrCreateDHCPv6LeaseManager(hncName, bridgelink, vlanID)
+	if err != nil {
+		return err
+	}
+
+	if err := lm.Start(context.Background()); err != nil {
+		return err
+	}
+
+	return lm.Err()
 }
 
 func (h *Handler) addNodeAnnotation(underlayIntfName string, underlay bool) error {
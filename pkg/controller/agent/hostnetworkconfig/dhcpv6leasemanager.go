@@ -0,0 +1,431 @@
+package hostnetworkconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenk/backoff"
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/nclient6"
+	"github.com/insomniacslk/dhcp/iana"
+	"github.com/sirupsen/logrus"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+const (
+	// the link-local address of a new interface is usable after the duplicate address detection which takes about
+	// one second by default
+	linkLocalTimeout = 10 * time.Second
+	releaseTimeout   = 3 * time.Second
+
+	// the renewal time if neither T1 nor the preferred lifetime is provided
+	defaultDHCPv6RenewalTime = 30 * time.Second
+	// the failed attempts are retried from the minimum up to the maximum delay as the DHCPv4 client does
+	dhcpv6BackoffMin = 4 * time.Second
+	dhcpv6BackoffMax = 64 * time.Second
+)
+
+// DHCPv6LeaseManager obtains an IPv6 address from the DHCPv6 server and renews it. The server only assigns the
+// address, the on-link prefix and the default route are learnt from the router advertisements, so the address is
+// assigned with a /128 prefix.
+type DHCPv6LeaseManager struct {
+	iface  string
+	link   *iface.Link
+	vlanID uint16
+	client *nclient6.Client
+	// notify is called when the lease expires to report it in the status
+	notify func()
+
+	mu      sync.Mutex
+	lease   *dhcpv6Lease
+	running bool
+	// expired is the error of the last lease which expired before it's extended, until a new lease is obtained
+	expired error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// dhcpv6Lease is the address in the reply with the times to renew, rebind and give it up, which are counted from
+// the time the reply is received
+type dhcpv6Lease struct {
+	reply  *dhcpv6.Message
+	ipAddr string
+	t1     time.Time
+	t2     time.Time
+	expiry time.Time
+}
+
+func NewDHCPv6LeaseManager(ifName string, link *iface.Link, vlanID uint16, notify func()) (*DHCPv6LeaseManager, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), linkLocalTimeout)
+	defer cancel()
+
+	// the DHCPv6 client sends from the link-local address
+	if err := iface.WaitLinkLocalAddress(ctx, ifName); err != nil {
+		return nil, err
+	}
+
+	c, err := nclient6.New(ifName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DHCPv6LeaseManager{
+		iface:  ifName,
+		link:   link,
+		vlanID: vlanID,
+		client: c,
+		notify: notify,
+	}, nil
+}
+
+// Start obtains the lease and maintains it in the background until the manager is stopped
+func (lm *DHCPv6LeaseManager) Start(ctx context.Context) error {
+	lm.mu.Lock()
+	if lm.running {
+		lm.mu.Unlock()
+		return nil
+	}
+	lm.mu.Unlock()
+
+	lm.ctx, lm.cancel = context.WithCancel(ctx)
+
+	if err := lm.acquire(); err != nil {
+		lm.cancel()
+		return err
+	}
+
+	lm.mu.Lock()
+	lm.running = true
+	lm.mu.Unlock()
+
+	go lm.run()
+
+	return nil
+}
+
+// Err returns the error of the last lease if it expired, a new lease is requested in the background
+func (lm *DHCPv6LeaseManager) Err() error {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	return lm.expired
+}
+
+// acquire requests a new lease and assigns the address, the address in use by another host is declined
+func (lm *DHCPv6LeaseManager) acquire() error {
+	reply, err := lm.request()
+	if err != nil {
+		return err
+	}
+
+	return lm.bind(reply)
+}
+
+// bind assigns the address in the reply and replaces the lease
+func (lm *DHCPv6LeaseManager) bind(reply *dhcpv6.Message) error {
+	lease, err := newDHCPv6Lease(reply, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := lm.link.SetIPAddress(lease.ipAddr, lm.vlanID); err != nil {
+		lm.declineIfConflict(reply, err)
+		return err
+	}
+
+	lm.mu.Lock()
+	lm.lease = lease
+	lm.expired = nil
+	lm.mu.Unlock()
+
+	return nil
+}
+
+// request runs the four-message exchange of solicit, advertise, request and reply
+func (lm *DHCPv6LeaseManager) request() (*dhcpv6.Message, error) {
+	advertise, err := lm.client.Solicit(lm.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := lm.client.Request(lm.ctx, advertise)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ipAddrFromReply(reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// extend renews the lease with the leasing server, or rebinds it with any server as RFC 8415 section 18.2.5
+// specifies by leaving the server identifier out, before the deadline
+func (lm *DHCPv6LeaseManager) extend(lease *dhcpv6Lease, deadline time.Time, renewing bool) (*dhcpv6.Message, error) {
+	msgType := dhcpv6.MessageTypeRenew
+	if !renewing {
+		msgType = dhcpv6.MessageTypeRebind
+	}
+	msg, err := newMessageFromReply(lease.reply, msgType)
+	if err != nil {
+		return nil, err
+	}
+	if !renewing {
+		msg.Options.Del(dhcpv6.OptionServerID)
+	}
+	msg.AddOption(dhcpv6.OptElapsedTime(0))
+
+	ctx, cancel := context.WithDeadline(lm.ctx, deadline)
+	defer cancel()
+
+	reply, err := lm.client.SendAndRead(ctx, nclient6.AllDHCPRelayAgentsAndServers, msg, nclient6.IsMessageType(dhcpv6.MessageTypeReply))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := ipAddrFromReply(reply); err != nil {
+		return nil, err
+	}
+
+	return reply, nil
+}
+
+// newDHCPv6Lease takes the times of the lease from the IA_NA. The renewal and the rebinding times are 0.5 and 0.8
+// times the preferred lifetime as RFC 8415 section 21.4 recommends if the server leaves them to the client.
+func newDHCPv6Lease(reply *dhcpv6.Message, start time.Time) (*dhcpv6Lease, error) {
+	ipAddr, err := ipAddrFromReply(reply)
+	if err != nil {
+		return nil, err
+	}
+	ia := reply.Options.OneIANA()
+	addr := ia.Options.OneAddress()
+	if addr.ValidLifetime <= 0 {
+		return nil, fmt.Errorf("DHCPv6 address %s is leased with zero valid lifetime", addr.IPv6Addr)
+	}
+
+	t1, t2 := ia.T1, ia.T2
+	if t1 <= 0 {
+		t1 = defaultDHCPv6RenewalTime
+		if addr.PreferredLifetime > 0 {
+			t1 = addr.PreferredLifetime / 2
+		}
+	}
+	if t2 <= 0 {
+		t2 = t1 * 8 / 5
+	}
+	valid := addr.ValidLifetime
+	t1, t2 = min(t1, valid), min(max(t1, t2), valid)
+
+	return &dhcpv6Lease{
+		reply:  reply,
+		ipAddr: ipAddr,
+		t1:     start.Add(t1),
+		t2:     start.Add(t2),
+		expiry: start.Add(valid),
+	}, nil
+}
+
+func newDHCPv6Backoff() *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = dhcpv6BackoffMin
+	b.MaxInterval = dhcpv6BackoffMax
+	b.MaxElapsedTime = 0
+	b.Reset()
+	return b
+}
+
+// run maintains the lease until the manager is stopped. The lease is renewed with the leasing server from T1 and
+// rebound with any server from T2, the failed attempts are retried with backoff. The address is removed once the
+// lease expires, then a new lease is requested.
+func (lm *DHCPv6LeaseManager) run() {
+	b := newDHCPv6Backoff()
+	for lm.ctx.Err() == nil {
+		lm.mu.Lock()
+		lease := lm.lease
+		lm.mu.Unlock()
+
+		var wait time.Duration
+		now := time.Now()
+		switch {
+		case lease == nil:
+			if err := lm.acquire(); err != nil {
+				wait = b.NextBackOff()
+				logrus.Warnf("request dhcpv6 lease on %s failed, retry in %s, error: %s", lm.iface, wait, err.Error())
+			} else {
+				b.Reset()
+			}
+		case now.Before(lease.t1):
+			wait = lease.t1.Sub(now)
+		case now.Before(lease.t2):
+			wait = lm.extendBefore(lease, lease.t2, true, b)
+		case now.Before(lease.expiry):
+			wait = lm.extendBefore(lease, lease.expiry, false, b)
+		default:
+			lm.expire(lease)
+		}
+
+		if wait <= 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-lm.ctx.Done():
+			timer.Stop()
+		}
+	}
+}
+
+// extendBefore extends the lease and binds the new reply, and returns the time to wait before the next attempt if
+// it fails
+func (lm *DHCPv6LeaseManager) extendBefore(lease *dhcpv6Lease, deadline time.Time, renewing bool,
+	b *backoff.ExponentialBackOff) time.Duration {
+	reply, err := lm.extend(lease, deadline, renewing)
+	if err == nil {
+		err = lm.bind(reply)
+	}
+	if err == nil {
+		b.Reset()
+		return 0
+	}
+	if lm.ctx.Err() != nil {
+		return 0
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		logrus.Warnf("extend dhcpv6 lease %s on %s failed, error: %s", lease.ipAddr, lm.iface, err.Error())
+		return 0
+	}
+	wait := min(b.NextBackOff(), remaining)
+	logrus.Warnf("extend dhcpv6 lease %s on %s failed, retry in %s, error: %s", lease.ipAddr, lm.iface, wait, err.Error())
+
+	return wait
+}
+
+// expire removes the address of the expired lease and reports the expiry
+func (lm *DHCPv6LeaseManager) expire(lease *dhcpv6Lease) {
+	logrus.Warnf("dhcpv6 lease %s on %s expired", lease.ipAddr, lm.iface)
+	if err := lm.link.DelIPAddress(lease.ipAddr, lm.vlanID); err != nil {
+		logrus.Errorf("remove dhcpv6 address %s from %s failed, error: %s", lease.ipAddr, lm.iface, err.Error())
+	}
+
+	lm.mu.Lock()
+	lm.lease = nil
+	lm.expired = fmt.Errorf("dhcpv6 lease %s expired at %s", lease.ipAddr, lease.expiry.Format(time.RFC3339))
+	lm.mu.Unlock()
+
+	if lm.notify != nil {
+		lm.notify()
+	}
+}
+
+// Stop releases the lease and closes the client, the manager can't be started again
+func (lm *DHCPv6LeaseManager) Stop() {
+	if lm == nil {
+		return
+	}
+
+	lm.mu.Lock()
+	cancel := lm.cancel
+	lease := lm.lease
+
+	lm.running = false
+	lm.cancel = nil
+	lm.lease = nil
+	lm.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	// Release DHCPv6 lease
+	if lease != nil {
+		if release, err := newMessageFromReply(lease.reply, dhcpv6.MessageTypeRelease); err == nil {
+			ctx, cancelRelease := context.WithTimeout(context.Background(), releaseTimeout)
+			_, _ = lm.client.SendAndRead(ctx, nclient6.AllDHCPRelayAgentsAndServers, release, nclient6.IsMessageType(dhcpv6.MessageTypeReply))
+			cancelRelease()
+		}
+	}
+
+	// the client socket is bound to the DHCPv6 client port of the interface, close it so that a new manager can be
+	// created for the interface
+	_ = lm.client.Close()
+}
+
+// declineIfConflict tells the server the address in the reply is in use by another host as RFC 8415 section 18.2.8
+// specifies
+func (lm *DHCPv6LeaseManager) declineIfConflict(reply *dhcpv6.Message, err error) {
+	var conflict *iface.AddressConflictError
+	if !errors.As(err, &conflict) {
+		return
+	}
+
+	decline, err := newMessageFromReply(reply, dhcpv6.MessageTypeDecline)
+	if err != nil {
+		logrus.Warnf("decline dhcpv6 address %s on %s failed, error: %s", conflict.IP, lm.iface, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(lm.ctx, releaseTimeout)
+	defer cancel()
+	if _, err := lm.client.SendAndRead(ctx, nclient6.AllDHCPRelayAgentsAndServers, decline, nclient6.IsMessageType(dhcpv6.MessageTypeReply)); err != nil {
+		logrus.Warnf("decline dhcpv6 address %s on %s failed, error: %s", conflict.IP, lm.iface, err.Error())
+	}
+}
+
+// newMessageFromReply builds the renew, rebind, release or decline message for the lease in the reply
+func newMessageFromReply(reply *dhcpv6.Message, msgType dhcpv6.MessageType) (*dhcpv6.Message, error) {
+	msg, err := dhcpv6.NewMessage()
+	if err != nil {
+		return nil, err
+	}
+	msg.MessageType = msgType
+
+	cid := reply.GetOneOption(dhcpv6.OptionClientID)
+	if cid == nil {
+		return nil, fmt.Errorf("client ID not provided by DHCPv6 server")
+	}
+	msg.AddOption(cid)
+
+	sid := reply.GetOneOption(dhcpv6.OptionServerID)
+	if sid == nil {
+		return nil, fmt.Errorf("server ID not provided by DHCPv6 server")
+	}
+	msg.AddOption(sid)
+
+	ia := reply.Options.OneIANA()
+	if ia == nil {
+		return nil, fmt.Errorf("IA_NA not provided by DHCPv6 server")
+	}
+	msg.AddOption(ia)
+
+	return msg, nil
+}
+
+func ipAddrFromReply(reply *dhcpv6.Message) (string, error) {
+	if status := reply.Options.Status(); status != nil && status.StatusCode != iana.StatusSuccess {
+		return "", fmt.Errorf("DHCPv6 server replied %s: %s", status.StatusCode, status.StatusMessage)
+	}
+
+	ia := reply.Options.OneIANA()
+	if ia == nil {
+		return "", fmt.Errorf("IA_NA not provided by DHCPv6 server")
+	}
+	if status := ia.Options.Status(); status != nil && status.StatusCode != iana.StatusSuccess {
+		return "", fmt.Errorf("DHCPv6 server replied %s for IA_NA: %s", status.StatusCode, status.StatusMessage)
+	}
+
+	addr := ia.Options.OneAddress()
+	if addr == nil || addr.IPv6Addr.To16() == nil || addr.IPv6Addr.To4() != nil {
+		return "", fmt.Errorf("no IPv6 address obtained from DHCPv6 server")
+	}
+
+	return fmt.Sprintf("%s/128", addr.IPv6Addr.String()), nil
+}
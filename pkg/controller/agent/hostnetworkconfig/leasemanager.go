@@ -2,14 +2,28 @@ package hostnetworkconfig
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/dhcp"
 	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/resolvconf"
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// the router and the DNS options are requested by default
+var requestedOptions = dhcpv4.WithRequestedOptions(
+	dhcpv4.OptionClasslessStaticRoute,
+	dhcpv4.OptionDNSDomainSearchList,
 )
 
 type LeaseManager struct {
@@ -17,30 +31,56 @@ type LeaseManager struct {
 	link   *iface.Link
 	vlanID uint16
 	client *nclient4.Client
+	// dhcpClient maintains the lease through the RFC 2131 states, it is created when the manager starts
+	dhcpClient *dhcp.Client
+	// identity is added to the requests to identify the node to the DHCP servers
+	identity *dhcp.Identity
+	// store persists the lease to verify it rather than obtaining a new one after the agent restarts
+	store *dhcp.Store
+
+	// resolvConf is the path of the node resolv.conf to write the DNS settings from the lease
+	resolvConf string
+	// notify is called when the state of the lease is changed to report it in the status
+	notify func()
 
 	mu      sync.Mutex
-	lease   *nclient4.Lease
 	ipAddr  string
 	running bool
+	// conflict is the error of the last leased address which is in use by another host
+	conflict error
+	// routes and dns are specified by the host network config, the routes are installed together with the routes
+	// from the lease, and the DNS settings from the lease are used only if dns is nil
+	routes []iface.HostRoute
+	dns    *resolvconf.Config
+	// policyRouting installs the default routes in the routing table dedicated to the host network, they're never
+	// installed in the main table to keep the default route of the node
+	policyRouting bool
 
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-func NewLeaseManager(iface string, link *iface.Link, vlanID uint16) (*LeaseManager, error) {
+func NewLeaseManager(iface string, link *iface.Link, vlanID uint16, identity *dhcp.Identity, resolvConf string, store *dhcp.Store,
+	notify func()) (*LeaseManager, error) {
 	c, err := nclient4.New(iface)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LeaseManager{
-		iface:  iface,
-		link:   link,
-		vlanID: vlanID,
-		client: c,
+		iface:      iface,
+		link:       link,
+		vlanID:     vlanID,
+		client:     c,
+		identity:   identity,
+		resolvConf: resolvConf,
+		store:      store,
+		notify:     notify,
 	}, nil
 }
 
+// Start obtains the lease and keeps renewing it in the background until the manager is stopped. The lease stored by
+// the previous run is verified with the server first, so that the address is kept across the restarts.
 func (lm *LeaseManager) Start(ctx context.Context) error {
 	lm.mu.Lock()
 	if lm.running {
@@ -50,101 +90,256 @@ func (lm *LeaseManager) Start(ctx context.Context) error {
 	lm.mu.Unlock()
 
 	lm.ctx, lm.cancel = context.WithCancel(ctx)
+	modifiers := append([]dhcpv4.Modifier{requestedOptions}, lm.identity.Modifiers()...)
+	dhcpClient := dhcp.NewClient(lm.iface, lm.client, lm, modifiers...)
 
-	lease, err := lm.client.Request(lm.ctx)
+	stored, err := lm.store.Load(lm.iface)
 	if err != nil {
+		logrus.Warnf("load dhcp lease of %s failed, request a new lease, error: %s", lm.iface, err.Error())
+	}
+
+	if stored != nil {
+		err = dhcpClient.Reboot(lm.ctx, stored)
+	} else {
+		err = dhcpClient.Acquire(lm.ctx)
+	}
+	// the declined lease is retried in the background after the wait required by RFC 2131, the conflict is
+	// reported by Err until then
+	var conflict *iface.AddressConflictError
+	if err != nil && !errors.As(err, &conflict) {
+		lm.cancel()
 		return err
 	}
 
-	ipAddr, err := ipAddrFromLease(lease)
+	lm.mu.Lock()
+	lm.dhcpClient = dhcpClient
+	lm.running = true
+	lm.mu.Unlock()
+
+	go dhcpClient.Run(lm.ctx)
+
+	return err
+}
+
+// Bound assigns the leased address to the interface if it's changed and applies the routes and the DNS settings
+func (lm *LeaseManager) Bound(lease *dhcp.Lease) error {
+	ipAddr, err := ipAddrFromLease(lease.Lease)
 	if err != nil {
 		return err
 	}
 
-	if ipAddr == "" {
-		return fmt.Errorf("no IP address obtained from DHCP server")
+	lm.mu.Lock()
+	sameIP := ipAddr == lm.ipAddr
+	lm.mu.Unlock()
+
+	if !sameIP {
+		err := lm.link.SetIPAddress(ipAddr, lm.vlanID)
+
+		var conflict *iface.AddressConflictError
+		lm.mu.Lock()
+		if errors.As(err, &conflict) {
+			lm.conflict = err
+		} else if err == nil {
+			lm.ipAddr = ipAddr
+			lm.conflict = nil
+		}
+		lm.mu.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := lm.store.Save(lm.iface, lease); err != nil {
+		logrus.Warnf("save dhcp lease of %s failed, error: %s", lm.iface, err.Error())
+	}
+
+	return lm.applyLease(lease)
+}
+
+// Expired removes the address together with the routes and the DNS settings from the lease, the specified routes
+// are kept out of the main table as they can't be reached without the address
+func (lm *LeaseManager) Expired(lease *dhcp.Lease) error {
+	lm.mu.Lock()
+	lm.ipAddr = ""
+	dns := lm.dns
+	lm.mu.Unlock()
+
+	if err := lm.store.Delete(lm.iface); err != nil {
+		return err
+	}
+
+	// the address of the lease stored by the previous run is on the interface as well
+	if ipAddr, err := ipAddrFromLease(lease.Lease); err == nil {
+		if err := lm.link.DelIPAddress(ipAddr, lm.vlanID); err != nil {
+			return err
+		}
 	}
 
-	if err := lm.link.SetIPAddress(ipAddr, lm.vlanID); err != nil {
+	if err := iface.EnsureHostRoutes(lm.iface, netlink.FAMILY_V4, nil); err != nil {
 		return err
 	}
 
+	if lm.isPolicyRouting() {
+		if err := iface.EnsurePolicyRouting(lm.iface, netlink.FAMILY_V4, nil); err != nil {
+			return err
+		}
+	}
+
+	if dns != nil {
+		return nil
+	}
+
+	return resolvconf.Update(lm.resolvConf, lm.iface, nil)
+}
+
+func (lm *LeaseManager) StateChanged(state dhcp.State, lease *dhcp.Lease) {
+	if lease != nil {
+		logrus.Infof("dhcp lease %s on %s is %s", lease.IP(), lm.iface, state)
+	} else {
+		logrus.Infof("dhcp client on %s is %s", lm.iface, state)
+	}
+
+	if lm.notify != nil {
+		lm.notify()
+	}
+}
+
+// Err returns the error of the last leased address if it's declined for the conflict, the dhcp client requests
+// another lease in the background
+func (lm *LeaseManager) Err() error {
 	lm.mu.Lock()
-	lm.lease = lease
-	lm.ipAddr = ipAddr
-	lm.running = true
+	defer lm.mu.Unlock()
+
+	return lm.conflict
+}
+
+// Status returns the state of the lease reported in the host network config status
+func (lm *LeaseManager) Status() *networkv1.DHCPLeaseStatus {
+	if lm == nil {
+		return nil
+	}
+
+	lm.mu.Lock()
+	dhcpClient := lm.dhcpClient
 	lm.mu.Unlock()
 
-	go lm.renewLoop()
+	if dhcpClient == nil {
+		return nil
+	}
+
+	state, lease := dhcpClient.Status()
+	status := &networkv1.DHCPLeaseStatus{State: string(state)}
+	if lease == nil {
+		return status
+	}
+
+	if ipAddr, err := ipAddrFromLease(lease.Lease); err == nil {
+		status.IP = ipAddr
+	}
+	if server := lease.Server(); server != nil {
+		status.Server = server.String()
+	}
+	status.LeaseStart = newMetaTime(lease.Start)
+	status.RenewalTime = newMetaTime(lease.T1)
+	status.RebindingTime = newMetaTime(lease.T2)
+	status.Expiry = newMetaTime(lease.Expiry)
+
+	return status
+}
 
-	return nil
+func newMetaTime(t time.Time) *metav1.Time {
+	mt := metav1.NewTime(t.Truncate(time.Second))
+	return &mt
 }
 
-func renewalDelay(lease *dhcpv4.DHCPv4) time.Duration {
-	// T1 (option 58), default fallback = 50% of lease
-	if t1 := lease.IPAddressRenewalTime(0); t1 > 0 {
-		return t1
+// SetStaticConfig updates the routes, the DNS settings and the policy routing specified by the host network config,
+// and applies them if the lease is obtained
+func (lm *LeaseManager) SetStaticConfig(routes []iface.HostRoute, dns *resolvconf.Config, policyRouting bool) error {
+	lm.mu.Lock()
+	lm.routes = routes
+	lm.dns = dns
+	lm.policyRouting = policyRouting
+	dhcpClient := lm.dhcpClient
+	lm.mu.Unlock()
+
+	if dhcpClient == nil {
+		return nil
 	}
 
-	// Fallback: 50% of lease time
-	if lt := lease.IPAddressLeaseTime(0); lt > 0 {
-		return lt / 2
+	_, lease := dhcpClient.Status()
+	if lease == nil {
+		return nil
 	}
 
-	// Absolute fallback
-	return 30 * time.Second
+	return lm.applyLease(lease)
 }
 
-func (lm *LeaseManager) renewLoop() {
-	for {
-		lm.mu.Lock()
-		lease := lm.lease
-		lm.mu.Unlock()
+// applyLease installs the specified routes together with the routes from the lease, and writes the DNS settings
+// from the lease unless they are specified. The default route from the lease is installed only in the routing table
+// of the host network if policy routing is enabled, as it would replace the default route of the node.
+func (lm *LeaseManager) applyLease(lease *dhcp.Lease) error {
+	lm.mu.Lock()
+	routes := append([]iface.HostRoute{}, lm.routes...)
+	dns := lm.dns
+	policyRouting := lm.policyRouting
+	lm.mu.Unlock()
+
+	if policyRouting {
+		routes, _ = splitDefaultRoutes(routes)
+	}
+	leaseRoutes, _ := splitDefaultRoutes(routesFromLease(lease.ACK))
+	routes = append(routes, leaseRoutes...)
+	if err := iface.EnsureHostRoutes(lm.iface, netlink.FAMILY_V4, routes); err != nil {
+		return err
+	}
 
-		if lease == nil {
-			return
+	if policyRouting {
+		if err := iface.EnsurePolicyRouting(lm.iface, netlink.FAMILY_V4, lm.defaultRoutes(lease)); err != nil {
+			return err
 		}
+	}
 
-		delay := renewalDelay(lease.ACK)
-		timer := time.NewTimer(delay)
-
-		select {
-		case <-timer.C:
-			newLease, err := lm.client.Renew(lm.ctx, lease)
-			if err != nil {
-				newLease, err = lm.client.Request(lm.ctx)
-				if err != nil {
-					continue
-				}
-			}
+	if dns != nil {
+		return nil
+	}
 
-			ipAddr, err := ipAddrFromLease(newLease)
-			if err != nil {
-				continue
-			}
+	return resolvconf.Update(lm.resolvConf, lm.iface, dnsFromLease(lease.ACK))
+}
 
-			lm.mu.Lock()
-			lm.lease = newLease
-			sameIP := ipAddr == lm.ipAddr
-			lm.mu.Unlock()
+// DefaultRoutes returns the specified default routes and the default route from the current lease, which are
+// installed in the routing table of the host network if policy routing is enabled
+func (lm *LeaseManager) DefaultRoutes() []iface.HostRoute {
+	lm.mu.Lock()
+	dhcpClient := lm.dhcpClient
+	lm.mu.Unlock()
 
-			if sameIP {
-				continue
-			}
+	var lease *dhcp.Lease
+	if dhcpClient != nil {
+		_, lease = dhcpClient.Status()
+	}
 
-			if err := lm.link.SetIPAddress(ipAddr, lm.vlanID); err != nil {
-				continue
-			}
+	return lm.defaultRoutes(lease)
+}
 
-			lm.mu.Lock()
-			lm.ipAddr = ipAddr
-			lm.mu.Unlock()
+func (lm *LeaseManager) defaultRoutes(lease *dhcp.Lease) []iface.HostRoute {
+	lm.mu.Lock()
+	_, defaults := splitDefaultRoutes(lm.routes)
+	lm.mu.Unlock()
 
-		case <-lm.ctx.Done():
-			timer.Stop()
-			return
-		}
+	if lease != nil {
+		_, leaseDefaults := splitDefaultRoutes(routesFromLease(lease.ACK))
+		defaults = append(defaults, leaseDefaults...)
 	}
+
+	return defaults
+}
+
+func (lm *LeaseManager) isPolicyRouting() bool {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	return lm.policyRouting
 }
 
 func (lm *LeaseManager) Stop() {
@@ -159,20 +354,22 @@ func (lm *LeaseManager) Stop() {
 	}
 
 	cancel := lm.cancel
-	lease := lm.lease
+	dhcpClient := lm.dhcpClient
 
 	lm.running = false
 	lm.cancel = nil
-	lm.lease = nil
 	lm.mu.Unlock()
 
 	if cancel != nil {
 		cancel()
 	}
 
-	// Release DHCP lease
-	if lease != nil {
-		_ = lm.client.Release(lease)
+	// Release DHCP lease, it's not verified by the next run any more
+	if _, lease := dhcpClient.Status(); lease != nil {
+		_ = lm.client.Release(lease.Lease, lm.identity.Modifiers()...)
+	}
+	if err := lm.store.Delete(lm.iface); err != nil {
+		logrus.Warnf("delete dhcp lease of %s failed, error: %s", lm.iface, err.Error())
 	}
 }
 
@@ -199,3 +396,45 @@ func ipAddrFromLease(lease *nclient4.Lease) (string, error) {
 
 	return ipAddr, nil
 }
+
+// routesFromLease returns the classless static routes in the lease, or the default route via the router if the
+// classless static routes aren't provided as RFC 3442 requires
+func routesFromLease(ack *dhcpv4.DHCPv4) []iface.HostRoute {
+	var routes []iface.HostRoute
+
+	if classless := ack.ClasslessStaticRoute(); len(classless) > 0 {
+		for _, r := range classless {
+			route := iface.HostRoute{Dst: r.Dest}
+			// the destination is on the link if the router is 0.0.0.0
+			if r.Router != nil && !r.Router.IsUnspecified() {
+				route.Gw = r.Router
+			}
+			routes = append(routes, route)
+		}
+		return routes
+	}
+
+	if routers := ack.Router(); len(routers) > 0 {
+		routes = append(routes, iface.HostRoute{
+			Dst: &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)},
+			Gw:  routers[0],
+		})
+	}
+
+	return routes
+}
+
+func dnsFromLease(ack *dhcpv4.DHCPv4) *resolvconf.Config {
+	cfg := &resolvconf.Config{}
+	for _, ip := range ack.DNS() {
+		cfg.Nameservers = append(cfg.Nameservers, ip.String())
+	}
+
+	if labels := ack.DomainSearch(); labels != nil && len(labels.Labels) > 0 {
+		cfg.Searches = labels.Labels
+	} else if domain := ack.DomainName(); domain != "" {
+		cfg.Searches = []string{domain}
+	}
+
+	return cfg
+}
@@ -0,0 +1,69 @@
+package hostnetworkconfig
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/resolvconf"
+)
+
+// hostRoutesOfFamily converts the routes of the address family in the host network config
+func hostRoutesOfFamily(routes []networkv1.Route, family int) ([]iface.HostRoute, error) {
+	var hostRoutes []iface.HostRoute
+	for _, r := range routes {
+		_, dst, err := net.ParseCIDR(string(r.Destination))
+		if err != nil {
+			return nil, fmt.Errorf("invalid route destination %s, error: %w", r.Destination, err)
+		}
+		if routeFamily(dst.IP) != family {
+			continue
+		}
+
+		route := iface.HostRoute{Dst: dst, Metric: int(r.Metric)}
+		if r.Gateway != "" {
+			route.Gw = net.ParseIP(r.Gateway)
+			if route.Gw == nil {
+				return nil, fmt.Errorf("invalid route gateway %s", r.Gateway)
+			}
+		}
+		hostRoutes = append(hostRoutes, route)
+	}
+
+	return hostRoutes, nil
+}
+
+// splitDefaultRoutes separates the default routes from the other routes. The default routes of the host network
+// are installed only in its dedicated routing table if policy routing is enabled, the default route of the node in
+// the main table is kept.
+func splitDefaultRoutes(routes []iface.HostRoute) (others, defaults []iface.HostRoute) {
+	for _, r := range routes {
+		if iface.IsDefaultRoute(r) {
+			defaults = append(defaults, r)
+		} else {
+			others = append(others, r)
+		}
+	}
+	return others, defaults
+}
+
+func routeFamily(ip net.IP) int {
+	if ip.To4() != nil {
+		return netlink.FAMILY_V4
+	}
+	return netlink.FAMILY_V6
+}
+
+func dnsFromSpec(dns *networkv1.DNSConfig) *resolvconf.Config {
+	if dns == nil {
+		return nil
+	}
+
+	return &resolvconf.Config{
+		Nameservers: dns.Nameservers,
+		Searches:    dns.Searches,
+	}
+}
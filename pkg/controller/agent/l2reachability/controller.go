@@ -0,0 +1,329 @@
+package l2reachability
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/probe"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	ControllerName = "harvester-network-l2reachability-controller"
+
+	defaultInterval = 300 * time.Second
+	defaultCount    = 5
+
+	// the agents on different nodes create their probe endpoints at slightly different times
+	settle = time.Second
+	gap    = 200 * time.Millisecond
+	wait   = time.Second
+)
+
+type runner struct {
+	generation int64
+	cancel     context.CancelFunc
+}
+
+type Handler struct {
+	ctx       context.Context
+	nodeName  string
+	l2rClient ctlnetworkv1.L2ReachabilityClient
+	vsCache   ctlnetworkv1.VlanStatusCache
+	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
+	nodeCache ctlcorev1.NodeCache
+
+	mu      sync.Mutex
+	runners map[string]*runner
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	l2rs := management.HarvesterNetworkFactory.Network().V1beta1().L2Reachability()
+	vss := management.HarvesterNetworkFactory.Network().V1beta1().VlanStatus()
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := &Handler{
+		ctx:       ctx,
+		nodeName:  management.Options.NodeName,
+		l2rClient: l2rs,
+		vsCache:   vss.Cache(),
+		nadCache:  nads.Cache(),
+		nodeCache: nodes.Cache(),
+		runners:   make(map[string]*runner),
+	}
+
+	l2rs.OnChange(ctx, ControllerName, handler.OnChange)
+	l2rs.OnRemove(ctx, ControllerName, handler.OnRemove)
+
+	return nil
+}
+
+// OnChange starts the probe rounds of the L2Reachability, the rounds are restarted when the spec is changed
+func (h *Handler) OnChange(key string, l2r *networkv1.L2Reachability) (*networkv1.L2Reachability, error) {
+	if l2r == nil || l2r.DeletionTimestamp != nil {
+		h.stopRunner(key)
+		return nil, nil
+	}
+
+	h.ensureRunner(l2r)
+
+	return l2r, nil
+}
+
+func (h *Handler) OnRemove(_ string, l2r *networkv1.L2Reachability) (*networkv1.L2Reachability, error) {
+	if l2r == nil {
+		return nil, nil
+	}
+
+	h.stopRunner(l2r.Name)
+
+	return l2r, nil
+}
+
+func (h *Handler) ensureRunner(l2r *networkv1.L2Reachability) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if r, ok := h.runners[l2r.Name]; ok {
+		if r.generation == l2r.Generation {
+			return
+		}
+		r.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.runners[l2r.Name] = &runner{generation: l2r.Generation, cancel: cancel}
+	go h.run(ctx, l2r.DeepCopy())
+
+	logrus.Infof("start l2 reachability %s on cluster network %s", l2r.Name, l2r.Spec.ClusterNetwork)
+}
+
+func (h *Handler) stopRunner(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if r, ok := h.runners[name]; ok {
+		r.cancel()
+		delete(h.runners, name)
+		logrus.Infof("stop l2 reachability %s", name)
+	}
+}
+
+// run starts the probe rounds at the multiples of the interval so that the agents on all nodes probe at the same time.
+// The node doesn't take part in the rounds if the cluster network isn't set up on it.
+func (h *Handler) run(ctx context.Context, l2r *networkv1.L2Reachability) {
+	interval := getInterval(l2r)
+	_, reported := l2r.Status.NodeStatus[h.nodeName]
+	for {
+		next := time.Now().Truncate(interval).Add(interval)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		if _, err := vlan.GetVlan(l2r.Spec.ClusterNetwork); errors.As(err, &netlink.LinkNotFoundError{}) {
+			if reported {
+				if err := h.patchNodeStatus(l2r.Name, nil); err != nil {
+					logrus.Errorf("remove l2 reachability %s status failed, error: %s", l2r.Name, err.Error())
+					continue
+				}
+				reported = false
+			}
+			continue
+		}
+
+		results, err := h.probe(ctx, l2r)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			logrus.Errorf("probe l2 reachability %s failed, error: %s", l2r.Name, err.Error())
+		}
+		if err := h.setNodeStatus(l2r, results, err); err != nil {
+			logrus.Errorf("update l2 reachability %s status failed, error: %s", l2r.Name, err.Error())
+			continue
+		}
+		reported = true
+	}
+}
+
+func (h *Handler) probe(ctx context.Context, l2r *networkv1.L2Reachability) ([]probe.Result, error) {
+	cnName := l2r.Spec.ClusterNetwork
+	v, err := vlan.GetVlan(cnName)
+	if err != nil {
+		return nil, err
+	}
+
+	vids, err := h.getVIDs(l2r)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := h.getPeers(cnName)
+	if err != nil {
+		return nil, err
+	}
+	if len(vids) == 0 || len(peers) == 0 {
+		return nil, nil
+	}
+
+	// the endpoint is dedicated to the L2Reachability, the rounds of the others on the cluster network run meanwhile
+	prefix := utils.GenerateL2ReachabilityProbePrefix(l2r.Name)
+	name, portName := utils.GenerateProbeEndpointName(prefix), utils.GenerateProbePortName(prefix)
+	if err := iface.EnsureProbeEndpoint(v.Bridge(), name, portName, vids); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := iface.RemoveProbeEndpoint(name); err != nil {
+			logrus.Errorf("remove probe endpoint %s failed, error: %s", name, err.Error())
+		}
+	}()
+
+	return probe.RunSession(ctx, &probe.SessionConfig{
+		ClusterNetwork: cnName,
+		Node:           h.nodeName,
+		IfName:         name,
+		VIDs:           vids,
+		Peers:          peers,
+		Count:          getCount(l2r),
+		Settle:         settle,
+		Gap:            gap,
+		Wait:           wait,
+	})
+}
+
+// getVIDs returns the VLANs in the spec, or all the VLANs of the nads on the cluster network if not specified
+func (h *Handler) getVIDs(l2r *networkv1.L2Reachability) ([]uint16, error) {
+	vis := utils.NewVlanIDSet()
+	if len(l2r.Spec.VlanIDs) == 0 {
+		var err error
+		if vis, err = utils.GeVlanIDSetFromClusterNetwork(l2r.Spec.ClusterNetwork, h.nadCache); err != nil {
+			return nil, err
+		}
+	}
+	for _, vid := range l2r.Spec.VlanIDs {
+		if err := vis.SetUint16VID(vid); err != nil {
+			return nil, err
+		}
+	}
+
+	var vids []uint16
+	if err := vis.WalkVIDs(l2r.Spec.ClusterNetwork, func(vid uint16) error {
+		vids = append(vids, vid)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return vids, nil
+}
+
+// getPeers returns the other nodes where the cluster network is set up, the mgmt network is on all nodes
+func (h *Handler) getPeers(cnName string) ([]string, error) {
+	var peers []string
+	if cnName == utils.ManagementClusterNetworkName {
+		nodes, err := h.nodeCache.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range nodes {
+			if node.Name != h.nodeName && node.DeletionTimestamp == nil {
+				peers = append(peers, node.Name)
+			}
+		}
+		return peers, nil
+	}
+
+	vss, err := h.vsCache.List(labels.Set{utils.KeyClusterNetworkLabel: cnName}.AsSelector())
+	if err != nil {
+		return nil, err
+	}
+	for _, vs := range vss {
+		if vs.Status.Node != h.nodeName && vs.DeletionTimestamp == nil {
+			peers = append(peers, vs.Status.Node)
+		}
+	}
+
+	return peers, nil
+}
+
+func getInterval(l2r *networkv1.L2Reachability) time.Duration {
+	if l2r.Spec.IntervalSeconds == 0 {
+		return defaultInterval
+	}
+	return time.Duration(l2r.Spec.IntervalSeconds) * time.Second
+}
+
+func getCount(l2r *networkv1.L2Reachability) int {
+	if l2r.Spec.Count == 0 {
+		return defaultCount
+	}
+	return l2r.Spec.Count
+}
+
+func (h *Handler) setNodeStatus(l2r *networkv1.L2Reachability, results []probe.Result, probeErr error) error {
+	nodeStatus := networkv1.L2ReachabilityNodeStatus{
+		LastProbeTime: metav1.Now(),
+		Results:       make([]networkv1.L2ReachabilityResult, 0, len(results)),
+	}
+	if probeErr != nil {
+		nodeStatus.Message = probeErr.Error()
+	}
+	for i := range results {
+		r := networkv1.L2ReachabilityResult{
+			VlanID:      results[i].VID,
+			Node:        results[i].Node,
+			Sent:        results[i].Sent,
+			Received:    results[i].Received,
+			LossPercent: results[i].LossPercent(),
+		}
+		if r.Received > 0 {
+			r.AverageRTT = results[i].AverageRTT.String()
+		}
+		nodeStatus.Results = append(nodeStatus.Results, r)
+	}
+
+	return h.patchNodeStatus(l2r.Name, nodeStatus)
+}
+
+func (h *Handler) patchNodeStatus(name string, nodeStatus interface{}) error {
+	patchPayload := map[string]interface{}{
+		"status": map[string]interface{}{
+			"nodeStatus": map[string]interface{}{
+				h.nodeName: nodeStatus,
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patchPayload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	if _, err := h.l2rClient.Patch(name, types.MergePatchType, patchBytes, "status"); err != nil {
+		return fmt.Errorf("failed to patch L2Reachability %s status for node %s: %w", name, h.nodeName, err)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package linkmonitor
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"slices"
 
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
@@ -13,6 +15,8 @@ import (
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/lldp"
 	"github.com/harvester/harvester-network-controller/pkg/network/monitor"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
 )
@@ -29,8 +33,10 @@ type Handler struct {
 	vcClient     ctlnetworkv1.VlanConfigClient
 	lmController ctlnetworkv1.LinkMonitorController
 	lmClient     ctlnetworkv1.LinkMonitorClient
+	lmCache      ctlnetworkv1.LinkMonitorCache
 
-	linkMonitor *monitor.Monitor
+	linkMonitor  *monitor.Monitor
+	lldpReceiver *lldp.Receiver
 }
 
 func Register(ctx context.Context, management *config.Management) error {
@@ -45,6 +51,7 @@ func Register(ctx context.Context, management *config.Management) error {
 		vcClient:     vcs,
 		lmController: lms,
 		lmClient:     lms,
+		lmCache:      lms.Cache(),
 	}
 
 	// initial and start link monitor
@@ -54,6 +61,10 @@ func Register(ctx context.Context, management *config.Management) error {
 	})
 	go h.linkMonitor.Start(ctx)
 
+	// receive LLDP frames on the NICs and bond slaves, the neighbors are reported in the link status
+	h.lldpReceiver = lldp.NewReceiver(h.UpdateLLDPNeighbor)
+	go h.lldpReceiver.Start(ctx)
+
 	lms.OnChange(ctx, controllerName, h.OnChange)
 	lms.OnRemove(ctx, controllerName, h.OnRemove)
 
@@ -71,6 +82,9 @@ func (h Handler) OnChange(_ string, lm *networkv1.LinkMonitor) (*networkv1.LinkM
 	}
 	if !isMatch {
 		h.DeletePattern(lm)
+		if lm.Name == utils.NICLinkMonitorName {
+			h.stopLLDP()
+		}
 		return lm, nil
 	}
 
@@ -99,6 +113,9 @@ func (h Handler) OnRemove(_ string, lm *networkv1.LinkMonitor) (*networkv1.LinkM
 	logrus.Infof("link monitor %s has been removed", lm.Name)
 
 	h.DeletePattern(lm)
+	if lm.Name == utils.NICLinkMonitorName {
+		h.stopLLDP()
+	}
 
 	return lm, nil
 }
@@ -125,6 +142,18 @@ func (h Handler) UpdateLink(key string, _ *netlink.LinkUpdate) error {
 	return nil
 }
 
+// UpdateLLDPNeighbor enqueues all link monitors because any of them may contain the link
+func (h Handler) UpdateLLDPNeighbor(ifName string) {
+	lms, err := h.lmCache.List(labels.Everything())
+	if err != nil {
+		logrus.Errorf("list link monitors failed when the LLDP neighbor of %s changes, error: %s", ifName, err.Error())
+		return
+	}
+	for _, lm := range lms {
+		h.lmController.Enqueue(lm.Name)
+	}
+}
+
 func (h Handler) AddPattern(lm *networkv1.LinkMonitor) {
 	pattern := monitor.NewPattern(lm.Spec.TargetLinkRule.TypeRule, lm.Spec.TargetLinkRule.NameRule)
 
@@ -151,11 +180,16 @@ func linkToLinkStatus(l netlink.Link) networkv1.LinkStatus {
 		Name:        l.Attrs().Name,
 		Index:       l.Attrs().Index,
 		Type:        l.Type(),
-		MAC:         l.Attrs().HardwareAddr.String(),
+		MAC:         iface.GetPermanentMAC(l).String(),
 		Promiscuous: l.Attrs().Promisc != 0,
 		MasterIndex: l.Attrs().MasterIndex,
 	}
 
+	if l.Type() == iface.TypeDevice {
+		linkStatus.PCIAddress = iface.GetPCIAddress(l.Attrs().Name)
+		linkStatus.Driver = iface.GetDriver(l.Attrs().Name)
+	}
+
 	switch l.Attrs().OperState {
 	case netlink.OperUp:
 		linkStatus.State = networkv1.LinkUp
@@ -197,7 +231,7 @@ func compareLinkStatusList(m, n []networkv1.LinkStatus) bool {
 	}
 
 	for i, linkStatus := range m {
-		if linkStatus != n[i] {
+		if !reflect.DeepEqual(linkStatus, n[i]) {
 			return false
 		}
 	}
@@ -211,14 +245,67 @@ func (h Handler) syncLinkStatus(lm *networkv1.LinkMonitor) error {
 	if err != nil {
 		return err
 	}
+	if lm.Name == utils.NICLinkMonitorName {
+		h.syncLLDPInterfaces(links)
+	}
+
 	linkStatusList := make([]networkv1.LinkStatus, len(links))
 	for i, link := range links {
 		linkStatusList[i] = linkToLinkStatus(link)
+		linkStatusList[i].LLDPNeighbor = h.getLLDPNeighbor(link.Attrs().Name)
 	}
 
 	return h.updateStatus(lm, linkStatusList)
 }
 
+// syncLLDPInterfaces listens to LLDP frames on the NICs matched by the nic link monitor and on the bond slaves
+func (h Handler) syncLLDPInterfaces(nics []netlink.Link) {
+	slaves, err := iface.ListBondSlaves()
+	if err != nil {
+		logrus.Errorf("list bond slaves failed, error: %s", err.Error())
+	}
+
+	names := make([]string, 0, len(nics)+len(slaves))
+	for _, links := range [][]netlink.Link{nics, slaves} {
+		for _, l := range links {
+			if !slices.Contains(names, l.Attrs().Name) {
+				names = append(names, l.Attrs().Name)
+			}
+		}
+	}
+
+	// failing to listen on some interfaces shouldn't block reporting the link status
+	if err := h.lldpReceiver.SetInterfaces(names); err != nil {
+		logrus.Warnf("receive LLDP frames failed, error: %s", err.Error())
+	}
+}
+
+func (h Handler) stopLLDP() {
+	if err := h.lldpReceiver.SetInterfaces(nil); err != nil {
+		logrus.Warnf("stop receiving LLDP frames failed, error: %s", err.Error())
+	}
+}
+
+func (h Handler) getLLDPNeighbor(ifName string) *networkv1.LLDPNeighbor {
+	n := h.lldpReceiver.GetNeighbor(ifName)
+	if n == nil {
+		return nil
+	}
+
+	neighbor := &networkv1.LLDPNeighbor{
+		ChassisID:       n.ChassisID,
+		PortID:          n.PortID,
+		PortDescription: n.PortDescription,
+		SystemName:      n.SystemName,
+		PortVlanID:      n.PortVlanID,
+	}
+	for _, vlan := range n.Vlans {
+		neighbor.Vlans = append(neighbor.Vlans, networkv1.LLDPVlan{ID: vlan.ID, Name: vlan.Name})
+	}
+
+	return neighbor
+}
+
 // update mgmt vlanconfig when mgmt cluster link params change
 func (h Handler) updateMgmtVlanConfig() error {
 	mgmtVlanConfigName := utils.GetMgmtVlanConfigName(h.nodeName)
@@ -0,0 +1,293 @@
+package nad
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	ControllerName = "harvester-network-nad-agent-controller"
+
+	defaultCheckPeriod          = 15 * time.Minute
+	defaultPingTimes            = 5
+	defaultPingInterval         = time.Second
+	defaultAllowPackageLostRate = 20
+
+	// the time for the new bridge port to start forwarding
+	settle = time.Second
+)
+
+// checkTarget is the gateway of a VLAN network to check
+type checkTarget struct {
+	clusterNetwork string
+	vid            uint16
+	gateway        net.IP
+}
+
+// Handler discovers the layer 3 network of the VLAN networks and checks the connectivity to the gateways from the
+// VLANs on the node, the results are recorded in the layer 3 network configuration of the nads
+type Handler struct {
+	ctx       context.Context
+	nodeName  string
+	nadClient ctlcniv1.NetworkAttachmentDefinitionClient
+	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
+	nodeCache ctlcorev1.NodeCache
+	hncCache  ctlnetworkv1.HostNetworkConfigCache
+	cnCache   ctlnetworkv1.ClusterNetworkCache
+
+	// the checks on a cluster network share the endpoint, they are run one at a time
+	checkMu sync.Mutex
+	// the discoveries on a VLAN share the sub-interface, they are run one at a time
+	discoverMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
+	nodes := management.CoreFactory.Core().V1().Node()
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+
+	handler := &Handler{
+		ctx:       ctx,
+		nodeName:  management.Options.NodeName,
+		nadClient: nads,
+		nadCache:  nads.Cache(),
+		nodeCache: nodes.Cache(),
+		hncCache:  hncs.Cache(),
+		cnCache:   cns.Cache(),
+		pending:   make(map[string]bool),
+	}
+
+	nads.OnChange(ctx, ControllerName, handler.OnChange)
+	cns.OnChange(ctx, ControllerName, handler.OnCNChange)
+
+	go handler.checkPeriodically()
+	go handler.discoverPeriodically()
+
+	return nil
+}
+
+// OnChange discovers the layer 3 network at once if it's not discovered yet or the VLAN is changed, and checks the
+// gateway at once if it's not checked on the node yet, e.g. the gateway is obtained or changed
+func (h *Handler) OnChange(_ string, nad *cniv1.NetworkAttachmentDefinition) (*cniv1.NetworkAttachmentDefinition, error) {
+	if nad == nil || nad.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	if _, networkConf, ok := getDiscoveryTarget(nad); ok && needsDiscovery(networkConf) && !h.discoveredByJob() {
+		h.trigger("discover", nad.Namespace, nad.Name, h.discover)
+		return nad, nil
+	}
+
+	_, networkConf, ok := getCheckTarget(nad)
+	if !ok || networkConf.GetNodeConnectivity(h.nodeName) != "" {
+		return nad, nil
+	}
+
+	h.trigger("check", nad.Namespace, nad.Name, h.check)
+
+	return nad, nil
+}
+
+// trigger runs the action on the nad in the background, the action is skipped if the same action on the nad is running
+func (h *Handler) trigger(action, namespace, name string, run func(namespace, name string) error) {
+	key := action + "/" + namespace + "/" + name
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending[key] {
+		return
+	}
+	h.pending[key] = true
+
+	go func() {
+		defer func() {
+			h.mu.Lock()
+			delete(h.pending, key)
+			h.mu.Unlock()
+		}()
+		if err := run(namespace, name); err != nil {
+			logrus.Errorf("%s nad %s/%s failed, error: %s", action, namespace, name, err.Error())
+		}
+	}()
+}
+
+func (h *Handler) checkPeriodically() {
+	ticker := time.NewTicker(defaultCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.ctx.Done():
+			return
+		}
+
+		nads, err := h.nadCache.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			logrus.Errorf("list nads failed, error: %s", err.Error())
+			continue
+		}
+		for _, nad := range nads {
+			if _, _, ok := getCheckTarget(nad); !ok {
+				continue
+			}
+			if err := h.check(nad.Namespace, nad.Name); err != nil {
+				logrus.Errorf("check gateway of nad %s/%s failed, error: %s", nad.Namespace, nad.Name, err.Error())
+			}
+		}
+	}
+}
+
+// check pings the gateway from the VLAN of the nad and records the result of the node, the result is removed if the
+// cluster network isn't set up on the node
+func (h *Handler) check(namespace, name string) error {
+	h.checkMu.Lock()
+	defer h.checkMu.Unlock()
+
+	nad, err := h.nadCache.Get(namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	target, _, ok := getCheckTarget(nad)
+	if !ok {
+		return nil
+	}
+
+	var connectivity utils.Connectivity
+	v, err := vlan.GetVlan(target.clusterNetwork)
+	switch {
+	case errors.As(err, &netlink.LinkNotFoundError{}):
+		// leave the connectivity empty to remove the result of the node
+	case err != nil:
+		return err
+	default:
+		if connectivity, err = pingGateway(v, target); err != nil {
+			logrus.Errorf("ping gateway %s of nad %s/%s failed, error: %s", target.gateway, namespace, name, err.Error())
+			connectivity = utils.PingFailed
+		}
+	}
+
+	return h.recordConnectivity(namespace, name, target.gateway.String(), connectivity)
+}
+
+// pingGateway sends the ARP probes of the gateway from an access port of the VLAN on the bridge, which is where the
+// VMs of the network are attached
+func pingGateway(v *vlan.Vlan, target *checkTarget) (utils.Connectivity, error) {
+	name, portName := utils.GenerateGatewayEndpointName(target.clusterNetwork), utils.GenerateGatewayPortName(target.clusterNetwork)
+	if err := iface.EnsureAccessEndpoint(v.Bridge(), name, portName, target.vid); err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := iface.RemoveProbeEndpoint(name); err != nil {
+			logrus.Errorf("remove gateway check endpoint %s failed, error: %s", name, err.Error())
+		}
+	}()
+	time.Sleep(settle)
+
+	replies, err := iface.PingARP(name, target.gateway, defaultPingTimes, defaultPingInterval)
+	if err != nil {
+		return "", err
+	}
+
+	if (defaultPingTimes-replies)*100/defaultPingTimes > defaultAllowPackageLostRate {
+		return utils.Unconnectable, nil
+	}
+	return utils.Connectable, nil
+}
+
+// recordConnectivity records the result of the node in the nad, the nads are updated by the agents on all nodes
+// so the conflicts are retried with the latest nad
+func (h *Handler) recordConnectivity(namespace, name, gateway string, connectivity utils.Connectivity) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nad, err := h.nadClient.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		networkConf, err := utils.NewLayer3NetworkConfFromNad(nad)
+		if err != nil {
+			return err
+		}
+		// the gateway is changed during the check, the new gateway will be checked
+		if networkConf.Gateway != gateway || networkConf.GetNodeConnectivity(h.nodeName) == connectivity {
+			return nil
+		}
+		networkConf.SetNodeConnectivity(h.nodeName, connectivity)
+
+		confStr, err := networkConf.ToString()
+		if err != nil {
+			return err
+		}
+		nadCopy := nad.DeepCopy()
+		utils.SetNadAnnotation(nadCopy, utils.KeyNetworkRoute, confStr)
+		if _, err := h.nadClient.Update(nadCopy); err != nil {
+			return err
+		}
+		logrus.Infof("connectivity to gateway %s of nad %s/%s from node %s is %q", gateway, namespace, name, h.nodeName, connectivity)
+
+		return nil
+	})
+}
+
+// getCheckTarget returns the gateway of the nad to check, only the gateways of the access mode VLAN networks whose
+// layer 3 network configuration is up to date are checked
+func getCheckTarget(nad *cniv1.NetworkAttachmentDefinition) (*checkTarget, *utils.Layer3NetworkConf, bool) {
+	if nad.DeletionTimestamp != nil || !utils.IsVlanNad(nad) {
+		return nil, nil, false
+	}
+
+	netconf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil || !netconf.IsBridgeCNI() || !netconf.IsVlanAccessMode() {
+		return nil, nil, false
+	}
+	cnName, err := netconf.GetClusterNetworkName()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	networkConf, err := utils.NewLayer3NetworkConfFromNad(nad)
+	// the gateway of the network served by multiple DHCP servers is not checked to keep the state until it's resolved
+	if err != nil || networkConf.CIDR == "" || networkConf.Gateway == "" || networkConf.Outdated ||
+		networkConf.Connectivity == utils.MultipleDHCPServers {
+		return nil, nil, false
+	}
+	gateway := net.ParseIP(networkConf.Gateway).To4()
+	if gateway == nil {
+		return nil, nil, false
+	}
+
+	return &checkTarget{
+		clusterNetwork: cnName,
+		vid:            uint16(netconf.GetVlanID()), // nolint: gosec
+		gateway:        gateway,
+	}, networkConf, true
+}
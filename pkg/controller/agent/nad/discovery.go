@@ -0,0 +1,329 @@
+package nad
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/helper"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/network/vlan"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	defaultDiscoveryPeriod = 30 * time.Minute
+	// the offers of all the DHCP servers answering within the window are collected to detect the rogue servers
+	defaultOfferWindow = 5 * time.Second
+)
+
+// discoveryTarget is the VLAN network whose layer 3 network is discovered by DHCP
+type discoveryTarget struct {
+	clusterNetwork string
+	vid            uint16
+	serverIPAddr   string
+}
+
+func (h *Handler) discoverPeriodically() {
+	ticker := time.NewTicker(defaultDiscoveryPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.ctx.Done():
+			return
+		}
+
+		if h.discoveredByJob() {
+			continue
+		}
+		nads, err := h.nadCache.List(metav1.NamespaceAll, labels.Everything())
+		if err != nil {
+			logrus.Errorf("list nads failed, error: %s", err.Error())
+			continue
+		}
+		for _, nad := range nads {
+			if _, _, ok := getDiscoveryTarget(nad); !ok {
+				continue
+			}
+			if err := h.discover(nad.Namespace, nad.Name); err != nil {
+				logrus.Errorf("discover layer 3 network of nad %s/%s failed, error: %s", nad.Namespace, nad.Name, err.Error())
+			}
+		}
+	}
+}
+
+// OnCNChange discovers the networks not discovered yet at once when the manager hands the discovery over to the agents
+func (h *Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil || cn.Name != utils.ManagementClusterNetworkName || h.discoveredByJob() {
+		return cn, nil
+	}
+
+	nads, err := h.nadCache.List(metav1.NamespaceAll, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, nad := range nads {
+		if _, networkConf, ok := getDiscoveryTarget(nad); ok && needsDiscovery(networkConf) {
+			h.trigger("discover", nad.Namespace, nad.Name, h.discover)
+		}
+	}
+
+	return cn, nil
+}
+
+// discoveredByJob returns true if the manager discovers the layer 3 networks by the helper jobs, which it publishes on
+// the mgmt cluster network
+func (h *Handler) discoveredByJob() bool {
+	cn, err := h.cnCache.Get(utils.ManagementClusterNetworkName)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logrus.Errorf("get cluster network %s failed, error: %s", utils.ManagementClusterNetworkName, err.Error())
+		}
+		return false
+	}
+
+	return cn.Annotations[utils.KeyL3DiscoveryJob] == utils.ValueTrue
+}
+
+// discover obtains the layer 3 network of the nad by DHCP from the VLAN sub-interface of the bridge and records it in
+// the nad. Only one node of the cluster network discovers the network to avoid flooding the DHCP servers.
+func (h *Handler) discover(namespace, name string) error {
+	h.discoverMu.Lock()
+	defer h.discoverMu.Unlock()
+
+	nad, err := h.nadCache.Get(namespace, name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	target, _, ok := getDiscoveryTarget(nad)
+	if !ok || h.discoveredByJob() {
+		return nil
+	}
+
+	if elected, err := h.isDiscoveryNode(target.clusterNetwork); err != nil || !elected {
+		return err
+	}
+
+	v, err := vlan.GetVlan(target.clusterNetwork)
+	if err != nil {
+		if errors.As(err, &netlink.LinkNotFoundError{}) {
+			logrus.Infof("cluster network %s is not set on this node, skip discovering nad %s/%s", target.clusterNetwork, namespace, name)
+			return nil
+		}
+		return err
+	}
+	bridgelink, err := v.GetBridgelink()
+	if err != nil {
+		return err
+	}
+
+	// the sub-interface of the host network on the same VLAN is reused and left as it is
+	_, _, err = bridgelink.GetVlanSubInterfaceAndOperState(target.vid)
+	created := err != nil
+	if err := bridgelink.AddBridgeVlanSelf(target.vid); err != nil {
+		return err
+	}
+	if err := bridgelink.CreateVlanSubInterface(target.vid); err != nil {
+		return err
+	}
+	ifName := utils.GetClusterNetworkBrVlanDevice(bridgelink.Attrs().Name, target.vid)
+	if created {
+		defer h.removeDiscoveryInterface(bridgelink, target)
+		// the router advertisements are solicited by the helper, the kernel mustn't configure the temporary interface
+		if err := iface.SetIPv6AutoConf(ifName, false, false); err != nil {
+			logrus.Warnf("disable IPv6 autoconf of %s failed, error: %s", ifName, err.Error())
+		}
+	}
+
+	dhcpOptions, err := h.getDHCPOptions(target)
+	if err != nil {
+		return err
+	}
+
+	result := helper.DiscoverLayer3Network(ifName, target.serverIPAddr, dhcpOptions, h.nodeName, defaultOfferWindow)
+
+	return h.recordLayer3Network(namespace, name, target, result)
+}
+
+// removeDiscoveryInterface removes the temporary sub-interface unless a host network is set up on the VLAN meanwhile
+func (h *Handler) removeDiscoveryInterface(bridgelink *iface.Link, target *discoveryTarget) {
+	if exists, err := h.hasHostNetwork(target); err != nil || exists {
+		return
+	}
+	if err := bridgelink.DelVlanSubInterface(target.vid); err != nil {
+		logrus.Errorf("remove discovery sub-interface of cluster network %s vlan %d failed, error: %s", target.clusterNetwork, target.vid, err.Error())
+	}
+	if err := bridgelink.DelBridgeVlanSelf(target.vid); err != nil {
+		logrus.Errorf("remove bridge vlan %d of cluster network %s failed, error: %s", target.vid, target.clusterNetwork, err.Error())
+	}
+}
+
+// isDiscoveryNode returns true if the node is the first ready node by name among the nodes of the cluster network
+func (h *Handler) isDiscoveryNode(cnName string) (bool, error) {
+	nodes, err := h.nodeCache.List(labels.Set{utils.GetLabelKeyOfClusterNetwork(cnName): utils.ValueTrue}.AsSelector())
+	if err != nil {
+		return false, err
+	}
+
+	var candidates []string
+	for _, node := range nodes {
+		if node.DeletionTimestamp != nil || utils.HasWitnessNodeLabelKey(node.Labels) || !isNodeReady(node) {
+			continue
+		}
+		candidates = append(candidates, node.Name)
+	}
+	if len(candidates) == 0 {
+		return false, nil
+	}
+	sort.Strings(candidates)
+
+	return candidates[0] == h.nodeName, nil
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hasHostNetwork returns true if a host network is on the same cluster network and VLAN as the target
+func (h *Handler) hasHostNetwork(target *discoveryTarget) (bool, error) {
+	hncs, err := h.hncCache.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, hnc := range hncs {
+		if hnc.DeletionTimestamp == nil && hnc.Spec.ClusterNetwork == target.clusterNetwork && hnc.Spec.VlanID == target.vid {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// getDHCPOptions returns the DHCP options of the host network on the same VLAN, so that the node identifies itself to
+// the DHCP servers of the VLAN as the host network does
+func (h *Handler) getDHCPOptions(target *discoveryTarget) (*networkv1.DHCPOptions, error) {
+	hncs, err := h.hncCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, hnc := range hncs {
+		if hnc.Spec.ClusterNetwork == target.clusterNetwork && hnc.Spec.VlanID == target.vid && hnc.Spec.DHCPOptions != nil {
+			return hnc.Spec.DHCPOptions, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// recordLayer3Network records the discovered network in the nad. The result is dropped if the nad is changed during
+// the discovery, and a failed re-probe doesn't wipe out the network discovered before.
+func (h *Handler) recordLayer3Network(namespace, name string, target *discoveryTarget, result *utils.Layer3NetworkConf) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		nad, err := h.nadClient.Get(namespace, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		current, networkConf, ok := getDiscoveryTarget(nad)
+		if !ok || *current != *target {
+			return nil
+		}
+
+		discovered := result.Connectivity != utils.DHCPFailed
+		if !discovered && (networkConf.CIDR != "" || networkConf.IPv6Prefix != "") && !networkConf.Outdated {
+			logrus.Warnf("re-discover layer 3 network of nad %s/%s failed, keep cidr %s gateway %s", namespace, name,
+				networkConf.CIDR, networkConf.Gateway)
+			return nil
+		}
+
+		newConf := *networkConf
+		newConf.Outdated = false
+		newConf.SetDHCPSettings(result)
+		// the connectivity of the nodes is checked again once the gateway is changed or the servers agree again, the
+		// result of the discovery overrides the connectivity if it fails or the servers disagree
+		if result.Connectivity != "" || newConf.Gateway != networkConf.Gateway || isDiscoveryConnectivity(networkConf.Connectivity) {
+			newConf.Connectivity = result.Connectivity
+			newConf.NodeConnectivity = nil
+			newConf.CheckedGateway = ""
+		}
+
+		confStr, err := newConf.ToString()
+		if err != nil {
+			return err
+		}
+		if confStr == utils.GetNadAnnotation(nad, utils.KeyNetworkRoute) {
+			return nil
+		}
+		nadCopy := nad.DeepCopy()
+		utils.SetNadAnnotation(nadCopy, utils.KeyNetworkRoute, confStr)
+		if _, err := h.nadClient.Update(nadCopy); err != nil {
+			return err
+		}
+		logrus.Infof("discover layer 3 network of nad %s/%s on node %s: cidr %q gateway %q connectivity %q", namespace, name,
+			h.nodeName, newConf.CIDR, newConf.Gateway, newConf.Connectivity)
+
+		return nil
+	})
+}
+
+// getDiscoveryTarget returns the VLAN network of the nad to discover, only the access mode VLAN networks in the auto
+// mode are discovered
+func getDiscoveryTarget(nad *cniv1.NetworkAttachmentDefinition) (*discoveryTarget, *utils.Layer3NetworkConf, bool) {
+	if nad.DeletionTimestamp != nil || !utils.IsVlanNad(nad) {
+		return nil, nil, false
+	}
+
+	netconf, err := utils.DecodeNadConfigToNetConf(nad)
+	if err != nil || !netconf.IsBridgeCNI() || !netconf.IsVlanAccessMode() || netconf.GetVlanID() == 0 {
+		return nil, nil, false
+	}
+	cnName, err := netconf.GetClusterNetworkName()
+	if err != nil {
+		return nil, nil, false
+	}
+
+	networkConf, err := utils.NewLayer3NetworkConfFromNad(nad)
+	if err != nil || networkConf.Mode != utils.Auto {
+		return nil, nil, false
+	}
+
+	return &discoveryTarget{
+		clusterNetwork: cnName,
+		vid:            uint16(netconf.GetVlanID()), // nolint: gosec
+		serverIPAddr:   networkConf.ServerIPAddr,
+	}, networkConf, true
+}
+
+// isDiscoveryConnectivity returns true if the connectivity is the result of the discovery rather than the gateway check
+func isDiscoveryConnectivity(connectivity utils.Connectivity) bool {
+	return connectivity == utils.DHCPFailed || connectivity == utils.MultipleDHCPServers
+}
+
+// needsDiscovery returns true if the network is not discovered yet or the VLAN is changed, the failed discovery is
+// retried periodically
+func needsDiscovery(networkConf *utils.Layer3NetworkConf) bool {
+	return networkConf.Outdated || (networkConf.CIDR == "" && networkConf.IPv6Prefix == "" && networkConf.Connectivity != utils.DHCPFailed)
+}
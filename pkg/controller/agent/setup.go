@@ -4,7 +4,10 @@ import (
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/clusternetwork"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/hostnetworkconfig"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/l2reachability"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/linkmonitor"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/nad"
+	"github.com/harvester/harvester-network-controller/pkg/controller/agent/vip"
 	"github.com/harvester/harvester-network-controller/pkg/controller/agent/vlanconfig"
 )
 
@@ -13,4 +16,7 @@ var RegisterFuncList = []config.RegisterFunc{
 	linkmonitor.Register,
 	clusternetwork.Register,
 	hostnetworkconfig.Register,
+	l2reachability.Register,
+	nad.Register,
+	vip.Register,
 }
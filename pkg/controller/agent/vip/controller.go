@@ -0,0 +1,323 @@
+package vip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	ControllerName = "harvester-network-vip-controller"
+
+	// the eligibility of this node is checked periodically while it's a candidate, to add the virtual IP back if
+	// it's removed and to release it if the link fails
+	checkInterval = 5 * time.Second
+)
+
+type Handler struct {
+	ctx           context.Context
+	nodeName      string
+	nodeCache     ctlcorev1.NodeCache
+	hncCache      ctlnetworkv1.HostNetworkConfigCache
+	vipCache      ctlnetworkv1.VirtualIPCache
+	vipController ctlnetworkv1.VirtualIPController
+	leaseClient   coordinationv1.LeasesGetter
+
+	mu       sync.Mutex
+	electors map[string]*elector
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	if !management.Options.EnableVipController {
+		return nil
+	}
+
+	vips := management.HarvesterNetworkFactory.Network().V1beta1().VirtualIP()
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := &Handler{
+		ctx:           ctx,
+		nodeName:      management.Options.NodeName,
+		nodeCache:     nodes.Cache(),
+		hncCache:      hncs.Cache(),
+		vipCache:      vips.Cache(),
+		vipController: vips,
+		leaseClient:   management.ClientSet.CoordinationV1(),
+		electors:      make(map[string]*elector),
+	}
+
+	vips.OnChange(ctx, ControllerName, handler.OnChange)
+	vips.OnRemove(ctx, ControllerName, handler.OnRemove)
+	hncs.OnChange(ctx, ControllerName, handler.EnqueueVirtualIPsByHostNetworkConfig)
+	nodes.OnChange(ctx, ControllerName, handler.EnqueueVirtualIPsByNode)
+
+	go handler.watchLinks(ctx)
+
+	return nil
+}
+
+// OnChange takes part in the election of the virtual IP if this node is eligible, otherwise leaves the election
+// and gives up the virtual IP
+func (h *Handler) OnChange(key string, vip *networkv1.VirtualIP) (*networkv1.VirtualIP, error) {
+	if vip == nil || vip.DeletionTimestamp != nil {
+		h.stopElector(key)
+		return nil, nil
+	}
+
+	ifName, eligible, err := h.checkEligibility(vip)
+	if err != nil {
+		return nil, err
+	}
+
+	if !eligible {
+		h.stopElector(vip.Name)
+		// the virtual IP may be left on the interface if the agent restarts
+		if ifName != "" {
+			if err := iface.DelVirtualIP(ifName, string(vip.Spec.Address)); err != nil {
+				return nil, err
+			}
+		}
+		// the node joins the election again by the events of the host network and the link
+		return vip, nil
+	}
+
+	h.ensureElector(vip, ifName)
+	h.vipController.EnqueueAfter(vip.Name, checkInterval)
+
+	return vip, nil
+}
+
+func (h *Handler) OnRemove(_ string, vip *networkv1.VirtualIP) (*networkv1.VirtualIP, error) {
+	if vip == nil {
+		return nil, nil
+	}
+
+	h.stopElector(vip.Name)
+
+	return vip, nil
+}
+
+// EnqueueVirtualIPsByHostNetworkConfig rechecks the eligibility once the host network is changed on this node
+func (h *Handler) EnqueueVirtualIPsByHostNetworkConfig(_ string, hnc *networkv1.HostNetworkConfig) (*networkv1.HostNetworkConfig, error) {
+	if hnc == nil {
+		return nil, nil
+	}
+
+	vips, err := h.vipCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vip := range vips {
+		if vip.Spec.HostNetworkConfig == hnc.Name {
+			h.vipController.Enqueue(vip.Name)
+		}
+	}
+
+	return hnc, nil
+}
+
+// EnqueueVirtualIPsByNode rechecks the virtual IPs whose node selector starts or stops matching this node after the
+// labels of this node are changed
+func (h *Handler) EnqueueVirtualIPsByNode(_ string, node *corev1.Node) (*corev1.Node, error) {
+	if node == nil || node.DeletionTimestamp != nil || node.Name != h.nodeName {
+		return node, nil
+	}
+
+	vips, err := h.vipCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vip := range vips {
+		if vip.Spec.NodeSelector == nil {
+			continue
+		}
+		// the invalid selector is reported by OnChange
+		selector, err := metav1.LabelSelectorAsSelector(vip.Spec.NodeSelector)
+		if err != nil {
+			continue
+		}
+		if selector.Matches(labels.Set(node.Labels)) != h.hasElector(vip.Name) {
+			h.vipController.Enqueue(vip.Name)
+		}
+	}
+
+	return node, nil
+}
+
+// watchLinks rechecks the eligibility of the virtual IPs on the host network interface whose state is changed, e.g.
+// the node joins the election once the link is up again
+func (h *Handler) watchLinks(ctx context.Context) {
+	linkCh := make(chan netlink.LinkUpdate)
+	if err := netlink.LinkSubscribe(linkCh, ctx.Done()); err != nil {
+		logrus.Errorf("subscribe link updates failed, error: %s", err.Error())
+		return
+	}
+
+	for {
+		select {
+		case update, ok := <-linkCh:
+			if !ok {
+				return
+			}
+			if err := h.enqueueVirtualIPsByInterface(update.Attrs().Name); err != nil {
+				logrus.Errorf("enqueue virtual ips on %s failed, error: %s", update.Attrs().Name, err.Error())
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *Handler) enqueueVirtualIPsByInterface(ifName string) error {
+	vips, err := h.vipCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, vip := range vips {
+		hnc, err := h.hncCache.Get(vip.Spec.HostNetworkConfig)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID) == ifName {
+			h.vipController.Enqueue(vip.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkEligibility returns the VLAN interface of the host network and whether this node is eligible to hold the
+// virtual IP. The node is eligible if the host network is ready on it, it matches the node selector of the virtual IP
+// and the VLAN interface is up.
+func (h *Handler) checkEligibility(vip *networkv1.VirtualIP) (string, bool, error) {
+	hnc, err := h.hncCache.Get(vip.Spec.HostNetworkConfig)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if hnc.DeletionTimestamp != nil || !isHostNetworkReady(hnc, h.nodeName) {
+		return "", false, nil
+	}
+
+	if vip.Spec.NodeSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(vip.Spec.NodeSelector)
+		if err != nil {
+			return "", false, err
+		}
+		node, err := h.nodeCache.Get(h.nodeName)
+		if err != nil {
+			return "", false, err
+		}
+		if !selector.Matches(labels.Set(node.Labels)) {
+			return "", false, nil
+		}
+	}
+
+	ifName := utils.GetClusterNetworkVlanDevice(hnc.Spec.ClusterNetwork, hnc.Spec.VlanID)
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		if errors.As(err, &netlink.LinkNotFoundError{}) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("get host network interface %s failed, error: %w", ifName, err)
+	}
+	if link.Attrs().OperState != netlink.OperUp {
+		return ifName, false, nil
+	}
+
+	return ifName, true, nil
+}
+
+func isHostNetworkReady(hnc *networkv1.HostNetworkConfig, nodeName string) bool {
+	nodeStatus, ok := hnc.Status.NodeStatus[nodeName]
+	if !ok {
+		return false
+	}
+	for _, c := range nodeStatus.Conditions {
+		if c.Type == networkv1.Ready {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
+
+// ensureElector starts the elector if it isn't started or the virtual IP is changed, and makes sure the virtual IP
+// is on the interface only if this node is the owner
+func (h *Handler) ensureElector(vip *networkv1.VirtualIP, ifName string) {
+	h.mu.Lock()
+	e, ok := h.electors[vip.Name]
+	if ok && e.generation == vip.Generation && e.ifName == ifName {
+		h.mu.Unlock()
+		e.sync(h.ctx)
+		return
+	}
+	delete(h.electors, vip.Name)
+	h.mu.Unlock()
+
+	if ok {
+		e.stop()
+	}
+
+	e = newElector(vip.Name, ifName, string(vip.Spec.Address), vip.Generation)
+	e.start(h.ctx, &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: utils.VirtualIPLeaseNamespace,
+			Name:      utils.GetVirtualIPLeaseName(vip.Name),
+		},
+		Client: h.leaseClient,
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: h.nodeName,
+		},
+	})
+
+	h.mu.Lock()
+	h.electors[vip.Name] = e
+	h.mu.Unlock()
+
+	logrus.Infof("join the election of virtual ip %s on %s", vip.Name, ifName)
+}
+
+func (h *Handler) hasElector(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.electors[name]
+	return ok
+}
+
+func (h *Handler) stopElector(name string) {
+	h.mu.Lock()
+	e, ok := h.electors[name]
+	delete(h.electors, name)
+	h.mu.Unlock()
+
+	if ok {
+		e.stop()
+		logrus.Infof("leave the election of virtual ip %s", name)
+	}
+}
@@ -0,0 +1,158 @@
+package vip
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+
+	// the gratuitous ARP is sent several times in case some of them are lost
+	garpCount    = 3
+	garpInterval = time.Second
+)
+
+// elector takes part in the election of the owner of a virtual IP on this node, the virtual IP is assigned to
+// the interface while this node is the owner
+type elector struct {
+	name       string
+	ifName     string
+	address    string
+	generation int64
+
+	mu      sync.Mutex
+	leading bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newElector(name, ifName, address string, generation int64) *elector {
+	return &elector{
+		name:       name,
+		ifName:     ifName,
+		address:    address,
+		generation: generation,
+		done:       make(chan struct{}),
+	}
+}
+
+func (e *elector) start(ctx context.Context, lock resourcelock.Interface) {
+	ctx, e.cancel = context.WithCancel(ctx)
+	go e.run(ctx, lock)
+}
+
+// stop releases the lease if this node is the owner and waits until the virtual IP is removed
+func (e *elector) stop() {
+	e.cancel()
+	<-e.done
+}
+
+func (e *elector) run(ctx context.Context, lock resourcelock.Interface) {
+	defer close(e.done)
+
+	for {
+		le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+			Lock:            lock,
+			LeaseDuration:   leaseDuration,
+			RenewDeadline:   renewDeadline,
+			RetryPeriod:     retryPeriod,
+			ReleaseOnCancel: true,
+			Name:            e.name,
+			Callbacks: leaderelection.LeaderCallbacks{
+				OnStartedLeading: e.onStartedLeading,
+				OnStoppedLeading: e.onStoppedLeading,
+			},
+		})
+		if err != nil {
+			logrus.Errorf("create elector of virtual ip %s failed, error: %s", e.name, err.Error())
+			return
+		}
+
+		le.Run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		// the lease fails to be renewed, take part in the election again
+		logrus.Infof("lost the lease of virtual ip %s, rejoin the election", e.name)
+	}
+}
+
+func (e *elector) isLeading() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// sync adds the virtual IP back if it is removed while this node is the owner, and removes the virtual IP left by
+// the previous run of the agent if this node isn't the owner
+func (e *elector) sync(ctx context.Context) {
+	if e.isLeading() {
+		e.assign(ctx)
+		return
+	}
+	if err := iface.DelVirtualIP(e.ifName, e.address); err != nil {
+		logrus.Errorf("remove virtual ip %s from %s failed, error: %s", e.address, e.ifName, err.Error())
+	}
+}
+
+func (e *elector) onStartedLeading(ctx context.Context) {
+	e.mu.Lock()
+	e.leading = true
+	e.mu.Unlock()
+
+	logrus.Infof("take over virtual ip %s %s on %s", e.name, e.address, e.ifName)
+	e.assign(ctx)
+}
+
+// onStoppedLeading is called whenever the election ends, even if this node has never been the owner
+func (e *elector) onStoppedLeading() {
+	e.mu.Lock()
+	e.leading = false
+	e.mu.Unlock()
+
+	if err := iface.DelVirtualIP(e.ifName, e.address); err != nil {
+		logrus.Errorf("remove virtual ip %s from %s failed, error: %s", e.address, e.ifName, err.Error())
+	}
+}
+
+// assign adds the virtual IP to the interface and announces it if the address is newly added
+func (e *elector) assign(ctx context.Context) {
+	added, err := iface.AddVirtualIP(e.ifName, e.address)
+	if err != nil {
+		logrus.Errorf("add virtual ip %s to %s failed, error: %s", e.address, e.ifName, err.Error())
+		return
+	}
+	if !added {
+		return
+	}
+
+	ip, _, err := net.ParseCIDR(e.address)
+	if err != nil {
+		logrus.Errorf("parse virtual ip %s failed, error: %s", e.address, err.Error())
+		return
+	}
+	go func() {
+		for i := 0; i < garpCount; i++ {
+			if err := iface.SendGratuitousARP(e.ifName, ip); err != nil {
+				logrus.Errorf("announce virtual ip %s failed, error: %s", e.address, err.Error())
+			}
+			select {
+			case <-time.After(garpInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"reflect"
 
 	"github.com/sirupsen/logrus"
 
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/vishvananda/netlink"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -35,6 +37,7 @@ type Handler struct {
 	nadCache                    ctlcniv1.NetworkAttachmentDefinitionCache
 	vcClient                    ctlnetworkv1.VlanConfigClient
 	vcCache                     ctlnetworkv1.VlanConfigCache
+	vcController                ctlnetworkv1.VlanConfigController
 	vsClient                    ctlnetworkv1.VlanStatusClient
 	vsCache                     ctlnetworkv1.VlanStatusCache
 	cnClient                    ctlnetworkv1.ClusterNetworkClient
@@ -59,6 +62,7 @@ func Register(ctx context.Context, management *config.Management) error {
 		nadCache:                    nads.Cache(),
 		vcClient:                    vcs,
 		vcCache:                     vcs.Cache(),
+		vcController:                vcs,
 		vsClient:                    vss,
 		vsCache:                     vss.Cache(),
 		cnClient:                    cns,
@@ -74,6 +78,7 @@ func Register(ctx context.Context, management *config.Management) error {
 
 	vcs.OnChange(ctx, ControllerName, handler.OnChange)
 	vcs.OnRemove(ctx, ControllerName, handler.OnRemove)
+	nodes.OnChange(ctx, ControllerName, handler.OnNodeChange)
 
 	return nil
 }
@@ -113,6 +118,39 @@ func (h Handler) OnChange(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanCo
 	return vc, nil
 }
 
+// OnNodeChange enqueues the vlanconfigs set up on this node whose node override applied on this node is changed by
+// the node labels
+func (h Handler) OnNodeChange(_ string, node *corev1.Node) (*corev1.Node, error) {
+	if node == nil || node.DeletionTimestamp != nil || node.Name != h.nodeName {
+		return node, nil
+	}
+
+	vcs, err := h.vcCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		if vc.Spec.ClusterNetwork == utils.ManagementClusterNetworkName || vc.DeletionTimestamp != nil {
+			continue
+		}
+		vs, err := h.getVlanStatus(vc)
+		if err != nil {
+			return nil, err
+		}
+		// the vlanconfig which is not set up on this node is enqueued by the manager once it matches this node
+		if vs == nil {
+			continue
+		}
+		if _, override := utils.GetEffectiveUplink(vc, node); override != vs.Status.UplinkOverride {
+			logrus.Infof("node override of vlanconfig %s on node %s is changed from %q to %q", vc.Name, h.nodeName,
+				vs.Status.UplinkOverride, override)
+			h.vcController.Enqueue(vc.Name)
+		}
+	}
+
+	return node, nil
+}
+
 func (h Handler) OnRemove(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
 	if vc == nil || vc.Spec.ClusterNetwork == utils.ManagementClusterNetworkName {
 		return nil, nil
@@ -196,9 +234,16 @@ func (h Handler) setupVLAN(vc *networkv1.VlanConfig) error {
 	var v *vlan.Vlan
 	var setupErr error
 	var uplink *iface.Link
+	var effectiveUplink *networkv1.Uplink
+	var override string
 
+	// merge the uplink with the node override matching this node
+	effectiveUplink, override, setupErr = h.getEffectiveUplink(vc)
+	if setupErr != nil {
+		goto updateStatus
+	}
 	// construct uplink
-	uplink, setupErr = setUplink(vc)
+	uplink, setupErr = setUplink(vc, effectiveUplink)
 	if setupErr != nil {
 		goto updateStatus
 	}
@@ -210,7 +255,7 @@ func (h Handler) setupVLAN(vc *networkv1.VlanConfig) error {
 
 updateStatus:
 	// Update status and still return setup error if not nil
-	if err := h.updateStatus(vc, setupErr); err != nil {
+	if err := h.updateStatus(vc, override, setupErr); err != nil {
 		return fmt.Errorf("update status into vlanstatus %s failed, error: %w, setup error: %v",
 			h.statusName(vc.Spec.ClusterNetwork), err, setupErr)
 	}
@@ -304,34 +349,61 @@ updateStatus:
 	return nil
 }
 
-func setUplink(vc *networkv1.VlanConfig) (*iface.Link, error) {
+func (h Handler) getEffectiveUplink(vc *networkv1.VlanConfig) (*networkv1.Uplink, string, error) {
+	if len(vc.Spec.NodeOverrides) == 0 {
+		return &vc.Spec.Uplink, "", nil
+	}
+
+	node, err := h.nodeCache.Get(h.nodeName)
+	if err != nil {
+		return nil, "", fmt.Errorf("get node %s failed, error: %w", h.nodeName, err)
+	}
+	uplink, override := utils.GetEffectiveUplink(vc, node)
+	if override != "" {
+		logrus.Infof("vlanconfig %s applies node override %s on node %s", vc.Name, override, h.nodeName)
+	}
+
+	return uplink, override, nil
+}
+
+func setUplink(vc *networkv1.VlanConfig, uplink *networkv1.Uplink) (*iface.Link, error) {
 	// set link attributes
 	linkAttrs := netlink.NewLinkAttrs()
 	linkAttrs.Name = vc.Spec.ClusterNetwork + utils.BondSuffix
-	if vc.Spec.Uplink.LinkAttrs != nil {
-		linkAttrs.MTU = vc.Spec.Uplink.LinkAttrs.MTU
-		linkAttrs.TxQLen = vc.Spec.Uplink.LinkAttrs.TxQLen
-		if vc.Spec.Uplink.LinkAttrs.HardwareAddr != nil {
-			linkAttrs.HardwareAddr = vc.Spec.Uplink.LinkAttrs.HardwareAddr
+	if uplink.LinkAttrs != nil {
+		linkAttrs.MTU = uplink.LinkAttrs.MTU
+		linkAttrs.TxQLen = uplink.LinkAttrs.TxQLen
+		if uplink.LinkAttrs.HardwareAddr != nil {
+			linkAttrs.HardwareAddr = uplink.LinkAttrs.HardwareAddr
 		}
 	}
 	// Note: do not use &netlink.Bond{}
 	bond := netlink.NewLinkBond(linkAttrs)
 	// set bonding mode
 	mode := netlink.BOND_MODE_ACTIVE_BACKUP
-	if vc.Spec.Uplink.BondOptions != nil && vc.Spec.Uplink.BondOptions.Mode != "" {
-		mode = netlink.StringToBondMode(string(vc.Spec.Uplink.BondOptions.Mode))
+	if uplink.BondOptions != nil && uplink.BondOptions.Mode != "" {
+		mode = netlink.StringToBondMode(string(uplink.BondOptions.Mode))
 	}
 	bond.Mode = mode
 
 	miimon := utils.DefaultValueMiimon
 	// set bonding miimon
-	if vc.Spec.Uplink.BondOptions != nil && vc.Spec.Uplink.BondOptions.Miimon != -1 {
-		miimon = vc.Spec.Uplink.BondOptions.Miimon
+	if uplink.BondOptions != nil && uplink.BondOptions.Miimon != -1 {
+		miimon = uplink.BondOptions.Miimon
 	}
 
 	bond.Miimon = miimon
-	b := iface.NewBond(bond, vc.Spec.Uplink.NICs)
+
+	if err := setBondOptions(bond, uplink.BondOptions); err != nil {
+		return nil, err
+	}
+
+	nics, err := getUplinkNICs(vc.Name, uplink, linkAttrs.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	b := iface.NewBond(bond, nics)
 	if err := b.EnsureBond(); err != nil {
 		return nil, err
 	}
@@ -339,7 +411,87 @@ func setUplink(vc *networkv1.VlanConfig) (*iface.Link, error) {
 	return &iface.Link{Link: b}, nil
 }
 
-func (h Handler) updateStatus(vc *networkv1.VlanConfig, setupErr error) error {
+// getUplinkNICs resolves the nic selector on this node if it is set
+func getUplinkNICs(vcName string, uplink *networkv1.Uplink, bondName string) ([]string, error) {
+	if uplink.NICSelector == nil {
+		return uplink.NICs, nil
+	}
+
+	candidates, err := iface.ListNICs(bondName)
+	if err != nil {
+		return nil, err
+	}
+	nics, err := utils.MatchNICs(uplink.NICSelector, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(nics) == 0 {
+		return nil, fmt.Errorf("nic selector of vlanconfig %s matches no NICs", vcName)
+	}
+	logrus.Infof("nic selector of vlanconfig %s matches NICs %v", vcName, nics)
+
+	return nics, nil
+}
+
+// setBondOptions sets the optional bonding options, the options which are not set keep -1 and are not sent to the kernel
+func setBondOptions(bond *netlink.Bond, opts *networkv1.BondOptions) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.LacpRate != "" {
+		bond.LacpRate = netlink.StringToBondLacpRate(string(opts.LacpRate))
+	}
+	if opts.XmitHashPolicy != "" {
+		bond.XmitHashPolicy = netlink.StringToBondXmitHashPolicy(string(opts.XmitHashPolicy))
+	}
+	if opts.AdSelect != "" {
+		bond.AdSelect = netlink.StringToBondAdSelectMap[string(opts.AdSelect)]
+	}
+	if opts.PrimaryReselect != "" {
+		bond.PrimaryReselect = netlink.StringToBondPrimaryReselectMap[string(opts.PrimaryReselect)]
+	}
+	if opts.FailOverMac != "" {
+		bond.FailOverMac = netlink.StringToBondFailOverMacMap[string(opts.FailOverMac)]
+	}
+	if opts.MinLinks > 0 {
+		bond.MinLinks = opts.MinLinks
+	}
+	if opts.UpDelay > 0 {
+		bond.UpDelay = opts.UpDelay
+	}
+	if opts.DownDelay > 0 {
+		bond.DownDelay = opts.DownDelay
+	}
+
+	if opts.ArpInterval > 0 {
+		bond.ArpInterval = opts.ArpInterval
+		// ARP monitoring and MII monitoring are exclusive, disable the default miimon
+		if opts.Miimon == -1 {
+			bond.Miimon = 0
+		}
+		for _, target := range opts.ArpIPTargets {
+			ip := net.ParseIP(target)
+			if ip == nil {
+				return fmt.Errorf("invalid arp ip target %s", target)
+			}
+			bond.ArpIpTargets = append(bond.ArpIpTargets, ip)
+		}
+	}
+
+	// the primary NIC is recorded by the kernel even if it has not been enslaved yet
+	if opts.Primary != "" {
+		l, err := netlink.LinkByName(opts.Primary)
+		if err != nil {
+			return fmt.Errorf("get primary NIC %s failed, error: %w", opts.Primary, err)
+		}
+		bond.Primary = l.Attrs().Index
+	}
+
+	return nil
+}
+
+func (h Handler) updateStatus(vc *networkv1.VlanConfig, override string, setupErr error) error {
 	var vStatus *networkv1.VlanStatus
 	name := h.statusName(vc.Spec.ClusterNetwork)
 	vs, getErr := h.vsCache.Get(name)
@@ -377,6 +529,7 @@ func (h Handler) updateStatus(vc *networkv1.VlanConfig, setupErr error) error {
 	vStatus.Status.VlanConfig = vc.Name
 	vStatus.Status.LinkMonitor = vc.Spec.ClusterNetwork
 	vStatus.Status.Node = h.nodeName
+	vStatus.Status.UplinkOverride = override
 	if setupErr == nil {
 		networkv1.Ready.SetStatusBool(vStatus, true)
 		networkv1.Ready.Message(vStatus, "")
@@ -24,13 +24,15 @@ import (
 
 const (
 	controllerName = "harvester-network-manager-cn-controller"
-	nicMonitorName = "nic"
 )
 
 type Handler struct {
 	lmClient          ctlnetworkv1.LinkMonitorClient
 	lmCache           ctlnetworkv1.LinkMonitorCache
+	l2rClient         ctlnetworkv1.L2ReachabilityClient
+	l2rCache          ctlnetworkv1.L2ReachabilityCache
 	cnClient          ctlnetworkv1.ClusterNetworkClient
+	cnCache           ctlnetworkv1.ClusterNetworkCache
 	nadClient         ctlcniv1.NetworkAttachmentDefinitionClient
 	nadCache          ctlcniv1.NetworkAttachmentDefinitionCache
 	hostNetworkCache  ctlnetworkv1.HostNetworkConfigCache
@@ -44,11 +46,15 @@ func Register(ctx context.Context, management *config.Management) error {
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
 	hns := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
 	nodes := management.CoreFactory.Core().V1().Node()
+	l2rs := management.HarvesterNetworkFactory.Network().V1beta1().L2Reachability()
 
 	h := Handler{
 		lmClient:          lms,
 		lmCache:           lms.Cache(),
+		l2rClient:         l2rs,
+		l2rCache:          l2rs.Cache(),
 		cnClient:          cns,
+		cnCache:           cns.Cache(),
 		nadClient:         nads,
 		nadCache:          nads.Cache(),
 		hostNetworkCache:  hns.Cache(),
@@ -60,14 +66,39 @@ func Register(ctx context.Context, management *config.Management) error {
 		return fmt.Errorf("initialize error: %w", err)
 	}
 
+	cns.OnChange(ctx, controllerName, h.MigrateAnnotations)
 	cns.OnChange(ctx, controllerName, h.EnsureLinkMonitor)
+	cns.OnChange(ctx, controllerName, h.EnsureL2Reachability)
 	cns.OnChange(ctx, controllerName, h.SetNadReadyLabel)
 	cns.OnChange(ctx, controllerName, h.SetHostNetworkStatus)
 	cns.OnRemove(ctx, controllerName, h.DeleteLinkMonitor)
+	cns.OnRemove(ctx, controllerName, h.DeleteL2Reachability)
+	l2rs.OnChange(ctx, controllerName, h.OnL2ReachabilityChange)
 
 	return nil
 }
 
+// MigrateAnnotations moves the legacy MTU annotation to the spec of cluster network
+func (h Handler) MigrateAnnotations(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	migrated, err := utils.MigrateClusterNetworkMTUAnnotation(cnCopy)
+	if err != nil {
+		// an invalid annotation can't be migrated, keep it for user to fix
+		logrus.Warnf("failed to migrate cluster network %s MTU annotation, error: %v", cn.Name, err)
+		return cn, nil
+	}
+	if !migrated {
+		return cn, nil
+	}
+
+	logrus.Infof("migrate cluster network %s annotation %s to spec.mtu %v", cn.Name, utils.KeyUplinkMTU, cnCopy.Spec.MTU)
+	return h.cnClient.Update(cnCopy)
+}
+
 func (h Handler) EnsureLinkMonitor(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
 	if cn == nil || cn.DeletionTimestamp != nil {
 		return nil, nil
@@ -96,6 +127,64 @@ func (h Handler) DeleteLinkMonitor(_ string, cn *networkv1.ClusterNetwork) (*net
 	return cn, nil
 }
 
+// EnsureL2Reachability creates the default L2Reachability with the same name as the cluster network to probe all
+// the VLANs in use, if it's enabled by the annotation of the cluster network
+func (h Handler) EnsureL2Reachability(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil || cn.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	if !networkv1.Ready.IsTrue(cn.Status) || cn.Annotations[utils.KeyL2Reachability] != utils.ValueTrue {
+		return cn, nil
+	}
+
+	if err := h.ensureL2Reachability(cn.Name); err != nil {
+		return nil, fmt.Errorf("ensure l2 reachability for cluster network %s failed, error: %w", cn.Name, err)
+	}
+
+	return cn, nil
+}
+
+func (h Handler) DeleteL2Reachability(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
+	if cn == nil {
+		return nil, nil
+	}
+
+	if err := h.deleteL2Reachability(cn.Name); err != nil {
+		return nil, fmt.Errorf("delete l2 reachability for cluster network %s failed, error: %w", cn.Name, err)
+	}
+
+	return cn, nil
+}
+
+// OnL2ReachabilityChange removes the annotation from the cluster network when the default L2Reachability is deleted
+// by the user, so that it isn't created again
+func (h Handler) OnL2ReachabilityChange(name string, l2r *networkv1.L2Reachability) (*networkv1.L2Reachability, error) {
+	if l2r != nil {
+		return l2r, nil
+	}
+
+	cn, err := h.cnCache.Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cn.DeletionTimestamp != nil || cn.Annotations[utils.KeyL2Reachability] != utils.ValueTrue {
+		return nil, nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	delete(cnCopy.Annotations, utils.KeyL2Reachability)
+	if _, err := h.cnClient.Update(cnCopy); err != nil {
+		return nil, fmt.Errorf("remove annotation %s from cluster network %s failed, error: %w", utils.KeyL2Reachability, name, err)
+	}
+	logrus.Infof("l2 reachability %s is deleted, disable it on cluster network %s", name, name)
+
+	return nil, nil
+}
+
 func (h Handler) SetNadReadyLabel(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
 	if cn == nil {
 		return nil, nil
@@ -142,6 +231,39 @@ func (h Handler) deleteLinkMonitor(name string) error {
 	return h.lmClient.Delete(name, &metav1.DeleteOptions{})
 }
 
+func (h Handler) ensureL2Reachability(name string) error {
+	_, err := h.l2rCache.Get(name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		return nil
+	}
+
+	if _, err := h.l2rClient.Create(&networkv1.L2Reachability{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: networkv1.L2ReachabilitySpec{
+			ClusterNetwork: name,
+		},
+	}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (h Handler) deleteL2Reachability(name string) error {
+	if _, err := h.l2rCache.Get(name); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	} else if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return h.l2rClient.Delete(name, &metav1.DeleteOptions{})
+}
+
 func (h Handler) setNadReadyLabel(cn *networkv1.ClusterNetwork) error {
 	isReady := utils.ValueFalse
 	// Set all net-attach-defs under the cluster network to be deleted as unready
@@ -347,7 +469,7 @@ func (h Handler) initializeClusterNetwork() error {
 func (h Handler) initializeLinkMonitor() error {
 	nicMonitor := &networkv1.LinkMonitor{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: nicMonitorName,
+			Name: utils.NICLinkMonitorName,
 		},
 		Spec: networkv1.LinkMonitorSpec{
 			TargetLinkRule: networkv1.TargetLinkRule{
@@ -356,7 +478,7 @@ func (h Handler) initializeLinkMonitor() error {
 		},
 	}
 	if _, err := h.lmClient.Create(nicMonitor); err != nil && !apierrors.IsAlreadyExists(err) {
-		return fmt.Errorf("create %s failed, error: %w", nicMonitorName, err)
+		return fmt.Errorf("create %s failed, error: %w", utils.NICLinkMonitorName, err)
 	}
 
 	return nil
@@ -0,0 +1,113 @@
+package hostnetworkconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	ControllerName = "harvester-network-manager-hostnetworkconfig-controller"
+
+	IPModePool = "pool"
+)
+
+type Handler struct {
+	hncClient ctlnetworkv1.HostNetworkConfigClient
+	nodeCache ctlcorev1.NodeCache
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := Handler{
+		hncClient: hncs,
+		nodeCache: nodes.Cache(),
+	}
+
+	hncs.OnChange(ctx, ControllerName, handler.AllocateIPs)
+
+	return nil
+}
+
+// AllocateIPs allocates the IPs from the pool to the nodes matched by the node selector and records them in the
+// status, the agents assign the allocated IPs to the host network interfaces
+func (h Handler) AllocateIPs(_ string, hnc *networkv1.HostNetworkConfig) (*networkv1.HostNetworkConfig, error) {
+	if hnc == nil || hnc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	var allocations map[string]networkv1.IPAddr
+	var allocateErr error
+	if hnc.Spec.Mode == IPModePool {
+		nodes, err := h.matchedNodes(hnc.Spec.NodeSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		pool, err := utils.NewIPPool(hnc.Spec.Pool)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool of host network config %s, error: %w", hnc.Name, err)
+		}
+
+		// the nodes allocated an IP are recorded even if the pool is exhausted
+		allocations, allocateErr = pool.Allocate(hnc.Status.Allocations, nodes)
+		if len(allocations) == 0 {
+			allocations = nil
+		}
+	}
+
+	if !reflect.DeepEqual(allocations, hnc.Status.Allocations) {
+		logrus.Infof("update ip allocations of host network config %s to %v", hnc.Name, allocations)
+		hncCopy := hnc.DeepCopy()
+		hncCopy.Status.Allocations = allocations
+		updated, err := h.hncClient.UpdateStatus(hncCopy)
+		if err != nil {
+			return nil, fmt.Errorf("update ip allocations of host network config %s failed, error: %w", hnc.Name, err)
+		}
+		hnc = updated
+	}
+
+	if allocateErr != nil {
+		return nil, fmt.Errorf("allocate ips for host network config %s failed, error: %w", hnc.Name, allocateErr)
+	}
+
+	return hnc, nil
+}
+
+func (h Handler) matchedNodes(nodeSelector *metav1.LabelSelector) ([]string, error) {
+	selector := labels.Everything()
+	if nodeSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(nodeSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	nodes, err := h.nodeCache.List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node.DeletionTimestamp != nil {
+			continue
+		}
+		names = append(names, node.Name)
+	}
+
+	return names, nil
+}
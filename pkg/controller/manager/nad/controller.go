@@ -5,12 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"sync"
-	"time"
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/go-ping/ping"
 	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	ctlbatchv1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/batch/v1"
 	"github.com/tidwall/sjson"
@@ -37,28 +34,18 @@ const (
 	jobServiceAccountName = "harvester-network-helper"
 	JobEnvNadNetwork      = "NAD_NETWORKS"
 	JobEnvDHCPServer      = "DHCP_SERVER"
+	JobEnvDHCPOptions     = "DHCP_OPTIONS"
+	JobEnvNodeName        = "NODE_NAME"
 
 	defaultInterface = "net1"
-
-	defaultPingTimes            = 5
-	defaultPingTimeout          = 10 * time.Second
-	defaultCheckPeriod          = 15 * time.Minute
-	defaultAllowPackageLostRate = 20
 )
 
-type nameWithNamespace struct {
-	namespace string
-	name      string
-}
-
-type checkMap struct {
-	items map[nameWithNamespace]string
-	mutex *sync.RWMutex
-}
-
 type Handler struct {
 	namespace   string
 	helperImage string
+	// the layer 3 network is discovered by the agents unless the helper jobs are enabled
+	// the flag is published on the mgmt cluster network for the agents to follow
+	enableL3DiscoveryJob bool
 
 	jobClient ctlbatchv1.JobClient
 	jobCache  ctlbatchv1.JobCache
@@ -66,54 +53,78 @@ type Handler struct {
 	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
 	cnClient  ctlnetworkv1.ClusterNetworkClient
 	cnCache   ctlnetworkv1.ClusterNetworkCache
-
-	*checkMap
+	hncCache  ctlnetworkv1.HostNetworkConfigCache
 }
 
 func Register(ctx context.Context, management *config.Management) error {
 	jobs := management.BatchFactory.Batch().V1().Job()
 	nads := management.CniFactory.K8s().V1().NetworkAttachmentDefinition()
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
 
 	handler := &Handler{
-		namespace:   management.Options.Namespace,
-		helperImage: management.Options.HelperImage,
-		jobClient:   jobs,
-		jobCache:    jobs.Cache(),
-		nadClient:   nads,
-		nadCache:    nads.Cache(),
-		cnClient:    cns,
-		cnCache:     cns.Cache(),
-		checkMap: &checkMap{
-			items: make(map[nameWithNamespace]string),
-			mutex: new(sync.RWMutex),
-		},
+		namespace:            management.Options.Namespace,
+		helperImage:          management.Options.HelperImage,
+		enableL3DiscoveryJob: management.Options.EnableL3DiscoveryJob,
+		jobClient:            jobs,
+		jobCache:             jobs.Cache(),
+		nadClient:            nads,
+		nadCache:             nads.Cache(),
+		cnClient:             cns,
+		cnCache:              cns.Cache(),
+		hncCache:             hncs.Cache(),
 	}
 
-	go handler.CheckConnectivityPeriodically()
-
 	nads.OnChange(ctx, ControllerName, handler.OnChange)
 	nads.OnRemove(ctx, ControllerName, handler.OnRemove)
 	cns.OnChange(ctx, ControllerName, handler.OnCNChange)
 	return nil
 }
 
+// publishL3DiscoveryJob annotates the mgmt cluster network if the layer 3 networks are discovered by the helper jobs,
+// the agents skip the discovery as long as the annotation is present
+func (h Handler) publishL3DiscoveryJob(cn *networkv1.ClusterNetwork) error {
+	if (cn.Annotations[utils.KeyL3DiscoveryJob] == utils.ValueTrue) == h.enableL3DiscoveryJob {
+		return nil
+	}
+
+	cnCopy := cn.DeepCopy()
+	if h.enableL3DiscoveryJob {
+		if cnCopy.Annotations == nil {
+			cnCopy.Annotations = make(map[string]string)
+		}
+		cnCopy.Annotations[utils.KeyL3DiscoveryJob] = utils.ValueTrue
+	} else {
+		delete(cnCopy.Annotations, utils.KeyL3DiscoveryJob)
+	}
+	if _, err := h.cnClient.Update(cnCopy); err != nil {
+		return fmt.Errorf("failed to publish the layer 3 discovery mode on cluster network %s, error: %w", cn.Name, err)
+	}
+
+	return nil
+}
+
 // Sync cluster network MTU value to all attached NADs
 func (h Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.ClusterNetwork, error) {
 	if cn == nil || cn.DeletionTimestamp != nil {
 		return nil, nil
 	}
 
-	// MTU annotation is not set
-	curMTU := cn.Annotations[utils.KeyUplinkMTU]
-	if curMTU == "" {
-		return nil, nil
+	if cn.Name == utils.ManagementClusterNetworkName {
+		if err := h.publishL3DiscoveryJob(cn); err != nil {
+			return nil, err
+		}
 	}
 
-	MTU, err := utils.GetMTUFromString(curMTU)
+	MTU, err := utils.GetMTUFromClusterNetwork(cn)
 	// skip if MTU is invalid
 	if err != nil {
-		logrus.Infof("cluster network %v has MTU annotation %v/%v with invalid value, skip to sync with nad %s", cn.Name, utils.KeyUplinkMTU, curMTU, err.Error())
+		logrus.Infof("skip to sync MTU with nads, error %s", err.Error())
+		return nil, nil
+	}
+
+	// MTU is not set
+	if MTU == 0 {
 		return nil, nil
 	}
 
@@ -145,7 +156,7 @@ func (h Handler) OnCNChange(_ string, cn *networkv1.ClusterNetwork) (*networkv1.
 		if _, err := h.nadClient.Update(nadCopy); err != nil {
 			return nil, err
 		}
-		logrus.Infof("sync cluster network %v annotation mtu %v/%v to nad %v", cn.Name, utils.KeyUplinkMTU, curMTU, nad.Name)
+		logrus.Infof("sync cluster network %v mtu %v to nad %v", cn.Name, MTU, nad.Name)
 	}
 
 	return nil, nil
@@ -319,6 +330,11 @@ func (h Handler) EnsureJob2GetLayer3NetworkInfo(nad *cniv1.NetworkAttachmentDefi
 
 	logrus.Infof("EnsureJob2GetLayer3NetworkInfo netconf: %+v", networkConf)
 
+	// the agents discover the layer 3 network, the jobs left by the previous version are cleared
+	if !h.enableL3DiscoveryJob {
+		return h.clearJob(nad)
+	}
+
 	// when nad's vlan is changed, the nad is marked as outdated
 	// the outdated is updated by the following created new job
 	// with the vlan label on job, it does not rely on nad's outdated flag to make decision
@@ -333,16 +349,8 @@ func (h Handler) EnsureJob2GetLayer3NetworkInfo(nad *cniv1.NetworkAttachmentDefi
 		}
 	}
 
+	// the connectivity to the gateway is checked by the agents from the VLAN on the nodes
 	if networkConf.CIDR != "" && networkConf.Gateway != "" && !networkConf.Outdated {
-		// initialize connectivity
-		if networkConf.Connectivity == "" {
-			if err := h.initializeConnectivity(nad, networkConf); err != nil {
-				logrus.Errorf("initialize connectivity of nad %s/%s failed, error: %v", nad.Namespace, nad.Name, err)
-			} else {
-				logrus.Infof("initialize connectivity of nad %s/%s successfully", nad.Namespace, nad.Name)
-			}
-		}
-		h.addItem(nad.Namespace, nad.Name, networkConf.Gateway)
 		return nil
 	}
 
@@ -361,7 +369,6 @@ func (h Handler) clearJob(nad *cniv1.NetworkAttachmentDefinition) error {
 	} else if err == nil {
 		// already onDelete, wait
 		if job.DeletionTimestamp != nil {
-			h.removeItem(nad.Namespace, nad.Name)
 			return nil
 		}
 
@@ -372,14 +379,8 @@ func (h Handler) clearJob(nad *cniv1.NetworkAttachmentDefinition) error {
 		}); err != nil {
 			return err
 		}
-
-		h.removeItem(nad.Namespace, nad.Name)
-		return nil
 	}
 
-	// IsNotFound, remove item from list anyway
-	h.removeItem(nad.Namespace, nad.Name)
-
 	return nil
 }
 
@@ -391,7 +392,6 @@ func (h Handler) clearOutdatedJob(nad *cniv1.NetworkAttachmentDefinition, netcon
 	} else if err == nil {
 		// job is onDelete, wait
 		if job.DeletionTimestamp != nil {
-			h.removeItem(nad.Namespace, nad.Name)
 			return nil
 		}
 
@@ -408,14 +408,8 @@ func (h Handler) clearOutdatedJob(nad *cniv1.NetworkAttachmentDefinition, netcon
 		}); err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
-
-		h.removeItem(nad.Namespace, nad.Name)
-		return nil
 	}
 
-	// IsNotFound, remove item from list anyway
-	h.removeItem(nad.Namespace, nad.Name)
-
 	return nil
 }
 
@@ -427,8 +421,13 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 			return err
 		}
 
+		dhcpOptions, err := h.getDHCPOptions(nad, l2netconf)
+		if err != nil {
+			return err
+		}
+
 		// create job
-		job, err = constructJob(nil, h.namespace, h.helperImage, nad, l2netconf, l3netconf)
+		job, err = constructJob(nil, h.namespace, h.helperImage, dhcpOptions, nad, l2netconf, l3netconf)
 		if err != nil {
 			return err
 		}
@@ -445,7 +444,7 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 	}
 
 	// is already existing, update if some fields are invalid
-	jobCopy, err := constructJob(job, h.namespace, h.helperImage, nad, l2netconf, l3netconf)
+	jobCopy, err := constructJob(job, h.namespace, h.helperImage, "", nad, l2netconf, l3netconf)
 	if err != nil {
 		return err
 	}
@@ -462,7 +461,36 @@ func (h Handler) createOrUpdateJob(nad *cniv1.NetworkAttachmentDefinition, l2net
 	return nil
 }
 
-func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf, l3netconf *utils.Layer3NetworkConf) (*batchv1.Job, error) {
+// getDHCPOptions returns the DHCP options of the host network on the same cluster network and VLAN as the nad, so that
+// the helper identifies itself to the DHCP servers of the VLAN as the nodes do
+func (h Handler) getDHCPOptions(nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf) (string, error) {
+	if netconf == nil {
+		return "", nil
+	}
+
+	hncs, err := h.hncCache.List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+
+	cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel)
+	for _, hnc := range hncs {
+		if hnc.Spec.ClusterNetwork != cnName || int(hnc.Spec.VlanID) != netconf.Vlan || hnc.Spec.DHCPOptions == nil {
+			continue
+		}
+
+		options, err := json.Marshal(hnc.Spec.DHCPOptions)
+		if err != nil {
+			return "", err
+		}
+		return string(options), nil
+	}
+
+	return "", nil
+}
+
+func constructJob(cur *batchv1.Job, namespace, image, dhcpOptions string, nad *cniv1.NetworkAttachmentDefinition, netconf *utils.NetConf,
+	l3netconf *utils.Layer3NetworkConf) (*batchv1.Job, error) {
 	job := &batchv1.Job{}
 	if cur != nil {
 		job = cur.DeepCopy()
@@ -510,6 +538,21 @@ func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkA
 				Name:  JobEnvDHCPServer,
 				Value: l3netconf.GetDHCPServerIPAddr(),
 			},
+			{
+				Name: JobEnvNodeName,
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						FieldPath: "spec.nodeName",
+					},
+				},
+			},
+		}
+
+		if dhcpOptions != "" {
+			env = append(env, corev1.EnvVar{
+				Name:  JobEnvDHCPOptions,
+				Value: dhcpOptions,
+			})
 		}
 
 		// Only inject non-default log levels during initialization.
@@ -563,128 +606,3 @@ func constructJob(cur *batchv1.Job, namespace, image string, nad *cniv1.NetworkA
 
 	return job, nil
 }
-
-func (h Handler) CheckConnectivityPeriodically() {
-	ticker := time.NewTicker(defaultCheckPeriod)
-
-	for range ticker.C {
-		h.mutex.RLock()
-		for nn, gw := range h.items {
-			go func(nn nameWithNamespace, gw string) {
-				if err := h.checkConnectivity(nn.namespace, nn.name, gw); err != nil {
-					logrus.Error(err)
-					return
-				}
-			}(nn, gw)
-		}
-		h.mutex.RUnlock()
-	}
-}
-
-func (h Handler) checkConnectivity(namespace, name, gw string) error {
-	connectivity, err := pingGW(gw)
-	if err != nil {
-		return err
-	}
-
-	nad, err := h.nadCache.Get(namespace, name)
-	if err != nil {
-		return fmt.Errorf("get cache of %s/%s failed, error: %s", namespace, name, err)
-	}
-
-	networkConf := &utils.Layer3NetworkConf{}
-	if nad.Annotations != nil && nad.Annotations[utils.KeyNetworkRoute] != "" {
-		networkConf, err = utils.NewLayer3NetworkConf(nad.Annotations[utils.KeyNetworkRoute])
-		if err != nil {
-			return fmt.Errorf("invalid layer 3 network configure: %w", err)
-		}
-	}
-
-	if networkConf.Connectivity == connectivity {
-		return nil
-	}
-	networkConf.Connectivity = connectivity
-
-	return h.updateNetworkConf(nad, networkConf)
-}
-
-func (h Handler) initializeConnectivity(nad *cniv1.NetworkAttachmentDefinition, networkConf *utils.Layer3NetworkConf) error {
-	connectivity, err := pingGW(networkConf.Gateway)
-	if err != nil {
-		return err
-	}
-
-	if networkConf.Connectivity == connectivity {
-		return nil
-	}
-	networkConf.Connectivity = connectivity
-
-	return h.updateNetworkConf(nad, networkConf)
-}
-
-func pingGW(gw string) (utils.Connectivity, error) {
-	connectivity := utils.PingFailed
-
-	pinger, err := ping.NewPinger(gw)
-	if err != nil {
-		return connectivity, fmt.Errorf("create pinger failed, error: %s", err.Error())
-	}
-	pinger.SetPrivileged(true)
-	pinger.Count = defaultPingTimes
-	pinger.Timeout = defaultPingTimeout
-	if err := pinger.Run(); err != nil {
-		return connectivity, fmt.Errorf("ping gw %s failed, error: %w", gw, err)
-	} // blocks until finished
-	stats := pinger.Statistics()
-
-	if stats.PacketLoss > defaultAllowPackageLostRate {
-		connectivity = utils.Unconnectable
-	} else {
-		connectivity = utils.Connectable
-	}
-
-	return connectivity, nil
-}
-
-func (h Handler) updateNetworkConf(nad *cniv1.NetworkAttachmentDefinition, networkConf *utils.Layer3NetworkConf) error {
-	nadCopy := nad.DeepCopy()
-	confStr, err := networkConf.ToString()
-	if err != nil {
-		return err
-	}
-	if nadCopy.Annotations == nil {
-		nadCopy.Annotations = make(map[string]string)
-	}
-	nadCopy.Annotations[utils.KeyNetworkRoute] = confStr
-	if _, err := h.nadClient.Update(nadCopy); err != nil {
-		return fmt.Errorf("update nad %s/%s failed, error: %w", nad.Namespace, nad.Name, err)
-	}
-
-	return nil
-}
-
-func (c *checkMap) addItem(namespace, name, addr string) {
-	nn := nameWithNamespace{namespace: namespace, name: name}
-	c.mutex.RLock()
-	oldAddr, ok := c.items[nn]
-	c.mutex.RUnlock()
-
-	if !ok || oldAddr != addr {
-		c.mutex.Lock()
-		c.items[nn] = addr
-		c.mutex.Unlock()
-	}
-}
-
-func (c *checkMap) removeItem(namespace, name string) {
-	nn := nameWithNamespace{namespace: namespace, name: name}
-	c.mutex.RLock()
-	_, ok := c.items[nn]
-	c.mutex.RUnlock()
-
-	if ok {
-		c.mutex.Lock()
-		delete(c.items, nn)
-		c.mutex.Unlock()
-	}
-}
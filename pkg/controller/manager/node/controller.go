@@ -15,6 +15,7 @@ import (
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/config"
+	hostnetworkconfigctl "github.com/harvester/harvester-network-controller/pkg/controller/manager/hostnetworkconfig"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
@@ -33,6 +34,7 @@ type Handler struct {
 	lmClient                ctlnetworkv1.LinkMonitorClient
 	hostNetworkConfigClient ctlnetworkv1.HostNetworkConfigClient
 	hostNetworkConfigCache  ctlnetworkv1.HostNetworkConfigCache
+	hostNetworkConfigCtrl   ctlnetworkv1.HostNetworkConfigController
 	nadCache                ctlcniv1.NetworkAttachmentDefinitionCache
 	nadClient               ctlcniv1.NetworkAttachmentDefinitionClient
 }
@@ -55,6 +57,7 @@ func Register(ctx context.Context, management *config.Management) error {
 		lmClient:                lms,
 		hostNetworkConfigClient: hns,
 		hostNetworkConfigCache:  hns.Cache(),
+		hostNetworkConfigCtrl:   hns,
 		nadClient:               nads,
 		nadCache:                nads.Cache(),
 	}
@@ -102,6 +105,14 @@ func (h Handler) OnChange(_ string, node *corev1.Node) (*corev1.Node, error) {
 		return nil, fmt.Errorf("failed to update underlay for overlay network, node: %s, error: %w", node.Name, err)
 	}
 
+	if err := h.enqueuePoolHostNetworkConfigs(node); err != nil {
+		return nil, fmt.Errorf("failed to enqueue pool host network configs, node: %s, error: %w", node.Name, err)
+	}
+
+	if err := h.pruneNodeConnectivity(node, false); err != nil {
+		return nil, fmt.Errorf("failed to prune gateway connectivity of nads, node: %s, error: %w", node.Name, err)
+	}
+
 	return node, nil
 }
 
@@ -118,6 +129,12 @@ func (h Handler) OnRemove(_ string, node *corev1.Node) (*corev1.Node, error) {
 	if err := h.clearLinkStatus(node.Name); err != nil {
 		return nil, err
 	}
+	if err := h.releasePoolIPs(node.Name); err != nil {
+		return nil, err
+	}
+	if err := h.pruneNodeConnectivity(node, true); err != nil {
+		return nil, err
+	}
 
 	return node, nil
 }
@@ -389,3 +406,94 @@ func (h Handler) updateUnderlayForOverlayNetwork(node *corev1.Node) error {
 
 	return nil
 }
+
+// enqueue the hostnetworkconfigs in pool mode to allocate or release the IP of the node if its matching is changed
+func (h Handler) enqueuePoolHostNetworkConfigs(node *corev1.Node) error {
+	hostnetworkconfigs, err := h.hostNetworkConfigCache.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list hostnetworkconfigs, error: %w", err)
+	}
+
+	for _, hostnetworkconfig := range hostnetworkconfigs {
+		if hostnetworkconfig.DeletionTimestamp != nil || hostnetworkconfig.Spec.Mode != hostnetworkconfigctl.IPModePool {
+			continue
+		}
+
+		selector := labels.Everything()
+		if hostnetworkconfig.Spec.NodeSelector != nil {
+			if selector, err = metav1.LabelSelectorAsSelector(hostnetworkconfig.Spec.NodeSelector); err != nil {
+				return fmt.Errorf("failed to convert label selector, hnc: %s, error: %w", hostnetworkconfig.Name, err)
+			}
+		}
+
+		_, allocated := hostnetworkconfig.Status.Allocations[node.Name]
+		if selector.Matches(labels.Set(node.Labels)) != allocated {
+			h.hostNetworkConfigCtrl.Enqueue(hostnetworkconfig.Name)
+		}
+	}
+
+	return nil
+}
+
+// release the IPs allocated to the removed node from the pools
+func (h Handler) releasePoolIPs(nodeName string) error {
+	hostnetworkconfigs, err := h.hostNetworkConfigCache.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("failed to list hostnetworkconfigs, error: %w", err)
+	}
+
+	for _, hostnetworkconfig := range hostnetworkconfigs {
+		if _, ok := hostnetworkconfig.Status.Allocations[nodeName]; !ok {
+			continue
+		}
+
+		hncCopy := hostnetworkconfig.DeepCopy()
+		delete(hncCopy.Status.Allocations, nodeName)
+		if _, err := h.hostNetworkConfigClient.UpdateStatus(hncCopy); err != nil {
+			return fmt.Errorf("release ip of hostnetworkconfig failed, hnc: %s, node: %s, error: %w", hostnetworkconfig.Name, nodeName, err)
+		}
+	}
+
+	return nil
+}
+
+// remove the gateway connectivity checked on the node from the nads of the cluster networks which the node doesn't
+// join any more, the agent of the node can't remove it after the node is removed or leaves the cluster network
+func (h Handler) pruneNodeConnectivity(node *corev1.Node, removed bool) error {
+	nads, err := utils.NewNadGetter(h.nadCache).ListAllNads()
+	if err != nil {
+		return fmt.Errorf("failed to list nads, error: %w", err)
+	}
+
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil || !utils.IsVlanNad(nad) {
+			continue
+		}
+		cnName := utils.GetNadLabel(nad, utils.KeyClusterNetworkLabel)
+		if !removed && node.Labels[utils.GetLabelKeyOfClusterNetwork(cnName)] == utils.ValueTrue {
+			continue
+		}
+
+		networkConf, err := utils.NewLayer3NetworkConfFromNad(nad)
+		if err != nil {
+			continue
+		}
+		if _, ok := networkConf.NodeConnectivity[node.Name]; !ok {
+			continue
+		}
+		networkConf.SetNodeConnectivity(node.Name, "")
+
+		confStr, err := networkConf.ToString()
+		if err != nil {
+			return err
+		}
+		nadCopy := nad.DeepCopy()
+		utils.SetNadAnnotation(nadCopy, utils.KeyNetworkRoute, confStr)
+		if _, err := h.nadClient.Update(nadCopy); err != nil {
+			return fmt.Errorf("update nad %s/%s failed, error: %w", nad.Namespace, nad.Name, err)
+		}
+		logrus.Infof("remove gateway connectivity of node %s from nad %s/%s", node.Name, nad.Namespace, nad.Name)
+	}
+
+	return nil
+}
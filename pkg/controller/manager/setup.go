@@ -3,8 +3,10 @@ package manager
 import (
 	"github.com/harvester/harvester-network-controller/pkg/config"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/clusternetwork"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/hostnetworkconfig"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/nad"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/node"
+	"github.com/harvester/harvester-network-controller/pkg/controller/manager/vip"
 	"github.com/harvester/harvester-network-controller/pkg/controller/manager/vlanconfig"
 )
 
@@ -13,4 +15,6 @@ var RegisterFuncList = []config.RegisterFunc{
 	vlanconfig.Register,
 	node.Register,
 	clusternetwork.Register,
+	vip.Register,
+	hostnetworkconfig.Register,
 }
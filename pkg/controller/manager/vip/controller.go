@@ -0,0 +1,221 @@
+package vip
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/config"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	ControllerName = "harvester-network-manager-vip-controller"
+
+	// the owner is refreshed while a node is eligible to take over the virtual IP, because the lease is acquired by
+	// the agents without notifying the manager
+	syncInterval = 10 * time.Second
+)
+
+type Handler struct {
+	vipClient     ctlnetworkv1.VirtualIPClient
+	vipController ctlnetworkv1.VirtualIPController
+	vipCache      ctlnetworkv1.VirtualIPCache
+	hncCache      ctlnetworkv1.HostNetworkConfigCache
+	nodeCache     ctlcorev1.NodeCache
+	leaseClient   coordinationv1.LeasesGetter
+}
+
+func Register(ctx context.Context, management *config.Management) error {
+	if !management.Options.EnableVipController {
+		return nil
+	}
+
+	vips := management.HarvesterNetworkFactory.Network().V1beta1().VirtualIP()
+	hncs := management.HarvesterNetworkFactory.Network().V1beta1().HostNetworkConfig()
+	nodes := management.CoreFactory.Core().V1().Node()
+
+	handler := Handler{
+		vipClient:     vips,
+		vipController: vips,
+		vipCache:      vips.Cache(),
+		hncCache:      hncs.Cache(),
+		nodeCache:     nodes.Cache(),
+		leaseClient:   management.ClientSet.CoordinationV1(),
+	}
+
+	vips.OnChange(ctx, ControllerName, handler.UpdateVirtualIPStatus)
+	vips.OnRemove(ctx, ControllerName, handler.DeleteLease)
+	hncs.OnChange(ctx, ControllerName, handler.EnqueueVirtualIPsByHostNetworkConfig)
+
+	return nil
+}
+
+// UpdateVirtualIPStatus reports the holder of the lease as the owner of the virtual IP. The owner is rechecked when
+// the lease expires if it's held, or periodically if it's not held while some node is eligible to take it over.
+func (h Handler) UpdateVirtualIPStatus(_ string, vip *networkv1.VirtualIP) (*networkv1.VirtualIP, error) {
+	if vip == nil || vip.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	vipCopy := vip.DeepCopy()
+	recheckAfter, err := h.computeVirtualIPStatus(vipCopy)
+	if err != nil {
+		return nil, fmt.Errorf("compute status of virtual ip %s failed, error: %w", vip.Name, err)
+	}
+	if recheckAfter > 0 {
+		h.vipController.EnqueueAfter(vip.Name, recheckAfter)
+	}
+
+	if equality.Semantic.DeepEqual(vip.Status, vipCopy.Status) {
+		return vip, nil
+	}
+
+	return h.vipClient.UpdateStatus(vipCopy)
+}
+
+// EnqueueVirtualIPsByHostNetworkConfig rechecks the owner once the host network is changed, e.g. a node becomes
+// eligible to take over the virtual IP
+func (h Handler) EnqueueVirtualIPsByHostNetworkConfig(_ string, hnc *networkv1.HostNetworkConfig) (*networkv1.HostNetworkConfig, error) {
+	if hnc == nil {
+		return nil, nil
+	}
+
+	vips, err := h.vipCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, vip := range vips {
+		if vip.Spec.HostNetworkConfig == hnc.Name {
+			h.vipController.Enqueue(vip.Name)
+		}
+	}
+
+	return hnc, nil
+}
+
+// computeVirtualIPStatus sets the owner and the ready condition, and returns when to recheck the owner, zero if
+// the owner can't change without the events of the virtual IP and the host network
+func (h Handler) computeVirtualIPStatus(vip *networkv1.VirtualIP) (time.Duration, error) {
+	hnc, err := h.hncCache.Get(vip.Spec.HostNetworkConfig)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+		vip.Status.Owner = ""
+		networkv1.Ready.False(vip)
+		networkv1.Ready.Message(vip, fmt.Sprintf("host network config %s is not found", vip.Spec.HostNetworkConfig))
+		return 0, nil
+	}
+
+	owner, expiredAt, err := h.getOwner(vip.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	vip.Status.Owner = owner
+	if owner != "" {
+		networkv1.Ready.True(vip)
+		networkv1.Ready.Message(vip, "")
+		// the lease is renewed before it expires, or taken over by another node after it expires
+		return max(time.Until(expiredAt), time.Second), nil
+	}
+
+	networkv1.Ready.False(vip)
+	networkv1.Ready.Message(vip, "no eligible node holds the virtual ip")
+	eligible, err := h.hasEligibleNode(vip, hnc)
+	if err != nil || !eligible {
+		return 0, err
+	}
+
+	return syncInterval, nil
+}
+
+// getOwner returns the holder of the lease and when the lease expires, empty if the lease is released or expired
+func (h Handler) getOwner(vipName string) (string, time.Time, error) {
+	lease, err := h.leaseClient.Leases(utils.VirtualIPLeaseNamespace).Get(context.TODO(), utils.GetVirtualIPLeaseName(vipName), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", time.Time{}, nil
+		}
+		return "", time.Time{}, err
+	}
+
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return "", time.Time{}, nil
+	}
+	expiredAt := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	if time.Now().After(expiredAt) {
+		return "", time.Time{}, nil
+	}
+
+	return *spec.HolderIdentity, expiredAt, nil
+}
+
+// hasEligibleNode tells whether the host network is ready on any node matching the node selector of the virtual IP,
+// the link state of the nodes is checked by the agents
+func (h Handler) hasEligibleNode(vip *networkv1.VirtualIP, hnc *networkv1.HostNetworkConfig) (bool, error) {
+	if hnc.DeletionTimestamp != nil {
+		return false, nil
+	}
+
+	selector := labels.Everything()
+	if vip.Spec.NodeSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(vip.Spec.NodeSelector); err != nil {
+			return false, err
+		}
+	}
+
+	for nodeName, nodeStatus := range hnc.Status.NodeStatus {
+		if !isReady(nodeStatus.Conditions) {
+			continue
+		}
+		node, err := h.nodeCache.Get(nodeName)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if selector.Matches(labels.Set(node.Labels)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DeleteLease deletes the lease of the virtual IP after the agents leave the election
+func (h Handler) DeleteLease(_ string, vip *networkv1.VirtualIP) (*networkv1.VirtualIP, error) {
+	if vip == nil {
+		return nil, nil
+	}
+
+	err := h.leaseClient.Leases(utils.VirtualIPLeaseNamespace).Delete(context.TODO(), utils.GetVirtualIPLeaseName(vip.Name), metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("delete lease of virtual ip %s failed, error: %w", vip.Name, err)
+	}
+
+	return vip, nil
+}
+
+func isReady(conditions []networkv1.Condition) bool {
+	for _, c := range conditions {
+		if c.Type == networkv1.Ready {
+			return c.Status == "True"
+		}
+	}
+	return false
+}
@@ -2,9 +2,12 @@ package vlanconfig
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -20,11 +23,12 @@ const (
 )
 
 type Handler struct {
-	cnClient ctlnetworkv1.ClusterNetworkClient
-	cnCache  ctlnetworkv1.ClusterNetworkCache
-	vsCache  ctlnetworkv1.VlanStatusCache
-	vcCache  ctlnetworkv1.VlanConfigCache
-	vcClient ctlnetworkv1.VlanConfigClient
+	cnClient     ctlnetworkv1.ClusterNetworkClient
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	vsCache      ctlnetworkv1.VlanStatusCache
+	vcCache      ctlnetworkv1.VlanConfigCache
+	vcClient     ctlnetworkv1.VlanConfigClient
+	vcController ctlnetworkv1.VlanConfigController
 }
 
 func Register(ctx context.Context, management *config.Management) error {
@@ -33,16 +37,19 @@ func Register(ctx context.Context, management *config.Management) error {
 	cns := management.HarvesterNetworkFactory.Network().V1beta1().ClusterNetwork()
 
 	handler := &Handler{
-		cnClient: cns,
-		cnCache:  cns.Cache(),
-		vsCache:  vss.Cache(),
-		vcCache:  vcs.Cache(),
-		vcClient: vcs,
+		cnClient:     cns,
+		cnCache:      cns.Cache(),
+		vsCache:      vss.Cache(),
+		vcCache:      vcs.Cache(),
+		vcClient:     vcs,
+		vcController: vcs,
 	}
 
 	vcs.OnChange(ctx, ControllerName, handler.EnsureClusterNetwork)
+	vcs.OnChange(ctx, ControllerName, handler.UpdateVlanConfigStatus)
 	vcs.OnRemove(ctx, ControllerName, handler.OnVlanConfigRemove)
 	vss.OnChange(ctx, ControllerName, handler.SetClusterNetworkReady)
+	vss.OnChange(ctx, ControllerName, handler.EnqueueVlanConfig)
 	vss.OnRemove(ctx, ControllerName, handler.SetClusterNetworkUnready)
 
 	return nil
@@ -85,9 +92,43 @@ func (h Handler) SetClusterNetworkUnready(_ string, vs *networkv1.VlanStatus) (*
 		return nil, fmt.Errorf("set cluster network unready before deleting vs %s failed, error: %w", vs.Name, err)
 	}
 
+	// the vs being deleted is not counted in the vlanconfig status any more
+	if vs.Status.VlanConfig != "" {
+		h.vcController.Enqueue(vs.Status.VlanConfig)
+	}
+
+	return vs, nil
+}
+
+// EnqueueVlanConfig triggers the status update of the vlanconfig when any of its vlanstatus is changed
+func (h Handler) EnqueueVlanConfig(_ string, vs *networkv1.VlanStatus) (*networkv1.VlanStatus, error) {
+	if vs == nil || vs.Status.ClusterNetwork == utils.ManagementClusterNetworkName || vs.Status.VlanConfig == "" {
+		return nil, nil
+	}
+
+	h.vcController.Enqueue(vs.Status.VlanConfig)
+
 	return vs, nil
 }
 
+// UpdateVlanConfigStatus aggregates the per-node vlanstatus into the vlanconfig status
+func (h Handler) UpdateVlanConfigStatus(_ string, vc *networkv1.VlanConfig) (*networkv1.VlanConfig, error) {
+	if vc == nil || vc.Spec.ClusterNetwork == utils.ManagementClusterNetworkName || vc.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	vcCopy := vc.DeepCopy()
+	if err := h.computeVlanConfigStatus(vcCopy); err != nil {
+		return nil, fmt.Errorf("compute status of vlanconfig %s failed, error: %w", vc.Name, err)
+	}
+
+	if equality.Semantic.DeepEqual(vc.Status, vcCopy.Status) {
+		return vc, nil
+	}
+
+	return h.vcClient.UpdateStatus(vcCopy)
+}
+
 func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 	name := vc.Spec.ClusterNetwork
 	curCn, err := h.cnCache.Get(name)
@@ -95,17 +136,16 @@ func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 		return err
 	}
 
-	MTU := utils.DefaultMTU
+	targetMTU := utils.DefaultMTU
 	vcMtu := utils.GetMTUFromVlanConfig(vc)
 	if utils.IsValidMTU(vcMtu) && vcMtu != 0 {
-		MTU = vcMtu
+		targetMTU = vcMtu
 	}
-	targetMTU := fmt.Sprintf("%v", MTU)
 
-	// check if the configured VC MTU value is updated to ClusterNetwork annotations
+	// check if the configured VC MTU value is updated to ClusterNetwork spec
 	if curCn != nil {
-		curMTU := curCn.Annotations[utils.KeyUplinkMTU]
-		if curMTU == targetMTU {
+		_, hasLegacyMTU := curCn.Annotations[utils.KeyUplinkMTU]
+		if curCn.Spec.MTU == targetMTU && !hasLegacyMTU {
 			// do not compare KeyMTUSourceVlanConfig, which is only used for reference
 			return nil
 		}
@@ -113,15 +153,16 @@ func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 		// update the new MTU, e.g. a new MTU value is set on the vlanconfig
 		cnCopy := curCn.DeepCopy()
 		if cnCopy.Annotations == nil {
-			cnCopy.Annotations = make(map[string]string, 2)
+			cnCopy.Annotations = make(map[string]string, 1)
 		}
-		cnCopy.Annotations[utils.KeyUplinkMTU] = targetMTU
+		delete(cnCopy.Annotations, utils.KeyUplinkMTU)
 		cnCopy.Annotations[utils.KeyMTUSourceVlanConfig] = vc.Name
+		cnCopy.Spec.MTU = targetMTU
 		if _, err := h.cnClient.Update(cnCopy); err != nil {
-			return fmt.Errorf("failed to update cluster network %s annotation %s with MTU %s: %w", name, utils.KeyUplinkMTU, targetMTU, err)
+			return fmt.Errorf("failed to update cluster network %s with MTU %v: %w", name, targetMTU, err)
 		}
 
-		logrus.Infof("update cluster network %s annotation %s to %s", name, utils.KeyUplinkMTU, targetMTU)
+		logrus.Infof("update cluster network %s MTU to %v", name, targetMTU)
 		return nil
 	}
 
@@ -130,10 +171,12 @@ func (h Handler) ensureClusterNetwork(vc *networkv1.VlanConfig) error {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
 			Annotations: map[string]string{
-				utils.KeyUplinkMTU:           targetMTU,
 				utils.KeyMTUSourceVlanConfig: vc.Name,
 			},
 		},
+		Spec: networkv1.ClusterNetworkSpec{
+			MTU: targetMTU,
+		},
 	}
 	if _, err := h.cnClient.Create(cn); err != nil {
 		return err
@@ -260,12 +303,13 @@ func (h Handler) OnVlanConfigRemove(_ string, vc *networkv1.VlanConfig) (*networ
 	if vcCandidate != nil {
 		mtu := utils.MTUDefaultTo(utils.GetMTUFromVlanConfig(vcCandidate))
 
-		// Please note that updating the MTU annotation here may be redundant,
+		// Please note that updating the MTU here may be redundant,
 		// as all `VlanConfig`s should have the same MTU value. However, it is
 		// safer to update them in case the boundary conditions change in the
 		// future.
-		cnCopy.Annotations[utils.KeyUplinkMTU] = fmt.Sprintf("%v", mtu)
+		delete(cnCopy.Annotations, utils.KeyUplinkMTU)
 		cnCopy.Annotations[utils.KeyMTUSourceVlanConfig] = vcCandidate.Name
+		cnCopy.Spec.MTU = mtu
 		if _, err := h.cnClient.Update(cnCopy); err != nil {
 			return nil, fmt.Errorf("failed to update cluster network %s after deleting source vlan config %s: %w", cnName, vc.Name, err)
 		}
@@ -274,13 +318,82 @@ func (h Handler) OnVlanConfigRemove(_ string, vc *networkv1.VlanConfig) (*networ
 		return nil, nil
 	}
 
-	// No candidate found, remove the annotations.
+	// No candidate found, remove the annotations and reset the MTU.
 	delete(cnCopy.Annotations, utils.KeyMTUSourceVlanConfig)
 	delete(cnCopy.Annotations, utils.KeyUplinkMTU)
+	cnCopy.Spec.MTU = 0
 	if _, err := h.cnClient.Update(cnCopy); err != nil {
-		return nil, fmt.Errorf("failed to clear cluster network %s MTU annotations after deleting source vlan config %s: %w", cnName, vc.Name, err)
+		return nil, fmt.Errorf("failed to clear cluster network %s MTU after deleting source vlan config %s: %w", cnName, vc.Name, err)
 	}
 
 	logrus.Infof("Cluster network %s MTU source vlan config %s removed as no remaining vlan config found", cnName, vc.Name)
 	return nil, nil
 }
+
+func (h Handler) computeVlanConfigStatus(vc *networkv1.VlanConfig) error {
+	matchedNodes, err := getMatchedNodes(vc)
+	if err != nil {
+		return err
+	}
+
+	status := &vc.Status
+	status.ObservedGeneration = vc.Generation
+	status.MatchedNodes = matchedNodes
+	status.MatchedNodeCount = len(matchedNodes)
+	status.ReadyNodeCount = 0
+	status.FailedNodeCount = 0
+	status.FailedNodes = nil
+
+	for _, node := range matchedNodes {
+		vs, err := h.vsCache.Get(utils.Name("", vc.Spec.ClusterNetwork, node))
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		// the vlanstatus is not created yet or it belongs to another vlanconfig, the node is still pending
+		if err != nil || vs.DeletionTimestamp != nil || vs.Status.VlanConfig != vc.Name {
+			continue
+		}
+
+		if networkv1.Ready.IsTrue(vs.Status) {
+			status.ReadyNodeCount++
+			continue
+		}
+
+		if networkv1.Ready.IsFalse(vs.Status) {
+			status.FailedNodeCount++
+			if status.FailedNodes == nil {
+				status.FailedNodes = make(map[string]string)
+			}
+			status.FailedNodes[node] = networkv1.Ready.GetMessage(vs.Status)
+		}
+	}
+
+	switch {
+	case status.FailedNodeCount > 0:
+		networkv1.Ready.False(status)
+		networkv1.Ready.Message(status, fmt.Sprintf("%d of %d matched nodes failed", status.FailedNodeCount, status.MatchedNodeCount))
+	case status.ReadyNodeCount < status.MatchedNodeCount:
+		networkv1.Ready.False(status)
+		networkv1.Ready.Message(status, fmt.Sprintf("%d of %d matched nodes are ready", status.ReadyNodeCount, status.MatchedNodeCount))
+	default:
+		networkv1.Ready.True(status)
+		networkv1.Ready.Message(status, "")
+	}
+
+	return nil
+}
+
+// getMatchedNodes returns the sorted node names from the matched nodes annotation
+func getMatchedNodes(vc *networkv1.VlanConfig) ([]string, error) {
+	if vc.Annotations == nil || vc.Annotations[utils.KeyMatchedNodes] == "" {
+		return nil, nil
+	}
+
+	var matchedNodes []string
+	if err := json.Unmarshal([]byte(vc.Annotations[utils.KeyMatchedNodes]), &matchedNodes); err != nil {
+		return nil, fmt.Errorf("invalid annotation %s, error: %w", utils.KeyMatchedNodes, err)
+	}
+	sort.Strings(matchedNodes)
+
+	return matchedNodes, nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2025 Harvester Network Controller Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1beta1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	networkharvesterhciiov1beta1 "github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/typed/network.harvesterhci.io/v1beta1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeL2Reachabilities implements L2ReachabilityInterface
+type fakeL2Reachabilities struct {
+	*gentype.FakeClientWithList[*v1beta1.L2Reachability, *v1beta1.L2ReachabilityList]
+	Fake *FakeNetworkV1beta1
+}
+
+func newFakeL2Reachabilities(fake *FakeNetworkV1beta1) networkharvesterhciiov1beta1.L2ReachabilityInterface {
+	return &fakeL2Reachabilities{
+		gentype.NewFakeClientWithList[*v1beta1.L2Reachability, *v1beta1.L2ReachabilityList](
+			fake.Fake,
+			"",
+			v1beta1.SchemeGroupVersion.WithResource("l2reachabilities"),
+			v1beta1.SchemeGroupVersion.WithKind("L2Reachability"),
+			func() *v1beta1.L2Reachability { return &v1beta1.L2Reachability{} },
+			func() *v1beta1.L2ReachabilityList { return &v1beta1.L2ReachabilityList{} },
+			func(dst, src *v1beta1.L2ReachabilityList) { dst.ListMeta = src.ListMeta },
+			func(list *v1beta1.L2ReachabilityList) []*v1beta1.L2Reachability {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1beta1.L2ReachabilityList, items []*v1beta1.L2Reachability) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -36,10 +36,18 @@ func (c *FakeNetworkV1beta1) HostNetworkConfigs() v1beta1.HostNetworkConfigInter
 	return newFakeHostNetworkConfigs(c)
 }
 
+func (c *FakeNetworkV1beta1) L2Reachabilities() v1beta1.L2ReachabilityInterface {
+	return newFakeL2Reachabilities(c)
+}
+
 func (c *FakeNetworkV1beta1) LinkMonitors() v1beta1.LinkMonitorInterface {
 	return newFakeLinkMonitors(c)
 }
 
+func (c *FakeNetworkV1beta1) VirtualIPs() v1beta1.VirtualIPInterface {
+	return newFakeVirtualIPs(c)
+}
+
 func (c *FakeNetworkV1beta1) VlanConfigs() v1beta1.VlanConfigInterface {
 	return newFakeVlanConfigs(c)
 }
@@ -22,8 +22,12 @@ type ClusterNetworkExpansion interface{}
 
 type HostNetworkConfigExpansion interface{}
 
+type L2ReachabilityExpansion interface{}
+
 type LinkMonitorExpansion interface{}
 
+type VirtualIPExpansion interface{}
+
 type VlanConfigExpansion interface{}
 
 type VlanStatusExpansion interface{}
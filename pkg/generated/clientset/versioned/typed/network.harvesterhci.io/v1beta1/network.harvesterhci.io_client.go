@@ -30,7 +30,9 @@ type NetworkV1beta1Interface interface {
 	RESTClient() rest.Interface
 	ClusterNetworksGetter
 	HostNetworkConfigsGetter
+	L2ReachabilitiesGetter
 	LinkMonitorsGetter
+	VirtualIPsGetter
 	VlanConfigsGetter
 	VlanStatusesGetter
 }
@@ -48,10 +50,18 @@ func (c *NetworkV1beta1Client) HostNetworkConfigs() HostNetworkConfigInterface {
 	return newHostNetworkConfigs(c)
 }
 
+func (c *NetworkV1beta1Client) L2Reachabilities() L2ReachabilityInterface {
+	return newL2Reachabilities(c)
+}
+
 func (c *NetworkV1beta1Client) LinkMonitors() LinkMonitorInterface {
 	return newLinkMonitors(c)
 }
 
+func (c *NetworkV1beta1Client) VirtualIPs() VirtualIPInterface {
+	return newVirtualIPs(c)
+}
+
 func (c *NetworkV1beta1Client) VlanConfigs() VlanConfigInterface {
 	return newVlanConfigs(c)
 }
@@ -39,6 +39,8 @@ type VlanConfigsGetter interface {
 type VlanConfigInterface interface {
 	Create(ctx context.Context, vlanConfig *networkharvesterhciiov1beta1.VlanConfig, opts v1.CreateOptions) (*networkharvesterhciiov1beta1.VlanConfig, error)
 	Update(ctx context.Context, vlanConfig *networkharvesterhciiov1beta1.VlanConfig, opts v1.UpdateOptions) (*networkharvesterhciiov1beta1.VlanConfig, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, vlanConfig *networkharvesterhciiov1beta1.VlanConfig, opts v1.UpdateOptions) (*networkharvesterhciiov1beta1.VlanConfig, error)
 	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
 	Get(ctx context.Context, name string, opts v1.GetOptions) (*networkharvesterhciiov1beta1.VlanConfig, error)
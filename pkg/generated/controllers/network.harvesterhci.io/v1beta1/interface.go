@@ -33,7 +33,9 @@ func init() {
 type Interface interface {
 	ClusterNetwork() ClusterNetworkController
 	HostNetworkConfig() HostNetworkConfigController
+	L2Reachability() L2ReachabilityController
 	LinkMonitor() LinkMonitorController
+	VirtualIP() VirtualIPController
 	VlanConfig() VlanConfigController
 	VlanStatus() VlanStatusController
 }
@@ -56,10 +58,18 @@ func (v *version) HostNetworkConfig() HostNetworkConfigController {
 	return generic.NewNonNamespacedController[*v1beta1.HostNetworkConfig, *v1beta1.HostNetworkConfigList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "HostNetworkConfig"}, "hostnetworkconfigs", v.controllerFactory)
 }
 
+func (v *version) L2Reachability() L2ReachabilityController {
+	return generic.NewNonNamespacedController[*v1beta1.L2Reachability, *v1beta1.L2ReachabilityList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "L2Reachability"}, "l2reachabilities", v.controllerFactory)
+}
+
 func (v *version) LinkMonitor() LinkMonitorController {
 	return generic.NewNonNamespacedController[*v1beta1.LinkMonitor, *v1beta1.LinkMonitorList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "LinkMonitor"}, "linkmonitors", v.controllerFactory)
 }
 
+func (v *version) VirtualIP() VirtualIPController {
+	return generic.NewNonNamespacedController[*v1beta1.VirtualIP, *v1beta1.VirtualIPList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "VirtualIP"}, "virtualips", v.controllerFactory)
+}
+
 func (v *version) VlanConfig() VlanConfigController {
 	return generic.NewNonNamespacedController[*v1beta1.VlanConfig, *v1beta1.VlanConfigList](schema.GroupVersionKind{Group: "network.harvesterhci.io", Version: "v1beta1", Kind: "VlanConfig"}, "vlanconfigs", v.controllerFactory)
 }
@@ -2,45 +2,249 @@ package helper
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/network/dhcp"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// the options requested besides the subnet mask, router, domain name and DNS servers requested by default
+var requestedOptions = dhcpv4.WithRequestedOptions(
+	dhcpv4.OptionNTPServers,
+	dhcpv4.OptionInterfaceMTU,
+	dhcpv4.OptionClasslessStaticRoute,
 )
 
-func obtainCIDRAndGw(iface string, serverAddr net.IP) (*net.IPNet, net.IP, error) {
-	var ack *dhcpv4.DHCPv4
-	var err error
-	if serverAddr != nil {
-		ack, err = sendInformMessage(iface, serverAddr)
-	} else {
-		ack, err = sendDiscoverMessage(iface)
+func newModifiers(l netlink.Link, dhcpOptions *networkv1.DHCPOptions, nodeName string) []dhcpv4.Modifier {
+	identity := dhcp.NewIdentity(dhcpOptions, nodeName, l.Attrs().HardwareAddr)
+	return append(identity.Modifiers(), requestedOptions)
+}
+
+// obtainLayer3Network asks the DHCP server for the network settings by the interface and returns the first answer, the
+// answers of the servers out of the allow-list are ignored
+func obtainLayer3Network(iface string, servers []net.IP, dhcpOptions *networkv1.DHCPOptions, nodeName string) (*dhcpv4.DHCPv4, error) {
+	l, err := netlink.LinkByName(iface)
+	if err != nil {
+		return nil, err
+	}
+	modifiers := newModifiers(l, dhcpOptions, nodeName)
+
+	switch {
+	case len(servers) == 0:
+		return sendDiscoverMessage(iface, modifiers)
+	case len(servers) == 1 && hasIPv4Address(l):
+		return sendInformMessage(iface, servers[0], modifiers)
+	default:
+		// the INFORM needs a client address, the interface without an address asks the servers by the DISCOVER instead
+		return sendDiscoverMessageToServers(iface, servers, modifiers)
 	}
+}
 
+// collectOffers broadcasts the DISCOVER and collects the OFFERs of all the servers answering within the window, the
+// OFFER of a server answering more than once is counted once
+func collectOffers(iface string, window time.Duration, dhcpOptions *networkv1.DHCPOptions, nodeName string) ([]*dhcpv4.DHCPv4, error) {
+	l, err := netlink.LinkByName(iface)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	defaultGateway := net.IP(ack.Options.Get(dhcpv4.OptionRouter))
-	cidr := &net.IPNet{}
-	cidr.Mask = ack.Options.Get(dhcpv4.OptionSubnetMask)
-	cidr.IP = defaultGateway.Mask(cidr.Mask)
+	broadcast, err := nclient4.New(iface, nclient4.WithTimeout(window), nclient4.WithRetry(1))
+	if err != nil {
+		return nil, err
+	}
+	defer broadcast.Close()
 
-	return cidr, defaultGateway, nil
+	discover, err := newDiscovery(broadcast, newModifiers(l, dhcpOptions, nodeName))
+	if err != nil {
+		return nil, err
+	}
+
+	var offers []*dhcpv4.DHCPv4
+	collect := func(msg *dhcpv4.DHCPv4) bool {
+		if msg.MessageType() == dhcpv4.MessageTypeOffer && findOffer(offers, serverOf(msg)) == nil {
+			offers = append(offers, msg)
+		}
+		// never matches to keep reading until the window is over
+		return false
+	}
+	if _, err := broadcast.SendAndRead(context.TODO(), nclient4.DefaultServers, discover, collect); err != nil &&
+		!errors.Is(err, nclient4.ErrNoResponse) {
+		return nil, err
+	}
+	if len(offers) == 0 {
+		return nil, nclient4.ErrNoResponse
+	}
+
+	return offers, nil
+}
+
+// setOffers records the servers of the offers and the network of the first offer from an allowed server. The network
+// is flagged with MultipleDHCPServers if any server is not allowed or the servers offer different networks.
+func setOffers(networkConf *utils.Layer3NetworkConf, offers []*dhcpv4.DHCPv4, allowed []net.IP) error {
+	var selected *dhcpv4.DHCPv4
+	multiple := false
+	for _, offer := range offers {
+		server := &utils.Layer3NetworkConf{}
+		if err := setLayer3Network(server, offer); err != nil {
+			logrus.Warnf("skip offer of DHCP server %s, error: %s", serverOf(offer), err.Error())
+			continue
+		}
+		networkConf.DHCPServers = append(networkConf.DHCPServers, utils.DHCPServer{
+			ServerIdentifier: serverOf(offer).String(),
+			CIDR:             server.CIDR,
+			Gateway:          server.Gateway,
+		})
+
+		first := networkConf.DHCPServers[0]
+		if !isAllowed(allowed, serverOf(offer)) || server.CIDR != first.CIDR || server.Gateway != first.Gateway {
+			multiple = true
+		}
+		if selected == nil && isAllowed(allowed, serverOf(offer)) {
+			selected = offer
+		}
+	}
+	if selected == nil {
+		return fmt.Errorf("no offer from the allowed DHCP servers %v", allowed)
+	}
+
+	if err := setLayer3Network(networkConf, selected); err != nil {
+		return err
+	}
+	if multiple {
+		networkConf.Connectivity = utils.MultipleDHCPServers
+	}
+
+	return nil
+}
+
+func isAllowed(allowed []net.IP, server net.IP) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, ip := range allowed {
+		if ip.Equal(server) {
+			return true
+		}
+	}
+	return false
 }
 
-func sendDiscoverMessage(iface string) (*dhcpv4.DHCPv4, error) {
+func findOffer(offers []*dhcpv4.DHCPv4, server net.IP) *dhcpv4.DHCPv4 {
+	for _, offer := range offers {
+		if serverOf(offer).Equal(server) {
+			return offer
+		}
+	}
+	return nil
+}
+
+// serverOf returns the identifier of the server, or the server address if the identifier is missing
+func serverOf(msg *dhcpv4.DHCPv4) net.IP {
+	if server := msg.ServerIdentifier(); server != nil {
+		return server
+	}
+	return msg.ServerIPAddr
+}
+
+// setLayer3Network records the network settings of the DHCP answer. The CIDR is the subnet of the offered address, or
+// of the client address in the answer to the INFORM which doesn't offer any address.
+func setLayer3Network(networkConf *utils.Layer3NetworkConf, msg *dhcpv4.DHCPv4) error {
+	mask := msg.SubnetMask()
+	if mask == nil {
+		return fmt.Errorf("subnet mask is not provided")
+	}
+	if ones, bits := mask.Size(); bits != 32 || ones == 0 {
+		return fmt.Errorf("invalid subnet mask %s", mask)
+	}
+	ip := msg.YourIPAddr
+	if ip == nil || ip.IsUnspecified() {
+		ip = msg.ClientIPAddr
+	}
+	if ip == nil || ip.IsUnspecified() {
+		return fmt.Errorf("no address is offered")
+	}
+	networkConf.CIDR = (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+
+	if routers := msg.Router(); len(routers) > 0 {
+		networkConf.Gateway = routers[0].String()
+	}
+	networkConf.DNSServers = ipsToStrings(msg.DNS())
+	networkConf.DomainName = msg.DomainName()
+	networkConf.NTPServers = ipsToStrings(msg.NTPServers())
+	if mtu, err := dhcpv4.GetUint16(dhcpv4.OptionInterfaceMTU, msg.Options); err == nil {
+		networkConf.MTU = int(mtu)
+	}
+	for _, r := range msg.ClasslessStaticRoute() {
+		route := utils.StaticRoute{Destination: r.Dest.String()}
+		// the destination is on the link if the router is 0.0.0.0
+		if r.Router != nil && !r.Router.IsUnspecified() {
+			route.Gateway = r.Router.String()
+		}
+		networkConf.Routes = append(networkConf.Routes, route)
+	}
+	if server := msg.ServerIdentifier(); server != nil {
+		networkConf.ServerIdentifier = server.String()
+	}
+
+	return nil
+}
+
+func ipsToStrings(ips []net.IP) []string {
+	var s []string
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return s
+}
+
+func sendDiscoverMessage(iface string, modifiers []dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	broadcast, err := nclient4.New(iface)
+	if err != nil {
+		return nil, err
+	}
+	defer broadcast.Close()
+	return broadcast.DiscoverOffer(context.TODO(), modifiers...)
+}
+
+// sendDiscoverMessageToServers broadcasts the DISCOVER and waits for the OFFER of the specified servers, the offers of
+// the other servers are ignored
+func sendDiscoverMessageToServers(iface string, servers []net.IP, modifiers []dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
 	broadcast, err := nclient4.New(iface)
 	if err != nil {
 		return nil, err
 	}
 	defer broadcast.Close()
-	return broadcast.DiscoverOffer(context.TODO())
+
+	discover, err := newDiscovery(broadcast, modifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	return broadcast.SendAndRead(context.TODO(), nclient4.DefaultServers, discover, func(msg *dhcpv4.DHCPv4) bool {
+		return msg.MessageType() == dhcpv4.MessageTypeOffer && isAllowed(servers, serverOf(msg))
+	})
+}
+
+func newDiscovery(client *nclient4.Client, modifiers []dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	return dhcpv4.NewDiscovery(client.InterfaceAddr(), dhcpv4.PrependModifiers(modifiers,
+		dhcpv4.WithOption(dhcpv4.OptMaxMessageSize(nclient4.MaxMessageSize)))...)
+}
+
+func hasIPv4Address(l netlink.Link) bool {
+	addresses, err := netlink.AddrList(l, netlink.FAMILY_V4)
+	return err == nil && len(addresses) > 0
 }
 
-func sendInformMessage(iface string, siaddr net.IP) (*dhcpv4.DHCPv4, error) {
+func sendInformMessage(iface string, siaddr net.IP, modifiers []dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
 	l, err := netlink.LinkByName(iface)
 	if err != nil {
 		return nil, err
@@ -63,7 +267,7 @@ func sendInformMessage(iface string, siaddr net.IP) (*dhcpv4.DHCPv4, error) {
 	}
 	defer unicast.Close()
 
-	inform, err := newInform(ciaddr, hwaddr)
+	inform, err := newInform(ciaddr, hwaddr, modifiers...)
 	if err != nil {
 		return nil, err
 	}
@@ -80,8 +284,8 @@ func sendInformMessage(iface string, siaddr net.IP) (*dhcpv4.DHCPv4, error) {
 	return response, nil
 }
 
-func newInform(ciaddr net.IP, hwaddr net.HardwareAddr) (*dhcpv4.DHCPv4, error) {
-	return dhcpv4.New(dhcpv4.WithHwAddr(hwaddr),
+func newInform(ciaddr net.IP, hwaddr net.HardwareAddr, modifiers ...dhcpv4.Modifier) (*dhcpv4.DHCPv4, error) {
+	return dhcpv4.New(dhcpv4.PrependModifiers(modifiers, dhcpv4.WithHwAddr(hwaddr),
 		dhcpv4.WithClientIP(ciaddr),
 		dhcpv4.WithRequestedOptions(
 			dhcpv4.OptionSubnetMask,
@@ -89,5 +293,5 @@ func newInform(ciaddr net.IP, hwaddr net.HardwareAddr) (*dhcpv4.DHCPv4, error) {
 			dhcpv4.OptionDomainName,
 			dhcpv4.OptionDomainNameServer,
 		),
-		dhcpv4.WithMessageType(dhcpv4.MessageTypeInform))
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeInform))...)
 }
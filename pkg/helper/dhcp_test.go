@@ -0,0 +1,194 @@
+package helper
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func TestSetLayer3Network(t *testing.T) {
+	_, dst, _ := net.ParseCIDR("10.10.0.0/16")
+	_, onLink, _ := net.ParseCIDR("192.168.100.0/24")
+
+	tests := []struct {
+		name      string
+		modifiers []dhcpv4.Modifier
+		expected  *utils.Layer3NetworkConf
+		wantErr   bool
+	}{
+		{
+			name: "offer with all the settings",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithYourIP(net.ParseIP("172.16.1.100")),
+				dhcpv4.WithNetmask(net.CIDRMask(24, 32)),
+				dhcpv4.WithRouter(net.ParseIP("172.16.1.254")),
+				dhcpv4.WithDNS(net.ParseIP("8.8.8.8"), net.ParseIP("1.1.1.1")),
+				dhcpv4.WithOption(dhcpv4.OptDomainName("example.com")),
+				dhcpv4.WithOption(dhcpv4.OptNTPServers(net.ParseIP("172.16.1.10"))),
+				dhcpv4.WithOption(dhcpv4.OptGeneric(dhcpv4.OptionInterfaceMTU, []byte{0x05, 0xdc})),
+				dhcpv4.WithOption(dhcpv4.OptClasslessStaticRoute(
+					&dhcpv4.Route{Dest: dst, Router: net.ParseIP("172.16.1.1")},
+					&dhcpv4.Route{Dest: onLink, Router: net.IPv4zero},
+				)),
+				dhcpv4.WithServerIP(net.ParseIP("172.16.1.2")),
+				dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.ParseIP("172.16.1.2"))),
+			},
+			expected: &utils.Layer3NetworkConf{
+				CIDR:       "172.16.1.0/24",
+				Gateway:    "172.16.1.254",
+				DNSServers: []string{"8.8.8.8", "1.1.1.1"},
+				DomainName: "example.com",
+				NTPServers: []string{"172.16.1.10"},
+				MTU:        1500,
+				Routes: []utils.StaticRoute{
+					{Destination: "10.10.0.0/16", Gateway: "172.16.1.1"},
+					{Destination: "192.168.100.0/24"},
+				},
+				ServerIdentifier: "172.16.1.2",
+			},
+		},
+		{
+			// the router is not in the subnet of the offered address, the CIDR must not be derived from it
+			name: "router outside the offered subnet",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithYourIP(net.ParseIP("172.16.1.100")),
+				dhcpv4.WithNetmask(net.CIDRMask(25, 32)),
+				dhcpv4.WithRouter(net.ParseIP("172.16.1.254")),
+			},
+			expected: &utils.Layer3NetworkConf{
+				CIDR:    "172.16.1.0/25",
+				Gateway: "172.16.1.254",
+			},
+		},
+		{
+			name: "answer to the inform",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithClientIP(net.ParseIP("172.16.2.20")),
+				dhcpv4.WithNetmask(net.CIDRMask(24, 32)),
+			},
+			expected: &utils.Layer3NetworkConf{
+				CIDR: "172.16.2.0/24",
+			},
+		},
+		{
+			name: "no subnet mask",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithYourIP(net.ParseIP("172.16.1.100")),
+				dhcpv4.WithRouter(net.ParseIP("172.16.1.254")),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no address",
+			modifiers: []dhcpv4.Modifier{
+				dhcpv4.WithNetmask(net.CIDRMask(24, 32)),
+				dhcpv4.WithRouter(net.ParseIP("172.16.1.254")),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := dhcpv4.New(append([]dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer)}, tc.modifiers...)...)
+			assert.NoError(t, err)
+
+			networkConf := &utils.Layer3NetworkConf{}
+			err = setLayer3Network(networkConf, msg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, networkConf)
+		})
+	}
+}
+
+func TestSetOffers(t *testing.T) {
+	newOffer := func(server, ip, gateway string) *dhcpv4.DHCPv4 {
+		offer, err := dhcpv4.New(
+			dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer),
+			dhcpv4.WithYourIP(net.ParseIP(ip)),
+			dhcpv4.WithNetmask(net.CIDRMask(24, 32)),
+			dhcpv4.WithRouter(net.ParseIP(gateway)),
+			dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.ParseIP(server))),
+		)
+		assert.NoError(t, err)
+		return offer
+	}
+	primary := newOffer("172.16.1.2", "172.16.1.100", "172.16.1.254")
+	secondary := newOffer("172.16.1.3", "172.16.1.200", "172.16.1.254")
+	rogue := newOffer("192.168.0.1", "192.168.0.100", "192.168.0.1")
+
+	tests := []struct {
+		name         string
+		offers       []*dhcpv4.DHCPv4
+		allowed      []net.IP
+		cidr         string
+		servers      int
+		connectivity utils.Connectivity
+		wantErr      bool
+	}{
+		{
+			name:    "single server",
+			offers:  []*dhcpv4.DHCPv4{primary},
+			cidr:    "172.16.1.0/24",
+			servers: 1,
+		},
+		{
+			name:    "servers agree",
+			offers:  []*dhcpv4.DHCPv4{primary, secondary},
+			cidr:    "172.16.1.0/24",
+			servers: 2,
+		},
+		{
+			name:         "servers disagree",
+			offers:       []*dhcpv4.DHCPv4{rogue, primary},
+			cidr:         "192.168.0.0/24",
+			servers:      2,
+			connectivity: utils.MultipleDHCPServers,
+		},
+		{
+			name:         "server out of the allow-list",
+			offers:       []*dhcpv4.DHCPv4{rogue, primary},
+			allowed:      []net.IP{net.ParseIP("172.16.1.2")},
+			cidr:         "172.16.1.0/24",
+			servers:      2,
+			connectivity: utils.MultipleDHCPServers,
+		},
+		{
+			name:    "allowed servers agree",
+			offers:  []*dhcpv4.DHCPv4{secondary, primary},
+			allowed: []net.IP{net.ParseIP("172.16.1.2"), net.ParseIP("172.16.1.3")},
+			cidr:    "172.16.1.0/24",
+			servers: 2,
+		},
+		{
+			name:    "no allowed server",
+			offers:  []*dhcpv4.DHCPv4{rogue},
+			allowed: []net.IP{net.ParseIP("172.16.1.2")},
+			servers: 1,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			networkConf := &utils.Layer3NetworkConf{}
+			err := setOffers(networkConf, tc.offers, tc.allowed)
+			assert.Len(t, networkConf.DHCPServers, tc.servers)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.cidr, networkConf.CIDR)
+			assert.Equal(t, tc.connectivity, networkConf.Connectivity)
+		})
+	}
+}
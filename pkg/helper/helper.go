@@ -1,13 +1,15 @@
 package helper
 
 import (
-	"net"
+	"time"
 
+	"github.com/insomniacslk/dhcp/dhcpv4"
 	"github.com/sirupsen/logrus"
 
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcni "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
@@ -15,25 +17,60 @@ import (
 
 type NetHelper struct {
 	nadClient ctlcniv1.NetworkAttachmentDefinitionClient
+	// the helper identifies itself to the DHCP servers with the DHCP options of the host network on the same VLAN
+	dhcpOptions *networkv1.DHCPOptions
+	nodeName    string
 }
 
-func New(cniFactory *ctlcni.Factory) *NetHelper {
+func New(cniFactory *ctlcni.Factory, dhcpOptions *networkv1.DHCPOptions, nodeName string) *NetHelper {
 	return &NetHelper{
-		nadClient: cniFactory.K8s().V1().NetworkAttachmentDefinition(),
+		nadClient:   cniFactory.K8s().V1().NetworkAttachmentDefinition(),
+		dhcpOptions: dhcpOptions,
+		nodeName:    nodeName,
 	}
 }
 
 func (n *NetHelper) GetVLANLayer3Network(selectedNetwork *nadv1.NetworkSelectionElement, serverIPAddr string) *utils.Layer3NetworkConf {
+	return DiscoverLayer3Network(selectedNetwork.InterfaceRequest, serverIPAddr, n.dhcpOptions, n.nodeName, 0)
+}
+
+// DiscoverLayer3Network obtains the CIDR, gateway and the other settings of the VLAN network from the DHCP servers by
+// the interface in the VLAN, only the servers in the allow-list of the server address are accepted if it's not empty.
+// The first answer is taken if the window is zero, otherwise the offers of all the servers answering within the window
+// are recorded to detect the rogue servers. The IPv6 prefix and router are discovered by the router solicitation. The
+// connectivity is DHCPFailed if neither an allowed server nor a router answers.
+func DiscoverLayer3Network(ifName, serverIPAddr string, dhcpOptions *networkv1.DHCPOptions, nodeName string, window time.Duration) *utils.Layer3NetworkConf {
 	networkConf := &utils.Layer3NetworkConf{
 		Mode:         utils.Auto,
 		ServerIPAddr: serverIPAddr,
 	}
-	cidr, gw, err := obtainCIDRAndGw(selectedNetwork.InterfaceRequest, net.ParseIP(serverIPAddr))
-	if err == nil {
-		networkConf.CIDR = cidr.String()
-		networkConf.Gateway = gw.String()
+	servers := networkConf.GetDHCPServerIPAddrs()
+
+	var err error
+	if window > 0 {
+		var offers []*dhcpv4.DHCPv4
+		if offers, err = collectOffers(ifName, window, dhcpOptions, nodeName); err == nil {
+			err = setOffers(networkConf, offers, servers)
+		}
 	} else {
-		logrus.Errorf("obtain CIDR and gw using DHCP protocol failed, error: %v", err)
+		var ack *dhcpv4.DHCPv4
+		if ack, err = obtainLayer3Network(ifName, servers, dhcpOptions, nodeName); err == nil {
+			err = setLayer3Network(networkConf, ack)
+		}
+	}
+	if err != nil {
+		logrus.Errorf("obtain layer 3 network on %s using DHCP protocol failed, error: %v", ifName, err)
+		// the servers answering are kept to tell the rogue servers
+		servers := networkConf.DHCPServers
+		networkConf.SetDHCPSettings(&utils.Layer3NetworkConf{})
+		networkConf.DHCPServers = servers
+	}
+
+	// the IPv6 network is discovered as well for the dual-stack and IPv6 only networks
+	if ipv6Err := discoverIPv6Network(networkConf, ifName); ipv6Err != nil {
+		logrus.Infof("discover IPv6 network on %s failed, error: %v", ifName, ipv6Err)
+	}
+	if err != nil && networkConf.IPv6Prefix == "" {
 		networkConf.Connectivity = utils.DHCPFailed
 	}
 
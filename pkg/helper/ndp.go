@@ -0,0 +1,285 @@
+package helper
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv6"
+	"github.com/insomniacslk/dhcp/dhcpv6/nclient6"
+	"github.com/insomniacslk/dhcp/iana"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// the router solicitation timers of RFC 4861 section 10, they're variables to be shortened in tests
+var (
+	maxRtrSolicitations     = 3
+	rtrSolicitationInterval = 4 * time.Second
+
+	linkLocalTimeout = 3 * time.Second
+)
+
+const (
+	// the neighbor discovery messages are only accepted with the hop limit 255 as they must not be forwarded
+	ndpHopLimit = 255
+
+	// the length of the router advertisement header including the ICMPv6 header
+	raHeaderLen = 16
+
+	ndpOptSourceLinkLayerAddr = 1
+	ndpOptPrefixInformation   = 3
+	ndpOptMTU                 = 5
+	ndpOptRDNSS               = 25
+
+	raFlagManaged        = 0x80
+	raFlagOther          = 0x40
+	prefixFlagOnLink     = 0x80
+	prefixFlagAutonomous = 0x40
+)
+
+var allRouters = net.ParseIP("ff02::2")
+
+var errNoRouterAdvertisement = errors.New("no router advertisement is received")
+
+// routerAdvertisement is the network settings advertised by the router
+type routerAdvertisement struct {
+	router net.IP
+	// managed and other mean the addresses and the other settings are provided by the DHCPv6 servers
+	managed        bool
+	other          bool
+	routerLifetime time.Duration
+	prefixes       []*net.IPNet
+	mtu            int
+	dnsServers     []net.IP
+}
+
+// discoverIPv6Network solicits the router advertisement on the interface and records the advertised network, the
+// other settings are requested from the DHCPv6 servers if the router says so. It returns an error if no router
+// answers, e.g. the VLAN is IPv4 only.
+func discoverIPv6Network(networkConf *utils.Layer3NetworkConf, ifName string) error {
+	ra, err := solicitRouterAdvertisement(ifName)
+	if err != nil {
+		return err
+	}
+	setIPv6Network(networkConf, ra)
+
+	if !ra.managed && !ra.other {
+		return nil
+	}
+	reply, err := requestIPv6Information(ifName)
+	if err != nil {
+		return fmt.Errorf("request DHCPv6 information failed, error: %w", err)
+	}
+	setIPv6Information(networkConf, reply)
+
+	return nil
+}
+
+// solicitRouterAdvertisement sends the router solicitations from the link-local address of the interface and returns
+// the first router advertisement received on the interface
+func solicitRouterAdvertisement(ifName string) (*routerAdvertisement, error) {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, fmt.Errorf("get interface %s failed, error: %w", ifName, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkLocalTimeout)
+	defer cancel()
+	if err := iface.WaitLinkLocalAddress(ctx, ifName); err != nil {
+		return nil, err
+	}
+
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return nil, fmt.Errorf("listen ICMPv6 failed, error: %w", err)
+	}
+	defer conn.Close()
+
+	pc := conn.IPv6PacketConn()
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeRouterAdvertisement)
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		return nil, err
+	}
+	if err := pc.SetControlMessage(ipv6.FlagInterface|ipv6.FlagHopLimit, true); err != nil {
+		return nil, err
+	}
+
+	rs := routerSolicitation(ifi.HardwareAddr)
+	cm := &ipv6.ControlMessage{HopLimit: ndpHopLimit, IfIndex: ifi.Index}
+	dst := &net.IPAddr{IP: allRouters, Zone: ifName}
+	buf := make([]byte, 1500)
+	for i := 0; i < maxRtrSolicitations; i++ {
+		if _, err := pc.WriteTo(rs, cm, dst); err != nil {
+			return nil, fmt.Errorf("send router solicitation on %s failed, error: %w", ifName, err)
+		}
+
+		if err := pc.SetReadDeadline(time.Now().Add(rtrSolicitationInterval)); err != nil {
+			return nil, err
+		}
+		for {
+			n, rcm, src, err := pc.ReadFrom(buf)
+			if err != nil {
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					break
+				}
+				return nil, fmt.Errorf("receive router advertisement failed, error: %w", err)
+			}
+
+			// RFC 4861 section 6.1.2, the advertisements are sent from the link-local address of the router
+			srcAddr, ok := src.(*net.IPAddr)
+			if rcm == nil || rcm.IfIndex != ifi.Index || rcm.HopLimit != ndpHopLimit || !ok || !srcAddr.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			ra, err := parseRouterAdvertisement(buf[:n])
+			if err != nil {
+				continue
+			}
+			ra.router = srcAddr.IP
+
+			return ra, nil
+		}
+	}
+
+	return nil, errNoRouterAdvertisement
+}
+
+// routerSolicitation returns the router solicitation with the source link-layer address option, the checksum is
+// filled by the kernel
+func routerSolicitation(mac net.HardwareAddr) []byte {
+	b := []byte{byte(ipv6.ICMPTypeRouterSolicitation), 0, 0, 0, 0, 0, 0, 0}
+	if len(mac) == 6 {
+		b = append(b, ndpOptSourceLinkLayerAddr, 1)
+		b = append(b, mac...)
+	}
+	return b
+}
+
+// parseRouterAdvertisement parses the router advertisement of RFC 4861 section 4.2, the prefixes which are neither
+// on-link nor for the address configuration and the expired options are skipped
+func parseRouterAdvertisement(b []byte) (*routerAdvertisement, error) {
+	if len(b) < raHeaderLen || b[0] != byte(ipv6.ICMPTypeRouterAdvertisement) || b[1] != 0 {
+		return nil, fmt.Errorf("invalid router advertisement")
+	}
+
+	ra := &routerAdvertisement{
+		managed:        b[5]&raFlagManaged != 0,
+		other:          b[5]&raFlagOther != 0,
+		routerLifetime: time.Duration(binary.BigEndian.Uint16(b[6:8])) * time.Second,
+	}
+
+	for opts := b[raHeaderLen:]; len(opts) > 0; {
+		if len(opts) < 2 {
+			return nil, fmt.Errorf("truncated option")
+		}
+		length := int(opts[1]) * 8
+		if length == 0 || length > len(opts) {
+			return nil, fmt.Errorf("invalid length %d of option %d", length, opts[0])
+		}
+		opt := opts[:length]
+		opts = opts[length:]
+
+		switch opt[0] {
+		case ndpOptPrefixInformation:
+			if length != 32 {
+				return nil, fmt.Errorf("invalid length %d of prefix information", length)
+			}
+			prefixLen, flags := int(opt[2]), opt[3]
+			valid := binary.BigEndian.Uint32(opt[4:8])
+			prefix := net.IP(opt[16:32])
+			if prefixLen > 128 || valid == 0 || flags&(prefixFlagOnLink|prefixFlagAutonomous) == 0 || prefix.IsLinkLocalUnicast() {
+				continue
+			}
+			mask := net.CIDRMask(prefixLen, 128)
+			ra.prefixes = append(ra.prefixes, &net.IPNet{IP: prefix.Mask(mask), Mask: mask})
+		case ndpOptMTU:
+			if length != 8 {
+				return nil, fmt.Errorf("invalid length %d of MTU", length)
+			}
+			ra.mtu = int(binary.BigEndian.Uint32(opt[4:8]))
+		case ndpOptRDNSS:
+			if length < 24 || (length-8)%16 != 0 {
+				return nil, fmt.Errorf("invalid length %d of recursive DNS servers", length)
+			}
+			if binary.BigEndian.Uint32(opt[4:8]) == 0 {
+				continue
+			}
+			for addrs := opt[8:]; len(addrs) > 0; addrs = addrs[16:] {
+				ra.dnsServers = append(ra.dnsServers, net.IP(append([]byte(nil), addrs[:16]...)))
+			}
+		}
+	}
+
+	return ra, nil
+}
+
+// setIPv6Network records the first advertised prefix and the router, the router is the gateway only if it's a default
+// router. The MTU of the DHCPv4 server is preferred.
+func setIPv6Network(networkConf *utils.Layer3NetworkConf, ra *routerAdvertisement) {
+	if len(ra.prefixes) > 0 {
+		networkConf.IPv6Prefix = ra.prefixes[0].String()
+	}
+	if ra.routerLifetime > 0 {
+		networkConf.IPv6Gateway = ra.router.String()
+	}
+	if networkConf.MTU == 0 {
+		networkConf.MTU = ra.mtu
+	}
+	networkConf.DNSServers = appendUniqueIPs(networkConf.DNSServers, ra.dnsServers)
+}
+
+// requestIPv6Information asks the DHCPv6 servers for the settings other than the addresses by the
+// INFORMATION-REQUEST of RFC 8415 section 18.2.6
+func requestIPv6Information(ifName string) (*dhcpv6.Message, error) {
+	client, err := nclient6.New(ifName)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	msg, err := dhcpv6.NewMessage(
+		dhcpv6.WithClientID(&dhcpv6.DUIDLL{HWType: iana.HWTypeEthernet, LinkLayerAddr: client.InterfaceAddr()}),
+		dhcpv6.WithRequestedOptions(dhcpv6.OptionDNSRecursiveNameServer, dhcpv6.OptionDomainSearchList, dhcpv6.OptionNTPServer),
+	)
+	if err != nil {
+		return nil, err
+	}
+	msg.MessageType = dhcpv6.MessageTypeInformationRequest
+	msg.AddOption(dhcpv6.OptElapsedTime(0))
+
+	return client.SendAndRead(context.TODO(), nclient6.AllDHCPRelayAgentsAndServers, msg, nclient6.IsMessageType(dhcpv6.MessageTypeReply))
+}
+
+// setIPv6Information records the DNS and NTP servers of the DHCPv6 reply besides the ones of the DHCPv4 server
+func setIPv6Information(networkConf *utils.Layer3NetworkConf, reply *dhcpv6.Message) {
+	networkConf.DNSServers = appendUniqueIPs(networkConf.DNSServers, reply.Options.DNS())
+	networkConf.NTPServers = appendUniqueIPs(networkConf.NTPServers, reply.Options.NTPServers())
+	if labels := reply.Options.DomainSearchList(); networkConf.DomainName == "" && labels != nil && len(labels.Labels) > 0 {
+		networkConf.DomainName = labels.Labels[0]
+	}
+}
+
+func appendUniqueIPs(s []string, ips []net.IP) []string {
+	for _, ip := range ips {
+		found := false
+		for _, existing := range s {
+			if existing == ip.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s = append(s, ip.String())
+		}
+	}
+	return s
+}
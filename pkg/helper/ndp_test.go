@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	header := []byte{134, 0, 0, 0, 64, raFlagOther, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0}
+	prefixInfo := func(prefix string, length, flags, valid byte) []byte {
+		b := []byte{ndpOptPrefixInformation, 4, length, flags, 0, 0, 0, valid, 0, 0, 0, valid, 0, 0, 0, 0}
+		return append(b, net.ParseIP(prefix).To16()...)
+	}
+	mtu := []byte{ndpOptMTU, 1, 0, 0, 0, 0, 0x05, 0xdc}
+	rdnss := append([]byte{ndpOptRDNSS, 3, 0, 0, 0, 0, 0x0e, 0x10}, net.ParseIP("2001:db8::53").To16()...)
+
+	concat := func(parts ...[]byte) []byte {
+		var b []byte
+		for _, p := range parts {
+			b = append(b, p...)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name     string
+		msg      []byte
+		prefixes []string
+		mtu      int
+		dns      []string
+		wantErr  bool
+	}{
+		{
+			name:     "prefix, mtu and dns servers",
+			msg:      concat(header, prefixInfo("2001:db8:1::", 64, prefixFlagOnLink|prefixFlagAutonomous, 0xff), mtu, rdnss),
+			prefixes: []string{"2001:db8:1::/64"},
+			mtu:      1500,
+			dns:      []string{"2001:db8::53"},
+		},
+		{
+			name: "expired and link-local prefixes are skipped",
+			msg: concat(header, prefixInfo("2001:db8:1::", 64, prefixFlagOnLink, 0),
+				prefixInfo("fe80::", 64, prefixFlagOnLink, 0xff), prefixInfo("2001:db8:2::", 64, prefixFlagOnLink, 0xff)),
+			prefixes: []string{"2001:db8:2::/64"},
+		},
+		{
+			name:    "zero option length",
+			msg:     concat(header, []byte{ndpOptMTU, 0, 0, 0, 0, 0, 0, 0}),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			msg:     header[:8],
+			wantErr: true,
+		},
+		{
+			name:    "not a router advertisement",
+			msg:     concat([]byte{133}, header[1:]),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ra, err := parseRouterAdvertisement(tc.msg)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.False(t, ra.managed)
+			assert.True(t, ra.other)
+			assert.Equal(t, 0x0708*time.Second, ra.routerLifetime)
+
+			var prefixes []string
+			for _, p := range ra.prefixes {
+				prefixes = append(prefixes, p.String())
+			}
+			assert.Equal(t, tc.prefixes, prefixes)
+			assert.Equal(t, tc.mtu, ra.mtu)
+
+			var dns []string
+			for _, ip := range ra.dnsServers {
+				dns = append(dns, ip.String())
+			}
+			assert.Equal(t, tc.dns, dns)
+		})
+	}
+}
+
+func TestSetIPv6Network(t *testing.T) {
+	_, prefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	ra := &routerAdvertisement{
+		router:         net.ParseIP("fe80::1"),
+		routerLifetime: 30 * time.Minute,
+		prefixes:       []*net.IPNet{prefix},
+		mtu:            9000,
+		dnsServers:     []net.IP{net.ParseIP("8.8.8.8"), net.ParseIP("2001:db8::53")},
+	}
+
+	networkConf := &utils.Layer3NetworkConf{MTU: 1500, DNSServers: []string{"8.8.8.8"}}
+	setIPv6Network(networkConf, ra)
+	assert.Equal(t, &utils.Layer3NetworkConf{
+		MTU:         1500,
+		DNSServers:  []string{"8.8.8.8", "2001:db8::53"},
+		IPv6Prefix:  "2001:db8:1::/64",
+		IPv6Gateway: "fe80::1",
+	}, networkConf)
+
+	// the router which is not a default router is not the gateway
+	ra.routerLifetime = 0
+	networkConf = &utils.Layer3NetworkConf{}
+	setIPv6Network(networkConf, ra)
+	assert.Equal(t, "2001:db8:1::/64", networkConf.IPv6Prefix)
+	assert.Equal(t, "", networkConf.IPv6Gateway)
+	assert.Equal(t, 9000, networkConf.MTU)
+}
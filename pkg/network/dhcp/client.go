@@ -0,0 +1,338 @@
+package dhcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/sirupsen/logrus"
+)
+
+// State is the state of the DHCP client defined in RFC 2131 section 4.4, the SELECTING and REQUESTING states are
+// covered by INIT as the lease is requested in one go
+type State string
+
+const (
+	StateInit      State = "Init"
+	StateBound     State = "Bound"
+	StateRenewing  State = "Renewing"
+	StateRebinding State = "Rebinding"
+)
+
+// Handler applies the lease to the interface and is notified of the state transitions
+type Handler interface {
+	// Bound is called when a lease is obtained, renewed or rebound
+	Bound(lease *Lease) error
+	// Expired is called when the lease expires or is declined by the server, the address must not be used any more
+	Expired(lease *Lease) error
+	// StateChanged is called after the state or the lease is changed
+	StateChanged(state State, lease *Lease)
+}
+
+// Client maintains a lease through the states of RFC 2131
+type Client struct {
+	name      string
+	client    *nclient4.Client
+	handler   Handler
+	modifiers []dhcpv4.Modifier
+	backoff   *backoff
+
+	mu    sync.Mutex
+	state State
+	lease *Lease
+	// declined is the time the last lease is declined
+	declined time.Time
+}
+
+// NewClient returns a client in the INIT state, the name is the interface name used in the logs and the modifiers
+// are applied to all the requests
+func NewClient(name string, client *nclient4.Client, handler Handler, modifiers ...dhcpv4.Modifier) *Client {
+	return &Client{
+		name:      name,
+		client:    client,
+		handler:   handler,
+		modifiers: modifiers,
+		backoff:   newBackoff(),
+		state:     StateInit,
+	}
+}
+
+// Status returns the current state and lease, the lease is nil in the INIT state
+func (c *Client) Status() (State, *Lease) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state, c.lease
+}
+
+// Acquire obtains a lease by the DISCOVER, OFFER, REQUEST and ACK exchange and binds it
+func (c *Client) Acquire(ctx context.Context) error {
+	l, err := c.client.Request(ctx, c.modifiers...)
+	if err != nil {
+		return err
+	}
+
+	lease := NewLease(l)
+	if err := c.bind(lease); err != nil {
+		return err
+	}
+	c.setState(StateBound, lease)
+
+	return nil
+}
+
+// Reboot verifies the lease of the previous run by the INIT-REBOOT exchange of RFC 2131 section 3.2 rather than
+// obtaining a new lease, so that the address is kept across the restarts. The lease is kept until it expires if no
+// server answers, a new lease is requested only if the lease is expired or declined by the server.
+func (c *Client) Reboot(ctx context.Context, lease *Lease) error {
+	if !time.Now().Before(lease.Expiry) {
+		return c.Acquire(ctx)
+	}
+
+	attemptCtx, cancel := context.WithDeadline(ctx, lease.Expiry)
+	defer cancel()
+
+	ack, err := c.sendRebootRequest(attemptCtx, lease)
+	switch {
+	case err != nil:
+		if ctx.Err() != nil {
+			return err
+		}
+		logrus.Warnf("verify dhcp lease %s on %s failed, keep it until %s, error: %s", lease.IP(), c.name,
+			lease.Expiry.Format(time.RFC3339), err.Error())
+	case isNak(ack):
+		logrus.Warnf("dhcp lease %s on %s is declined by server %s, request a new lease", lease.IP(), c.name, ack.ServerIdentifier())
+		if err := c.handler.Expired(lease); err != nil {
+			return err
+		}
+		return c.Acquire(ctx)
+	default:
+		lease = NewLease(&nclient4.Lease{Offer: lease.Offer, ACK: ack, CreationTime: time.Now()})
+	}
+
+	if err := c.bind(lease); err != nil {
+		if isConflict(err) {
+			c.drop(lease)
+		}
+		return err
+	}
+	c.setState(StateBound, lease)
+
+	return nil
+}
+
+// Run maintains the lease until the context is cancelled. The lease is renewed with the leasing server from T1 and
+// rebound with any server from T2, the failed attempts are retried with backoff. The lease is dropped once it
+// expires or the server declines it, then a new lease is requested.
+func (c *Client) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		state, lease := c.Status()
+
+		var wait time.Duration
+		switch state {
+		case StateInit:
+			// RFC 2131 section 3.1 requires to wait at least 10 seconds after declining an address
+			if wait = time.Until(c.declinedTime().Add(declineWait)); wait > 0 {
+				break
+			}
+			if err := c.Acquire(ctx); err != nil {
+				wait = c.backoff.next()
+				logrus.Warnf("request dhcp lease on %s failed, retry in %s, error: %s", c.name, wait, err.Error())
+			} else {
+				c.backoff.reset()
+			}
+		case StateBound:
+			if wait = time.Until(lease.T1); wait <= 0 {
+				c.setState(StateRenewing, lease)
+			}
+		case StateRenewing:
+			if time.Now().Before(lease.T2) {
+				wait = c.extend(ctx, lease, lease.T2, true)
+			} else {
+				c.setState(StateRebinding, lease)
+			}
+		case StateRebinding:
+			if time.Now().Before(lease.Expiry) {
+				wait = c.extend(ctx, lease, lease.Expiry, false)
+			} else {
+				logrus.Warnf("dhcp lease %s on %s expired", lease.IP(), c.name)
+				c.drop(lease)
+			}
+		}
+
+		if wait <= 0 {
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+}
+
+// extend renews the lease with the leasing server or rebinds it with any server before the deadline, and returns
+// the time to wait before the next attempt if it fails
+func (c *Client) extend(ctx context.Context, lease *Lease, deadline time.Time, renewing bool) time.Duration {
+	attemptCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	ack, err := c.sendRequest(attemptCtx, lease, renewing)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			logrus.Warnf("extend dhcp lease %s on %s failed, error: %s", lease.IP(), c.name, err.Error())
+			return 0
+		}
+		wait := min(c.backoff.next(), remaining)
+		logrus.Warnf("extend dhcp lease %s on %s failed, retry in %s, error: %s", lease.IP(), c.name, wait, err.Error())
+		return wait
+	}
+	c.backoff.reset()
+
+	if isNak(ack) {
+		logrus.Warnf("dhcp lease %s on %s is declined by server %s", lease.IP(), c.name, ack.ServerIdentifier())
+		c.drop(lease)
+		return 0
+	}
+
+	newLease := NewLease(&nclient4.Lease{Offer: lease.Offer, ACK: ack, CreationTime: time.Now()})
+	if err := c.bind(newLease); err != nil {
+		logrus.Errorf("apply dhcp lease %s on %s failed, error: %s", newLease.IP(), c.name, err.Error())
+		// the lease of another address is declined
+		if isConflict(err) {
+			c.drop(lease)
+			return 0
+		}
+	}
+	c.setState(StateBound, newLease)
+
+	return 0
+}
+
+// sendRequest sends the REQUEST with the leased address in ciaddr. The request is broadcast by the raw socket
+// in both states, only the leasing server is expected to answer it in the RENEWING state.
+func (c *Client) sendRequest(ctx context.Context, lease *Lease, renewing bool) (*dhcpv4.DHCPv4, error) {
+	xid, err := dhcpv4.GenerateTransactionID()
+	if err != nil {
+		return nil, err
+	}
+
+	modifiers := append([]dhcpv4.Modifier{
+		dhcpv4.WithTransactionID(xid),
+		dhcpv4.WithOption(dhcpv4.OptMaxMessageSize(nclient4.MaxMessageSize)),
+	}, c.modifiers...)
+	request, err := dhcpv4.NewRenewFromAck(lease.ACK, modifiers...)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed, error: %w", err)
+	}
+
+	match := nclient4.IsMessageType(dhcpv4.MessageTypeAck, dhcpv4.MessageTypeNak)
+	if renewing {
+		match = nclient4.IsAll(nclient4.IsCorrectServer(lease.Server()), match)
+	}
+
+	return c.client.SendAndRead(ctx, nclient4.DefaultServers, request, match)
+}
+
+// sendRebootRequest broadcasts the REQUEST with the previous address in the requested IP option, the ciaddr and
+// the server identifier are left empty in the INIT-REBOOT state
+func (c *Client) sendRebootRequest(ctx context.Context, lease *Lease) (*dhcpv4.DHCPv4, error) {
+	modifiers := append([]dhcpv4.Modifier{
+		dhcpv4.WithHwAddr(c.client.InterfaceAddr()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeRequest),
+		dhcpv4.WithBroadcast(true),
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(lease.IP())),
+		dhcpv4.WithOption(dhcpv4.OptMaxMessageSize(nclient4.MaxMessageSize)),
+		dhcpv4.WithRequestedOptions(
+			dhcpv4.OptionSubnetMask,
+			dhcpv4.OptionRouter,
+			dhcpv4.OptionDomainName,
+			dhcpv4.OptionDomainNameServer,
+		),
+	}, c.modifiers...)
+	request, err := dhcpv4.New(modifiers...)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed, error: %w", err)
+	}
+
+	return c.client.SendAndRead(ctx, nclient4.DefaultServers, request,
+		nclient4.IsMessageType(dhcpv4.MessageTypeAck, dhcpv4.MessageTypeNak))
+}
+
+// bind applies the lease, and declines it if the address is in use by another host
+func (c *Client) bind(lease *Lease) error {
+	err := c.handler.Bound(lease)
+	if !isConflict(err) {
+		return err
+	}
+
+	logrus.Warnf("decline dhcp lease %s on %s, error: %s", lease.IP(), c.name, err.Error())
+	c.mu.Lock()
+	c.declined = time.Now()
+	c.mu.Unlock()
+	if declineErr := c.decline(lease); declineErr != nil {
+		logrus.Warnf("decline dhcp lease %s on %s failed, error: %s", lease.IP(), c.name, declineErr.Error())
+	}
+
+	return err
+}
+
+// decline tells the server the address is in use by another host
+func (c *Client) decline(lease *Lease) error {
+	modifiers := append([]dhcpv4.Modifier{
+		dhcpv4.WithHwAddr(c.client.InterfaceAddr()),
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeDecline),
+		dhcpv4.WithOption(dhcpv4.OptRequestedIPAddress(lease.IP())),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(lease.Server())),
+	}, c.modifiers...)
+	decline, err := dhcpv4.New(modifiers...)
+	if err != nil {
+		return err
+	}
+	// the parameter request list doesn't belong to the DECLINE
+	decline.Options.Del(dhcpv4.OptionParameterRequestList)
+
+	// the DECLINE isn't answered, it's sent once with the cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.client.SendAndRead(ctx, nclient4.DefaultServers, decline, nil); err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Client) declinedTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.declined
+}
+
+// drop stops using the lease and returns to the INIT state
+func (c *Client) drop(lease *Lease) {
+	if err := c.handler.Expired(lease); err != nil {
+		logrus.Errorf("remove dhcp lease %s on %s failed, error: %s", lease.IP(), c.name, err.Error())
+	}
+	c.setState(StateInit, nil)
+}
+
+func (c *Client) setState(state State, lease *Lease) {
+	c.mu.Lock()
+	changed := c.state != state || c.lease != lease
+	c.state, c.lease = state, lease
+	c.mu.Unlock()
+
+	if changed {
+		c.handler.StateChanged(state, lease)
+	}
+}
@@ -0,0 +1,380 @@
+package dhcp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/insomniacslk/dhcp/dhcpv4/server4"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+const (
+	testClientIfName = "dhcp-test0"
+	testServerIfName = "dhcp-test1"
+
+	testLeaseTime = 3 * time.Second
+	testT1        = time.Second
+	testT2        = 2 * time.Second
+)
+
+var (
+	testServerIP = net.IPv4(10, 99, 0, 1).To4()
+	testClientIP = net.IPv4(10, 99, 0, 10).To4()
+	testMask     = net.CIDRMask(24, 32)
+)
+
+// the replies of the test server to the REQUEST
+const (
+	replyAck = iota
+	replyNak
+	replyNone
+	// replyNakReboot declines the INIT-REBOOT requests and acknowledges the others
+	replyNakReboot
+)
+
+// testServer leases the same address to any client and always broadcasts the replies
+type testServer struct {
+	mu       sync.Mutex
+	reply    int
+	requests []*dhcpv4.DHCPv4
+	declines []*dhcpv4.DHCPv4
+}
+
+func (s *testServer) setReply(reply int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+func (s *testServer) handle(conn net.PacketConn, _ net.Addr, m *dhcpv4.DHCPv4) {
+	modifiers := []dhcpv4.Modifier{
+		dhcpv4.WithServerIP(testServerIP),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(testServerIP)),
+	}
+
+	switch m.MessageType() {
+	case dhcpv4.MessageTypeDiscover:
+		modifiers = append(modifiers, dhcpv4.WithMessageType(dhcpv4.MessageTypeOffer), dhcpv4.WithYourIP(testClientIP))
+	case dhcpv4.MessageTypeRequest:
+		s.mu.Lock()
+		reply := s.reply
+		s.requests = append(s.requests, m)
+		s.mu.Unlock()
+
+		// the INIT-REBOOT request carries neither the server identifier nor ciaddr
+		reboot := m.ServerIdentifier() == nil && m.ClientIPAddr.IsUnspecified()
+		switch {
+		case reply == replyNone:
+			return
+		case reply == replyNak, reply == replyNakReboot && reboot:
+			modifiers = append(modifiers, dhcpv4.WithMessageType(dhcpv4.MessageTypeNak))
+		default:
+			modifiers = append(modifiers,
+				dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
+				dhcpv4.WithYourIP(testClientIP),
+				dhcpv4.WithOption(dhcpv4.OptSubnetMask(testMask)),
+				dhcpv4.WithOption(dhcpv4.OptIPAddressLeaseTime(testLeaseTime)),
+				dhcpv4.WithOption(dhcpv4.OptRenewTimeValue(testT1)),
+				dhcpv4.WithOption(dhcpv4.OptRebindingTimeValue(testT2)),
+			)
+		}
+	case dhcpv4.MessageTypeDecline:
+		s.mu.Lock()
+		s.declines = append(s.declines, m)
+		s.mu.Unlock()
+		return
+	default:
+		return
+	}
+
+	resp, err := dhcpv4.NewReplyFromRequest(m, modifiers...)
+	if err != nil {
+		return
+	}
+	_, _ = conn.WriteTo(resp.ToBytes(), &net.UDPAddr{IP: net.IPv4bcast, Port: dhcpv4.ClientPort})
+}
+
+// renewals returns the REQUESTs carrying the leased address in ciaddr
+func (s *testServer) renewals() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, m := range s.requests {
+		if m.ClientIPAddr.Equal(testClientIP) {
+			n++
+		}
+	}
+	return n
+}
+
+type testHandler struct {
+	mu      sync.Mutex
+	bound   int
+	expired int
+	states  []State
+	// conflict fails the binding as if the address is in use by another host
+	conflict bool
+}
+
+func (h *testHandler) Bound(lease *Lease) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conflict {
+		return &iface.AddressConflictError{IP: lease.IP(), MAC: net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01}}
+	}
+	h.bound++
+	return nil
+}
+
+func (h *testHandler) Expired(*Lease) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.expired++
+	return nil
+}
+
+func (h *testHandler) StateChanged(state State, _ *Lease) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.states = append(h.states, state)
+}
+
+func (h *testHandler) counts() (int, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.bound, h.expired
+}
+
+func (h *testHandler) getStates() []State {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]State{}, h.states...)
+}
+
+// setupTestClient starts the test server on one end of a veth pair and returns a client on the other end
+func setupTestClient(t *testing.T) (*Client, *testServer, *testHandler) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testClientIfName},
+		PeerName:  testServerIfName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(veth) })
+
+	for _, name := range []string{testClientIfName, testServerIfName} {
+		link, err := netlink.LinkByName(name)
+		assert.NoError(t, err)
+		assert.NoError(t, netlink.LinkSetUp(link))
+	}
+	serverLink, err := netlink.LinkByName(testServerIfName)
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(serverLink, &netlink.Addr{IPNet: &net.IPNet{IP: testServerIP, Mask: testMask}}))
+
+	ts := &testServer{}
+	server, err := server4.NewServer(testServerIfName, nil, ts.handle)
+	if err != nil {
+		t.Skipf("start dhcp server failed, error: %s", err.Error())
+	}
+	go func() { _ = server.Serve() }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	nc, err := nclient4.New(testClientIfName, nclient4.WithTimeout(200*time.Millisecond), nclient4.WithRetry(2))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = nc.Close() })
+
+	handler := &testHandler{}
+	client := NewClient(testClientIfName, nc, handler)
+	client.backoff = &backoff{min: 100 * time.Millisecond, max: 200 * time.Millisecond}
+
+	return client, ts, handler
+}
+
+func runTestClient(t *testing.T, client *Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestClientRenew(t *testing.T) {
+	client, server, handler := setupTestClient(t)
+
+	assert.NoError(t, client.Acquire(context.Background()))
+	state, lease := client.Status()
+	assert.Equal(t, StateBound, state)
+	assert.True(t, testClientIP.Equal(lease.IP()))
+	assert.True(t, testServerIP.Equal(lease.Server()))
+	assert.Equal(t, testT1, lease.T1.Sub(lease.Start))
+	assert.Equal(t, testT2, lease.T2.Sub(lease.Start))
+	assert.Equal(t, testLeaseTime, lease.Expiry.Sub(lease.Start))
+
+	runTestClient(t, client)
+
+	// the lease is renewed at T1 and keeps bound
+	assert.Eventually(t, func() bool {
+		bound, _ := handler.counts()
+		return bound >= 2
+	}, 2*testT1, 50*time.Millisecond)
+	state, renewed := client.Status()
+	assert.Equal(t, StateBound, state)
+	assert.True(t, renewed.Start.After(lease.Start))
+	assert.GreaterOrEqual(t, server.renewals(), 1)
+	assert.Contains(t, handler.getStates(), StateRenewing)
+	_, expired := handler.counts()
+	assert.Equal(t, 0, expired)
+}
+
+func TestClientRebindAndExpire(t *testing.T) {
+	client, server, handler := setupTestClient(t)
+
+	assert.NoError(t, client.Acquire(context.Background()))
+	server.setReply(replyNone)
+	runTestClient(t, client)
+
+	// the lease is dropped once it expires without any reply
+	assert.Eventually(t, func() bool {
+		_, expired := handler.counts()
+		return expired == 1
+	}, 2*testLeaseTime, 50*time.Millisecond)
+	assert.Equal(t, []State{StateBound, StateRenewing, StateRebinding, StateInit}, handler.getStates()[:4])
+
+	// a new lease is requested after that
+	server.setReply(replyAck)
+	assert.Eventually(t, func() bool {
+		state, _ := client.Status()
+		return state == StateBound
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func TestClientNak(t *testing.T) {
+	client, server, handler := setupTestClient(t)
+
+	assert.NoError(t, client.Acquire(context.Background()))
+	server.setReply(replyNak)
+	runTestClient(t, client)
+
+	// the lease is dropped as soon as the renewal is declined
+	assert.Eventually(t, func() bool {
+		_, expired := handler.counts()
+		return expired == 1
+	}, testT2, 50*time.Millisecond)
+	assert.Equal(t, []State{StateBound, StateRenewing, StateInit}, handler.getStates()[:3])
+}
+
+func TestClientReboot(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   int
+		expired bool
+		// renewed tells whether the lease is verified by the server, otherwise the stored lease is kept
+		renewed bool
+	}{
+		{
+			name:    "lease is acknowledged",
+			reply:   replyAck,
+			renewed: true,
+		},
+		{
+			name:    "lease is declined and a new lease is requested",
+			reply:   replyNakReboot,
+			expired: true,
+			renewed: true,
+		},
+		{
+			name:  "lease is kept if no server answers",
+			reply: replyNone,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server, _ := setupTestClient(t)
+
+			assert.NoError(t, client.Acquire(context.Background()))
+			_, stored := client.Status()
+
+			// the client of the restarted agent
+			rebootHandler := &testHandler{}
+			rebooted := NewClient(testClientIfName, client.client, rebootHandler)
+			server.setReply(tc.reply)
+
+			assert.NoError(t, rebooted.Reboot(context.Background(), stored))
+			state, lease := rebooted.Status()
+			assert.Equal(t, StateBound, state)
+			assert.True(t, testClientIP.Equal(lease.IP()))
+			assert.Equal(t, tc.renewed, lease != stored)
+			bound, expired := rebootHandler.counts()
+			assert.Equal(t, 1, bound)
+			assert.Equal(t, tc.expired, expired == 1)
+		})
+	}
+}
+
+func TestClientRebootExpiredLease(t *testing.T) {
+	client, server, _ := setupTestClient(t)
+
+	assert.NoError(t, client.Acquire(context.Background()))
+	_, stored := client.Status()
+	stored = NewLease(&nclient4.Lease{Offer: stored.Offer, ACK: stored.ACK, CreationTime: time.Now().Add(-2 * testLeaseTime)})
+
+	rebootHandler := &testHandler{}
+	rebooted := NewClient(testClientIfName, client.client, rebootHandler)
+	assert.NoError(t, rebooted.Reboot(context.Background(), stored))
+
+	// a new lease is obtained by the full DISCOVER without verifying the expired one
+	_, lease := rebooted.Status()
+	assert.True(t, lease.Start.After(stored.Expiry))
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, m := range server.requests {
+		assert.NotNil(t, m.ServerIdentifier())
+	}
+}
+
+func TestClientDecline(t *testing.T) {
+	client, server, handler := setupTestClient(t)
+	handler.conflict = true
+
+	// the address in use is declined rather than bound
+	err := client.Acquire(context.Background())
+	assert.True(t, isConflict(err))
+	state, _ := client.Status()
+	assert.Equal(t, StateInit, state)
+
+	assert.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.declines) == 1
+	}, time.Second, 50*time.Millisecond)
+	server.mu.Lock()
+	requests := len(server.requests)
+	server.mu.Unlock()
+
+	// no lease is requested again within the wait after the decline
+	runTestClient(t, client)
+	time.Sleep(500 * time.Millisecond)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, requests, len(server.requests))
+	decline := server.declines[0]
+	assert.True(t, testClientIP.Equal(decline.RequestedIPAddress()))
+	assert.True(t, testServerIP.Equal(decline.ServerIdentifier()))
+	assert.True(t, decline.ClientIPAddr.IsUnspecified())
+}
@@ -0,0 +1,88 @@
+package dhcp
+
+import (
+	"net"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/iana"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+const (
+	ClientIDNodeName = "nodeName"
+	ClientIDMAC      = "mac"
+)
+
+// Identity identifies the client in the requests besides the hardware address, so that the DHCP servers are able
+// to reserve the addresses and register the hostnames
+type Identity struct {
+	ClientID         []byte
+	Hostname         string
+	VendorClass      string
+	RequestedOptions []uint8
+}
+
+// NewIdentity returns the identity of the node in the requests sent from the interface with the hardware address,
+// it returns nil if no option is specified
+func NewIdentity(options *networkv1.DHCPOptions, nodeName string, hwAddr net.HardwareAddr) *Identity {
+	if options == nil {
+		return nil
+	}
+
+	identity := &Identity{VendorClass: options.VendorClass}
+	switch options.ClientID {
+	case ClientIDNodeName:
+		identity.ClientID = ClientIDFromName(nodeName)
+	case ClientIDMAC:
+		identity.ClientID = ClientIDFromHWAddr(hwAddr)
+	}
+	if options.SendHostname {
+		identity.Hostname = nodeName
+	}
+	for _, code := range options.RequestedOptions {
+		if code > 0 && code < 255 {
+			identity.RequestedOptions = append(identity.RequestedOptions, uint8(code))
+		}
+	}
+
+	return identity
+}
+
+// ClientIDFromName returns the client identifier of type 0, which is a name rather than a hardware address as
+// RFC 2132 section 9.14 specifies
+func ClientIDFromName(name string) []byte {
+	return append([]byte{0}, name...)
+}
+
+// ClientIDFromHWAddr returns the client identifier of the ethernet hardware type
+func ClientIDFromHWAddr(hwAddr net.HardwareAddr) []byte {
+	return append([]byte{byte(iana.HWTypeEthernet)}, hwAddr...)
+}
+
+// Modifiers returns the modifiers to add the identity to the requests
+func (i *Identity) Modifiers() []dhcpv4.Modifier {
+	if i == nil {
+		return nil
+	}
+
+	var modifiers []dhcpv4.Modifier
+	if len(i.ClientID) > 0 {
+		modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptClientIdentifier(i.ClientID)))
+	}
+	if i.Hostname != "" {
+		modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptHostName(i.Hostname)))
+	}
+	if i.VendorClass != "" {
+		modifiers = append(modifiers, dhcpv4.WithOption(dhcpv4.OptClassIdentifier(i.VendorClass)))
+	}
+	if len(i.RequestedOptions) > 0 {
+		codes := make([]dhcpv4.OptionCode, 0, len(i.RequestedOptions))
+		for _, code := range i.RequestedOptions {
+			codes = append(codes, dhcpv4.GenericOptionCode(code))
+		}
+		modifiers = append(modifiers, dhcpv4.WithRequestedOptions(codes...))
+	}
+
+	return modifiers
+}
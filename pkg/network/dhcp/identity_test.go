@@ -0,0 +1,66 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestIdentity(t *testing.T) {
+	hwAddr := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+
+	tests := []struct {
+		name             string
+		options          *networkv1.DHCPOptions
+		clientID         []byte
+		hostname         string
+		vendorClass      string
+		requestedOptions []dhcpv4.OptionCode
+	}{
+		{
+			name: "no options",
+		},
+		{
+			name: "client identifier from node name and hostname",
+			options: &networkv1.DHCPOptions{
+				ClientID:     ClientIDNodeName,
+				SendHostname: true,
+			},
+			clientID: append([]byte{0}, "node1"...),
+			hostname: "node1",
+		},
+		{
+			name: "client identifier from mac, vendor class and requested options",
+			options: &networkv1.DHCPOptions{
+				ClientID:         ClientIDMAC,
+				VendorClass:      "harvester",
+				RequestedOptions: []int{42, 119},
+			},
+			clientID:         []byte{1, 0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+			vendorClass:      "harvester",
+			requestedOptions: []dhcpv4.OptionCode{dhcpv4.OptionNTPServers, dhcpv4.OptionDNSDomainSearchList},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			identity := NewIdentity(tc.options, "node1", hwAddr)
+			assert.Equal(t, tc.options == nil, identity == nil)
+
+			msg, err := dhcpv4.NewDiscovery(hwAddr, identity.Modifiers()...)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.clientID, msg.Options.Get(dhcpv4.OptionClientIdentifier))
+			assert.Equal(t, tc.hostname, msg.HostName())
+			assert.Equal(t, tc.vendorClass, msg.ClassIdentifier())
+			for _, code := range tc.requestedOptions {
+				assert.True(t, msg.IsOptionRequested(code), code.String())
+			}
+			// the default options are still requested
+			assert.True(t, msg.IsOptionRequested(dhcpv4.OptionRouter))
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Package dhcp implements the DHCPv4 client state machine of RFC 2131 on top of nclient4. The client obtains a lease
+// in the INIT state, renews it with the leasing server at T1, rebinds it with any server at T2 and drops it when it
+// expires or is declined by the server. A lease of the previous run is verified in the INIT-REBOOT state, the leases
+// are persisted by the Store for that.
+package dhcp
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+const (
+	// the lease time is mandatory in the ACK, the default only guards against the non-compliant servers
+	defaultLeaseTime = time.Hour
+
+	defaultBackoffMin = 4 * time.Second
+	defaultBackoffMax = 64 * time.Second
+
+	declineWait = 10 * time.Second
+)
+
+// Lease is a DHCPv4 lease with its timers, which are counted from the time the lease is obtained
+type Lease struct {
+	*nclient4.Lease
+
+	Start time.Time
+	// T1 is the time to renew the lease with the leasing server
+	T1 time.Time
+	// T2 is the time to rebind the lease with any server
+	T2     time.Time
+	Expiry time.Time
+}
+
+// NewLease computes the timers of the lease, T1 and T2 default to 50% and 87.5% of the lease time as RFC 2131
+// section 4.4.5 specifies
+func NewLease(lease *nclient4.Lease) *Lease {
+	ack := lease.ACK
+
+	leaseTime := ack.IPAddressLeaseTime(defaultLeaseTime)
+	if leaseTime <= 0 {
+		leaseTime = defaultLeaseTime
+	}
+	t1 := ack.IPAddressRenewalTime(leaseTime / 2)
+	t2 := ack.IPAddressRebindingTime(leaseTime * 7 / 8)
+	if t2 <= 0 || t2 > leaseTime {
+		t2 = leaseTime * 7 / 8
+	}
+	if t1 <= 0 || t1 > t2 {
+		t1 = leaseTime / 2
+	}
+
+	start := lease.CreationTime
+	return &Lease{
+		Lease:  lease,
+		Start:  start,
+		T1:     start.Add(t1),
+		T2:     start.Add(t2),
+		Expiry: start.Add(leaseTime),
+	}
+}
+
+// IP returns the leased address
+func (l *Lease) IP() net.IP {
+	return l.ACK.YourIPAddr
+}
+
+// Server returns the identifier of the leasing server
+func (l *Lease) Server() net.IP {
+	if server := l.ACK.ServerIdentifier(); server != nil {
+		return server
+	}
+	return l.ACK.ServerIPAddr
+}
+
+// backoff is the jittered exponential backoff between the failed attempts, the delay doubles from min up to max and
+// is randomized between the half and the whole of it to spread the retries of the nodes
+type backoff struct {
+	min, max time.Duration
+	attempt  int
+}
+
+func newBackoff() *backoff {
+	return &backoff{min: defaultBackoffMin, max: defaultBackoffMax}
+}
+
+func (b *backoff) next() time.Duration {
+	d := b.max
+	if b.attempt < 16 && b.min<<b.attempt < b.max {
+		d = b.min << b.attempt
+	}
+	b.attempt++
+
+	return d/2 + rand.N(d/2+1)
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// isConflict tells whether the address of the lease is in use by another host
+func isConflict(err error) bool {
+	var conflict *iface.AddressConflictError
+	return errors.As(err, &conflict)
+}
+
+// isNak tells whether the server declines the request
+func isNak(msg *dhcpv4.DHCPv4) bool {
+	return msg.MessageType() == dhcpv4.MessageTypeNak
+}
@@ -0,0 +1,77 @@
+package dhcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLease(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []dhcpv4.Option
+		t1, t2, lt time.Duration
+	}{
+		{
+			name: "timers from the options",
+			options: []dhcpv4.Option{
+				dhcpv4.OptIPAddressLeaseTime(time.Hour),
+				dhcpv4.OptRenewTimeValue(20 * time.Minute),
+				dhcpv4.OptRebindingTimeValue(40 * time.Minute),
+			},
+			t1: 20 * time.Minute, t2: 40 * time.Minute, lt: time.Hour,
+		},
+		{
+			name:    "default timers",
+			options: []dhcpv4.Option{dhcpv4.OptIPAddressLeaseTime(time.Hour)},
+			t1:      30 * time.Minute, t2: 52*time.Minute + 30*time.Second, lt: time.Hour,
+		},
+		{
+			name: "invalid timers",
+			options: []dhcpv4.Option{
+				dhcpv4.OptIPAddressLeaseTime(time.Hour),
+				dhcpv4.OptRenewTimeValue(50 * time.Minute),
+				dhcpv4.OptRebindingTimeValue(2 * time.Hour),
+			},
+			t1: 50 * time.Minute, t2: 52*time.Minute + 30*time.Second, lt: time.Hour,
+		},
+		{
+			name:    "no lease time",
+			options: nil,
+			t1:      defaultLeaseTime / 2, t2: defaultLeaseTime * 7 / 8, lt: defaultLeaseTime,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			modifiers := []dhcpv4.Modifier{dhcpv4.WithMessageType(dhcpv4.MessageTypeAck)}
+			for _, option := range tc.options {
+				modifiers = append(modifiers, dhcpv4.WithOption(option))
+			}
+			ack, err := dhcpv4.New(modifiers...)
+			assert.NoError(t, err)
+
+			start := time.Now()
+			lease := NewLease(&nclient4.Lease{ACK: ack, CreationTime: start})
+			assert.Equal(t, start.Add(tc.t1), lease.T1)
+			assert.Equal(t, start.Add(tc.t2), lease.T2)
+			assert.Equal(t, start.Add(tc.lt), lease.Expiry)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{min: time.Second, max: 4 * time.Second}
+
+	for _, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		d := b.next()
+		assert.GreaterOrEqual(t, d, max/2)
+		assert.LessOrEqual(t, d, max)
+	}
+
+	b.reset()
+	assert.LessOrEqual(t, b.next(), time.Second)
+}
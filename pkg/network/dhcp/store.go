@@ -0,0 +1,111 @@
+package dhcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+)
+
+const leaseFileSuffix = ".lease"
+
+// Store persists the leases in a node-local directory, one file per interface, so that the leases survive the
+// restarts of the agent. A store with an empty directory persists nothing.
+type Store struct {
+	dir string
+}
+
+// storedLease is the file format of the lease, the messages are kept in the wire format
+type storedLease struct {
+	Offer        []byte    `json:"offer,omitempty"`
+	ACK          []byte    `json:"ack"`
+	CreationTime time.Time `json:"creationTime"`
+}
+
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+leaseFileSuffix)
+}
+
+// Load returns the lease of the interface, or nil if there is no lease stored
+func (s *Store) Load(name string) (*Lease, error) {
+	if s.dir == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored storedLease
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("decode lease of %s failed, error: %w", name, err)
+	}
+
+	ack, err := dhcpv4.FromBytes(stored.ACK)
+	if err != nil {
+		return nil, fmt.Errorf("decode lease of %s failed, error: %w", name, err)
+	}
+	lease := &nclient4.Lease{ACK: ack, CreationTime: stored.CreationTime}
+	if len(stored.Offer) > 0 {
+		if lease.Offer, err = dhcpv4.FromBytes(stored.Offer); err != nil {
+			return nil, fmt.Errorf("decode lease of %s failed, error: %w", name, err)
+		}
+	}
+
+	return NewLease(lease), nil
+}
+
+// Save writes the lease of the interface, the file is replaced atomically so that a crash never leaves a partial
+// lease behind
+func (s *Store) Save(name string, lease *Lease) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	stored := storedLease{ACK: lease.ACK.ToBytes(), CreationTime: lease.CreationTime}
+	if lease.Offer != nil {
+		stored.Offer = lease.Offer.ToBytes()
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp := s.path(name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write lease of %s failed, error: %w", name, err)
+	}
+	if err := os.Rename(tmp, s.path(name)); err != nil {
+		return fmt.Errorf("write lease of %s failed, error: %w", name, err)
+	}
+
+	return nil
+}
+
+// Delete removes the lease of the interface if it exists
+func (s *Store) Delete(name string) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	if err := os.Remove(s.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package dhcp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/insomniacslk/dhcp/dhcpv4"
+	"github.com/insomniacslk/dhcp/dhcpv4/nclient4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	ack, err := dhcpv4.New(
+		dhcpv4.WithMessageType(dhcpv4.MessageTypeAck),
+		dhcpv4.WithYourIP(net.IPv4(10, 99, 0, 10)),
+		dhcpv4.WithOption(dhcpv4.OptServerIdentifier(net.IPv4(10, 99, 0, 1))),
+		dhcpv4.WithOption(dhcpv4.OptIPAddressLeaseTime(time.Hour)),
+	)
+	assert.NoError(t, err)
+	lease := NewLease(&nclient4.Lease{ACK: ack, CreationTime: time.Now()})
+
+	store := NewStore(t.TempDir())
+
+	loaded, err := store.Load("eth0")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	assert.NoError(t, store.Save("eth0", lease))
+	loaded, err = store.Load("eth0")
+	assert.NoError(t, err)
+	if assert.NotNil(t, loaded) {
+		assert.True(t, lease.IP().Equal(loaded.IP()))
+		assert.True(t, lease.Server().Equal(loaded.Server()))
+		assert.Nil(t, loaded.Offer)
+		assert.True(t, lease.Start.Equal(loaded.Start))
+		assert.True(t, lease.Expiry.Equal(loaded.Expiry))
+	}
+
+	assert.NoError(t, store.Delete("eth0"))
+	assert.NoError(t, store.Delete("eth0"))
+	loaded, err = store.Load("eth0")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	// nothing is persisted without the directory
+	store = NewStore("")
+	assert.NoError(t, store.Save("eth0", lease))
+	loaded, err = store.Load("eth0")
+	assert.NoError(t, err)
+	assert.Nil(t, loaded)
+}
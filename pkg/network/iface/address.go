@@ -0,0 +1,69 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// AddVirtualIP adds the virtual IP to the interface and returns true if it isn't on the interface before.
+// The virtual IP is added without the prefix route which is added by the primary address of the same subnet,
+// and the flag tells it apart from the addresses assigned by the host network.
+func AddVirtualIP(ifName, cidr string) (bool, error) {
+	link, addr, err := getLinkAndAddr(ifName, cidr)
+	if err != nil {
+		return false, err
+	}
+
+	addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+	if err != nil {
+		return false, fmt.Errorf("list addresses of %s failed, error: %w", ifName, err)
+	}
+	for _, a := range addrs {
+		if a.IP.Equal(addr.IP) {
+			return false, nil
+		}
+	}
+
+	addr.Flags = unix.IFA_F_NOPREFIXROUTE
+	if err := netlink.AddrAdd(link, addr); err != nil {
+		return false, fmt.Errorf("add virtual ip %s to %s failed, error: %w", cidr, ifName, err)
+	}
+
+	return true, nil
+}
+
+// DelVirtualIP deletes the virtual IP from the interface, it's ignored if the interface or the address doesn't exist
+func DelVirtualIP(ifName, cidr string) error {
+	link, addr, err := getLinkAndAddr(ifName, cidr)
+	if err != nil {
+		if errors.As(err, &netlink.LinkNotFoundError{}) {
+			return nil
+		}
+		return err
+	}
+
+	if err := netlink.AddrDel(link, addr); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+		return fmt.Errorf("delete virtual ip %s from %s failed, error: %w", cidr, ifName, err)
+	}
+
+	return nil
+}
+
+func isVirtualIP(addr *netlink.Addr) bool {
+	return addr.Flags&unix.IFA_F_NOPREFIXROUTE != 0
+}
+
+func getLinkAndAddr(ifName, cidr string) (netlink.Link, *netlink.Addr, error) {
+	addr, err := netlink.ParseAddr(cidr)
+	if err != nil {
+		return nil, nil, err
+	}
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return link, addr, nil
+}
@@ -0,0 +1,61 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	testVethName = "vip-test0"
+	testPeerName = "vip-test1"
+)
+
+func TestVirtualIP(t *testing.T) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testVethName},
+		PeerName:  testPeerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	defer func() { _ = netlink.LinkDel(veth) }()
+	assert.NoError(t, netlink.LinkSetUp(veth))
+
+	primary, err := netlink.ParseAddr("172.16.100.2/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(veth, primary))
+
+	added, err := AddVirtualIP(testVethName, "172.16.100.10/24")
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	assert.NoError(t, SendGratuitousARP(testVethName, net.ParseIP("172.16.100.10")))
+
+	// adding it again is a no-op
+	added, err = AddVirtualIP(testVethName, "172.16.100.10/24")
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	addrs, err := netlink.AddrList(veth, netlink.FAMILY_V4)
+	assert.NoError(t, err)
+	virtualIPs := 0
+	for i := range addrs {
+		if isVirtualIP(&addrs[i]) {
+			virtualIPs++
+			assert.Equal(t, "172.16.100.10", addrs[i].IP.String())
+		}
+	}
+	assert.Equal(t, 1, virtualIPs)
+
+	assert.NoError(t, DelVirtualIP(testVethName, "172.16.100.10/24"))
+	// deleting the absent address or the address of the absent link is a no-op
+	assert.NoError(t, DelVirtualIP(testVethName, "172.16.100.10/24"))
+	assert.NoError(t, DelVirtualIP("vip-absent0", "172.16.100.10/24"))
+
+	addrs, err = netlink.AddrList(veth, netlink.FAMILY_V4)
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 1)
+}
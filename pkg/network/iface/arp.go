@@ -0,0 +1,187 @@
+package iface
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	arpHardwareEthernet = 1
+	arpOpRequest        = 1
+	arpOpReply          = 2
+	minEthernetFrameLen = 60
+
+	ethernetHeaderLen = 14
+	// the length of the ARP packet of the ethernet and IPv4 addresses
+	arpPacketLen = 28
+)
+
+var broadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// SendGratuitousARP broadcasts an ARP request whose sender and target are both the IP on the interface,
+// so that the neighbors and the switches update the MAC address of the IP
+func SendGratuitousARP(ifName string, ip net.IP) error {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return fmt.Errorf("get interface %s failed, error: %w", ifName, err)
+	}
+
+	frame, err := gratuitousARPFrame(ifi.HardwareAddr, ip)
+	if err != nil {
+		return err
+	}
+
+	conn, err := packet.Listen(ifi, packet.Raw, unix.ETH_P_ARP, nil)
+	if err != nil {
+		return fmt.Errorf("listen on %s failed, error: %w", ifName, err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteTo(frame, &packet.Addr{HardwareAddr: broadcastMAC}); err != nil {
+		return fmt.Errorf("send gratuitous ARP of %s on %s failed, error: %w", ip, ifName, err)
+	}
+
+	return nil
+}
+
+// PingARP sends the ARP probes of the IPv4 address on the interface at the interval and returns the number of the
+// probes answered. The probes have the unspecified sender IP, so that the reachability of the address on the link is
+// checked without any address on the interface and the neighbors don't learn anything from the probes.
+func PingARP(ifName string, ip net.IP, count int, interval time.Duration) (int, error) {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return 0, fmt.Errorf("get interface %s failed, error: %w", ifName, err)
+	}
+
+	frame, err := arpProbeFrame(ifi.HardwareAddr, ip)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := packet.Listen(ifi, packet.Raw, unix.ETH_P_ARP, nil)
+	if err != nil {
+		return 0, fmt.Errorf("listen on %s failed, error: %w", ifName, err)
+	}
+	defer conn.Close()
+
+	replies := 0
+	for i := 0; i < count; i++ {
+		if _, err := conn.WriteTo(frame, &packet.Addr{HardwareAddr: broadcastMAC}); err != nil {
+			return 0, fmt.Errorf("send ARP probe of %s on %s failed, error: %w", ip, ifName, err)
+		}
+
+		answered, err := waitARPReply(conn, ifi.HardwareAddr, ip, time.Now().Add(interval))
+		if err != nil {
+			return 0, err
+		}
+		if answered {
+			replies++
+		}
+	}
+
+	return replies, nil
+}
+
+// waitARPReply waits until the deadline for the ARP reply from the address to the hardware address, the duplicated
+// replies of the same probe are ignored
+func waitARPReply(conn *packet.Conn, mac net.HardwareAddr, ip net.IP, deadline time.Time) (bool, error) {
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return false, err
+	}
+
+	answered := false
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return answered, nil
+			}
+			return false, fmt.Errorf("receive ARP packets failed, error: %w", err)
+		}
+
+		arp, ok := parseARPFrame(buf[:n])
+		if ok && arp.op == arpOpReply && arp.senderIP.Equal(ip) && bytes.Equal(buf[:6], mac) {
+			answered = true
+		}
+	}
+}
+
+func gratuitousARPFrame(mac net.HardwareAddr, ip net.IP) ([]byte, error) {
+	return arpRequestFrame(mac, ip, ip)
+}
+
+// arpProbeFrame builds the ARP probe of RFC 5227 which asks for the IP with the unspecified sender IP, so that the
+// neighbors don't learn the IP before it's assigned
+func arpProbeFrame(mac net.HardwareAddr, ip net.IP) ([]byte, error) {
+	return arpRequestFrame(mac, net.IPv4zero, ip)
+}
+
+func arpRequestFrame(mac net.HardwareAddr, senderIP, targetIP net.IP) ([]byte, error) {
+	sender, target := senderIP.To4(), targetIP.To4()
+	if sender == nil || target == nil {
+		return nil, fmt.Errorf("%s or %s is not an IPv4 address", senderIP, targetIP)
+	}
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%s is not an ethernet address", mac)
+	}
+
+	frame := make([]byte, 0, minEthernetFrameLen)
+	frame = append(frame, broadcastMAC...)
+	frame = append(frame, mac...)
+	frame = binary.BigEndian.AppendUint16(frame, unix.ETH_P_ARP)
+
+	frame = binary.BigEndian.AppendUint16(frame, arpHardwareEthernet)
+	frame = binary.BigEndian.AppendUint16(frame, unix.ETH_P_IP)
+	frame = append(frame, byte(len(mac)), byte(len(sender)))
+	frame = binary.BigEndian.AppendUint16(frame, arpOpRequest)
+	frame = append(frame, mac...)
+	frame = append(frame, sender...)
+	// the target hardware address is ignored in a request
+	frame = append(frame, make(net.HardwareAddr, len(mac))...)
+	frame = append(frame, target...)
+
+	// pad the short frame with zeros
+	for len(frame) < minEthernetFrameLen {
+		frame = append(frame, 0)
+	}
+
+	return frame, nil
+}
+
+// arpPacket is the ARP packet of the ethernet and IPv4 addresses
+type arpPacket struct {
+	op        uint16
+	senderMAC net.HardwareAddr
+	senderIP  net.IP
+	targetIP  net.IP
+}
+
+// parseARPFrame returns the ARP packet in the ethernet frame, or false if it isn't an ARP packet of the ethernet
+// and IPv4 addresses
+func parseARPFrame(frame []byte) (*arpPacket, bool) {
+	if len(frame) < ethernetHeaderLen+arpPacketLen || binary.BigEndian.Uint16(frame[12:14]) != unix.ETH_P_ARP {
+		return nil, false
+	}
+
+	arp := frame[ethernetHeaderLen:]
+	if binary.BigEndian.Uint16(arp[0:2]) != arpHardwareEthernet || binary.BigEndian.Uint16(arp[2:4]) != unix.ETH_P_IP ||
+		arp[4] != 6 || arp[5] != 4 {
+		return nil, false
+	}
+
+	return &arpPacket{
+		op:        binary.BigEndian.Uint16(arp[6:8]),
+		senderMAC: append(net.HardwareAddr{}, arp[8:14]...),
+		senderIP:  append(net.IP{}, arp[14:18]...),
+		targetIP:  append(net.IP{}, arp[24:28]...),
+	}, true
+}
@@ -0,0 +1,66 @@
+package iface
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func Test_gratuitousARPFrame(t *testing.T) {
+	mac := net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+
+	frame, err := gratuitousARPFrame(mac, net.ParseIP("172.16.100.10"))
+	assert.NoError(t, err)
+	assert.Len(t, frame, minEthernetFrameLen)
+	assert.Equal(t, []byte{
+		// ethernet header
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x52, 0x54, 0x00, 0x12, 0x34, 0x56, 0x08, 0x06,
+		// hardware type, protocol type, lengths and request operation
+		0x00, 0x01, 0x08, 0x00, 0x06, 0x04, 0x00, 0x01,
+		// sender
+		0x52, 0x54, 0x00, 0x12, 0x34, 0x56, 172, 16, 100, 10,
+		// target
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 172, 16, 100, 10,
+	}, frame[:42])
+
+	_, err = gratuitousARPFrame(mac, net.ParseIP("fd00::10"))
+	assert.Error(t, err)
+
+	_, err = gratuitousARPFrame(net.HardwareAddr{0x01}, net.ParseIP("172.16.100.10"))
+	assert.Error(t, err)
+}
+
+func Test_parseARPFrame(t *testing.T) {
+	mac := net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+
+	frame, err := arpProbeFrame(mac, net.ParseIP("172.16.100.10"))
+	assert.NoError(t, err)
+	arp, ok := parseARPFrame(frame)
+	if assert.True(t, ok) {
+		assert.Equal(t, uint16(arpOpRequest), arp.op)
+		assert.Equal(t, mac, arp.senderMAC)
+		assert.True(t, arp.senderIP.IsUnspecified())
+		assert.True(t, arp.targetIP.Equal(net.ParseIP("172.16.100.10")))
+	}
+
+	_, ok = parseARPFrame(frame[:40])
+	assert.False(t, ok)
+}
+
+func TestPingARP(t *testing.T) {
+	peer := setupDADVeth(t)
+	addr, err := netlink.ParseAddr("192.168.21.1/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(peer, addr))
+
+	replies, err := PingARP(testDADIfName, net.ParseIP("192.168.21.1"), 3, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, replies)
+
+	replies, err = PingARP(testDADIfName, net.ParseIP("192.168.21.2"), 2, 100*time.Millisecond)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, replies)
+}
@@ -249,16 +249,163 @@ func (b *Bond) remove() error {
 	return nil
 }
 
-// modifyBond deletes the original bond and creates a new one
+// modifyBond changes the bond attributes in place to keep the bond attached to the bridge,
+// the bond is recreated only when the kernel refuses the in-place change
 func (b *Bond) modifyBond(oldBond *netlink.Bond) error {
 	if compareBond(oldBond, b.Bond) {
 		return nil
 	}
 
+	err := b.modifyBondInPlace(oldBond)
+	if err == nil {
+		logrus.Infof("bond %s is modified in place", b.Name)
+		return nil
+	}
+	logrus.Warnf("modify bond %s in place failed, error: %v, recreate it", b.Name, err)
+
+	return b.recreateBond(oldBond)
+}
+
+func (b *Bond) modifyBondInPlace(oldBond *netlink.Bond) error {
+	changes, needDown, needNoSlaves := diffBondOptions(oldBond, b.Bond)
+	// the released slaves are enslaved again by ensureBondSlaves
+	if needNoSlaves {
+		slaves, err := getSlaves(oldBond.Index)
+		if err != nil {
+			return err
+		}
+		for _, slave := range slaves {
+			if err := netlink.LinkSetNoMaster(slave); err != nil {
+				return fmt.Errorf("release slave %s failed, error: %w", slave.Attrs().Name, err)
+			}
+		}
+	}
+	// the bond is set up again by ensureBond
+	if needDown {
+		if err := netlink.LinkSetDown(oldBond); err != nil {
+			return fmt.Errorf("set bond down failed, error: %w", err)
+		}
+	}
+	if changes != nil {
+		if err := netlink.LinkModify(changes); err != nil {
+			return fmt.Errorf("modify bond options failed, error: %w", err)
+		}
+	}
+
+	if b.MTU != 0 && oldBond.MTU != b.MTU {
+		if err := netlink.LinkSetMTU(oldBond, b.MTU); err != nil {
+			return fmt.Errorf("set MTU %d failed, error: %w", b.MTU, err)
+		}
+	}
+	if b.TxQLen != -1 && oldBond.TxQLen != b.TxQLen {
+		if err := netlink.LinkSetTxQLen(oldBond, b.TxQLen); err != nil {
+			return fmt.Errorf("set txqlen %d failed, error: %w", b.TxQLen, err)
+		}
+	}
+	if b.HardwareAddr.String() != "" && oldBond.HardwareAddr.String() != b.HardwareAddr.String() {
+		if err := netlink.LinkSetHardwareAddr(oldBond, b.HardwareAddr); err != nil {
+			return fmt.Errorf("set hardware address %s failed, error: %w", b.HardwareAddr, err)
+		}
+	}
+
+	return nil
+}
+
+// diffBondOptions returns a bond carrying only the changed bonding options, nil if no option is changed.
+// The mode and fail_over_mac can only be changed when the bond has no slaves,
+// the mode, lacp_rate and ad_select can only be changed when the bond is down.
+func diffBondOptions(old, new *netlink.Bond) (changes *netlink.Bond, needDown, needNoSlaves bool) { //nolint
+	linkAttrs := netlink.NewLinkAttrs()
+	linkAttrs.Name = old.Name
+	linkAttrs.Index = old.Index
+	c := netlink.NewLinkBond(linkAttrs)
+	changed := false
+
+	diffInt := func(oldValue, newValue int, set func(int)) {
+		if newValue = omittedToZero(newValue); oldValue != newValue {
+			set(newValue)
+			changed = true
+		}
+	}
+
+	if old.Mode != new.Mode {
+		c.Mode = new.Mode
+		changed, needDown, needNoSlaves = true, true, true
+	}
+	newMiimon := new.Miimon
+	if newMiimon == -1 {
+		newMiimon = utils.DefaultValueMiimon
+	}
+	if old.Miimon != newMiimon {
+		c.Miimon = newMiimon
+		changed = true
+	}
+	diffInt(old.UpDelay, new.UpDelay, func(v int) { c.UpDelay = v })
+	diffInt(old.DownDelay, new.DownDelay, func(v int) { c.DownDelay = v })
+	diffInt(old.MinLinks, new.MinLinks, func(v int) { c.MinLinks = v })
+	diffInt(old.ArpInterval, new.ArpInterval, func(v int) { c.ArpInterval = v })
+	diffInt(old.Primary, new.Primary, func(v int) { c.Primary = v })
+	diffInt(int(old.PrimaryReselect), int(new.PrimaryReselect), func(v int) { c.PrimaryReselect = netlink.BondPrimaryReselect(v) })
+	diffInt(int(old.XmitHashPolicy), int(new.XmitHashPolicy), func(v int) { c.XmitHashPolicy = netlink.BondXmitHashPolicy(v) })
+	diffInt(int(old.FailOverMac), int(new.FailOverMac), func(v int) {
+		c.FailOverMac = netlink.BondFailOverMac(v)
+		needNoSlaves = true
+	})
+	diffInt(int(old.LacpRate), int(new.LacpRate), func(v int) {
+		c.LacpRate = netlink.BondLacpRate(v)
+		needDown = true
+	})
+	diffInt(int(old.AdSelect), int(new.AdSelect), func(v int) {
+		c.AdSelect = netlink.BondAdSelect(v)
+		needDown = true
+	})
+	// an empty list clears the targets
+	if !equalIPs(old.ArpIpTargets, new.ArpIpTargets) {
+		c.ArpIpTargets = append([]net.IP{}, new.ArpIpTargets...)
+		changed = true
+	}
+
+	if !changed {
+		return nil, false, false
+	}
+	return c, needDown, needNoSlaves
+}
+
+// recreateBond deletes the old bond and adds a new one,
+// the bridge master and the vlan filter entries of the old bond are restored afterwards
+func (b *Bond) recreateBond(oldBond *netlink.Bond) error {
+	vlanMap, err := netlink.BridgeVlanList()
+	if err != nil {
+		return fmt.Errorf("list bridge vlans failed, error: %w", err)
+	}
+	vlans := vlanMap[int32(oldBond.Index)] //nolint:gosec
+	masterIndex := oldBond.MasterIndex
+
 	if err := netlink.LinkDel(oldBond); err != nil {
 		return err
 	}
-	return netlink.LinkAdd(b.Bond)
+	if err := netlink.LinkAdd(b.Bond); err != nil {
+		return err
+	}
+
+	if masterIndex == 0 {
+		return nil
+	}
+	l, err := netlink.LinkByName(b.Name)
+	if err != nil {
+		return fmt.Errorf("fetch bond %s failed, error: %w", b.Name, err)
+	}
+	if err := netlink.LinkSetMasterByIndex(l, masterIndex); err != nil {
+		return fmt.Errorf("restore master %d of bond %s failed, error: %w", masterIndex, b.Name, err)
+	}
+	for _, v := range vlans {
+		if err := netlink.BridgeVlanAdd(l, v.Vid, v.PortVID(), v.EngressUntag(), false, true); err != nil {
+			return fmt.Errorf("restore vlan %d of bond %s failed, error: %w", v.Vid, b.Name, err)
+		}
+	}
+	logrus.Infof("bond %s is recreated with master %d and %d vlans restored", b.Name, masterIndex, len(vlans))
+
+	return nil
 }
 
 func getSlaves(index int) ([]netlink.Link, error) {
@@ -281,6 +428,30 @@ func getSlaves(index int) ([]netlink.Link, error) {
 	return links, nil
 }
 
+// ListBondSlaves lists the links enslaved by any bond
+func ListBondSlaves() ([]netlink.Link, error) {
+	all, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	bondIndexes := make(map[int]bool)
+	for _, l := range all {
+		if l.Type() == TypeBond {
+			bondIndexes[l.Attrs().Index] = true
+		}
+	}
+
+	links := make([]netlink.Link, 0, len(all))
+	for _, l := range all {
+		if bondIndexes[l.Attrs().MasterIndex] {
+			links = append(links, l)
+		}
+	}
+
+	return links, nil
+}
+
 func compareBond(old, new *netlink.Bond) bool { //nolint
 	if old.Name != new.Name {
 		return false
@@ -316,5 +487,45 @@ func compareBond(old, new *netlink.Bond) bool { //nolint
 		return false
 	}
 
+	// the options below are -1 when they are omitted, the bond is created with the kernel default value 0 then
+	if old.UpDelay != omittedToZero(new.UpDelay) || old.DownDelay != omittedToZero(new.DownDelay) ||
+		old.MinLinks != omittedToZero(new.MinLinks) || old.ArpInterval != omittedToZero(new.ArpInterval) ||
+		old.Primary != omittedToZero(new.Primary) {
+		return false
+	}
+
+	if int(old.LacpRate) != omittedToZero(int(new.LacpRate)) ||
+		int(old.XmitHashPolicy) != omittedToZero(int(new.XmitHashPolicy)) ||
+		int(old.AdSelect) != omittedToZero(int(new.AdSelect)) ||
+		int(old.PrimaryReselect) != omittedToZero(int(new.PrimaryReselect)) ||
+		int(old.FailOverMac) != omittedToZero(int(new.FailOverMac)) {
+		return false
+	}
+
+	return equalIPs(old.ArpIpTargets, new.ArpIpTargets)
+}
+
+func omittedToZero(v int) int {
+	if v == -1 {
+		return 0
+	}
+	return v
+}
+
+// equalIPs compares two IP lists regardless of the order
+func equalIPs(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ips := make(map[string]int, len(a))
+	for _, ip := range a {
+		ips[ip.String()]++
+	}
+	for _, ip := range b {
+		if ips[ip.String()] == 0 {
+			return false
+		}
+		ips[ip.String()]--
+	}
 	return true
 }
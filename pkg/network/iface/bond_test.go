@@ -0,0 +1,162 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	testBond = "test-bo"
+)
+
+// newKernelBond simulates a bond read back from the kernel, whose options have the kernel default values
+func newKernelBond() *netlink.Bond {
+	return &netlink.Bond{
+		LinkAttrs: netlink.LinkAttrs{Name: testBond, Index: 10, MTU: 1500, TxQLen: 1000},
+		Mode:      netlink.BOND_MODE_ACTIVE_BACKUP,
+		Miimon:    utils.DefaultValueMiimon,
+	}
+}
+
+// newDesiredBond simulates a bond built from the vlanconfig, whose omitted options are -1
+func newDesiredBond() *netlink.Bond {
+	linkAttrs := netlink.NewLinkAttrs()
+	linkAttrs.Name = testBond
+	bond := netlink.NewLinkBond(linkAttrs)
+	bond.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+	bond.Miimon = utils.DefaultValueMiimon
+	return bond
+}
+
+func Test_diffBondOptions(t *testing.T) {
+	tests := []struct {
+		name         string
+		old          func(b *netlink.Bond)
+		new          func(b *netlink.Bond)
+		changed      bool
+		needDown     bool
+		needNoSlaves bool
+		check        func(t *testing.T, c *netlink.Bond)
+	}{
+		{
+			name:    "no option is changed",
+			changed: false,
+		},
+		{
+			name: "link attributes are not bonding options",
+			new: func(b *netlink.Bond) {
+				b.MTU = 9000
+				b.TxQLen = 2000
+			},
+			changed: false,
+		},
+		{
+			name:    "miimon is changed in place",
+			new:     func(b *netlink.Bond) { b.Miimon = 200 },
+			changed: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.Equal(t, 200, c.Miimon)
+				assert.Equal(t, netlink.BondMode(-1), c.Mode)
+				assert.Equal(t, testBond, c.Name)
+				assert.Equal(t, 10, c.Index)
+			},
+		},
+		{
+			name:         "mode change requires the bond down without slaves",
+			new:          func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_802_3AD },
+			changed:      true,
+			needDown:     true,
+			needNoSlaves: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.Equal(t, netlink.BOND_MODE_802_3AD, c.Mode)
+			},
+		},
+		{
+			name: "lacp rate change requires the bond down",
+			old:  func(b *netlink.Bond) { b.Mode = netlink.BOND_MODE_802_3AD },
+			new: func(b *netlink.Bond) {
+				b.Mode = netlink.BOND_MODE_802_3AD
+				b.LacpRate = netlink.BOND_LACP_RATE_FAST
+			},
+			changed:  true,
+			needDown: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.Equal(t, netlink.BOND_LACP_RATE_FAST, c.LacpRate)
+			},
+		},
+		{
+			name:         "fail_over_mac change requires the bond without slaves",
+			new:          func(b *netlink.Bond) { b.FailOverMac = netlink.BOND_FAIL_OVER_MAC_ACTIVE },
+			changed:      true,
+			needNoSlaves: true,
+		},
+		{
+			name:    "omitted option is reset to the kernel default",
+			old:     func(b *netlink.Bond) { b.UpDelay = 200 },
+			changed: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.Equal(t, 0, c.UpDelay)
+			},
+		},
+		{
+			name: "arp monitoring replaces mii monitoring",
+			new: func(b *netlink.Bond) {
+				b.Miimon = 0
+				b.ArpInterval = 1000
+				b.ArpIpTargets = []net.IP{net.ParseIP("192.168.0.1")}
+			},
+			changed: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.Equal(t, 0, c.Miimon)
+				assert.Equal(t, 1000, c.ArpInterval)
+				assert.Len(t, c.ArpIpTargets, 1)
+			},
+		},
+		{
+			name:    "removed arp targets are cleared",
+			old:     func(b *netlink.Bond) { b.ArpIpTargets = []net.IP{net.ParseIP("192.168.0.1")} },
+			changed: true,
+			check: func(t *testing.T, c *netlink.Bond) {
+				assert.NotNil(t, c.ArpIpTargets)
+				assert.Empty(t, c.ArpIpTargets)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			old, desired := newKernelBond(), newDesiredBond()
+			if tc.old != nil {
+				tc.old(old)
+			}
+			if tc.new != nil {
+				tc.new(desired)
+			}
+			c, needDown, needNoSlaves := diffBondOptions(old, desired)
+			assert.Equal(t, tc.changed, c != nil)
+			assert.Equal(t, tc.needDown, needDown)
+			assert.Equal(t, tc.needNoSlaves, needNoSlaves)
+			if tc.check != nil {
+				tc.check(t, c)
+			}
+		})
+	}
+}
+
+func Test_compareBond(t *testing.T) {
+	assert.True(t, compareBond(newKernelBond(), newDesiredBond()))
+
+	desired := newDesiredBond()
+	desired.ArpIpTargets = []net.IP{net.ParseIP("192.168.0.2"), net.ParseIP("192.168.0.1")}
+	old := newKernelBond()
+	old.ArpIpTargets = []net.IP{net.ParseIP("192.168.0.1").To4(), net.ParseIP("192.168.0.2").To4()}
+	assert.True(t, compareBond(old, desired))
+
+	desired.XmitHashPolicy = netlink.BOND_XMIT_HASH_POLICY_LAYER3_4
+	assert.False(t, compareBond(old, desired))
+}
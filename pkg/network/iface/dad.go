@@ -0,0 +1,169 @@
+package iface
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// the timers of the address conflict detection in RFC 5227 section 1.1, they're variables to be shortened in tests
+var (
+	arpProbeWait        = time.Second
+	arpProbeNum         = 3
+	arpProbeMin         = time.Second
+	arpProbeMax         = 2 * time.Second
+	arpAnnounceWait     = 2 * time.Second
+	arpAnnounceNum      = 2
+	arpAnnounceInterval = 2 * time.Second
+
+	// the kernel sends one neighbor solicitation and waits one second by default
+	ipv6DADTimeout = 5 * time.Second
+)
+
+// AddressConflictError is returned if the address is in use by another host on the link
+type AddressConflictError struct {
+	IP net.IP
+	// MAC is the hardware address of the host using the address, it's nil if unknown
+	MAC net.HardwareAddr
+}
+
+func (e *AddressConflictError) Error() string {
+	if e.MAC == nil {
+		return fmt.Sprintf("address %s is in use by another host", e.IP)
+	}
+	return fmt.Sprintf("address %s is in use by %s", e.IP, e.MAC)
+}
+
+// ProbeIPv4 detects whether the IPv4 address is in use on the link by the ARP probes of RFC 5227 before it's
+// assigned to the interface, and returns an AddressConflictError if it is
+func ProbeIPv4(ifName string, ip net.IP) error {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return fmt.Errorf("get interface %s failed, error: %w", ifName, err)
+	}
+
+	frame, err := arpProbeFrame(ifi.HardwareAddr, ip)
+	if err != nil {
+		return err
+	}
+
+	conn, err := packet.Listen(ifi, packet.Raw, unix.ETH_P_ARP, nil)
+	if err != nil {
+		return fmt.Errorf("listen on %s failed, error: %w", ifName, err)
+	}
+	defer conn.Close()
+
+	// the random delay avoids probing at the same time as the other nodes starting together
+	if err := waitARPConflict(conn, ifi.HardwareAddr, ip, randDuration(0, arpProbeWait)); err != nil {
+		return err
+	}
+
+	for i := 0; i < arpProbeNum; i++ {
+		if _, err := conn.WriteTo(frame, &packet.Addr{HardwareAddr: broadcastMAC}); err != nil {
+			return fmt.Errorf("send ARP probe of %s on %s failed, error: %w", ip, ifName, err)
+		}
+
+		wait := randDuration(arpProbeMin, arpProbeMax)
+		if i == arpProbeNum-1 {
+			wait = arpAnnounceWait
+		}
+		if err := waitARPConflict(conn, ifi.HardwareAddr, ip, wait); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitARPConflict waits for the ARP packets claiming the address, either the sender of the packet is the address
+// or another host is probing the address as well
+func waitARPConflict(conn *packet.Conn, mac net.HardwareAddr, ip net.IP, wait time.Duration) error {
+	if err := conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return fmt.Errorf("receive ARP packets failed, error: %w", err)
+		}
+
+		arp, ok := parseARPFrame(buf[:n])
+		if !ok || bytes.Equal(arp.senderMAC, mac) {
+			continue
+		}
+		if arp.senderIP.Equal(ip) || (arp.op == arpOpRequest && arp.senderIP.IsUnspecified() && arp.targetIP.Equal(ip)) {
+			return &AddressConflictError{IP: ip, MAC: arp.senderMAC}
+		}
+	}
+}
+
+// AnnounceIPv4 broadcasts the gratuitous ARP announcements after the address is assigned, so that the neighbors
+// update the stale entries of the address
+func AnnounceIPv4(ifName string, ip net.IP) error {
+	for i := 0; i < arpAnnounceNum; i++ {
+		if i > 0 {
+			time.Sleep(arpAnnounceInterval)
+		}
+		if err := SendGratuitousARP(ifName, ip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitIPv6DAD waits until the kernel completes the duplicate address detection of the IPv6 address, the address is
+// deleted if the detection fails
+func waitIPv6DAD(link netlink.Link, ip net.IP) error {
+	deadline := time.Now().Add(ipv6DADTimeout)
+	for {
+		addresses, err := netlink.AddrList(link, netlink.FAMILY_V6)
+		if err != nil {
+			return fmt.Errorf("list addresses of %s failed, error: %w", link.Attrs().Name, err)
+		}
+
+		var addr *netlink.Addr
+		for i := range addresses {
+			if addresses[i].IP.Equal(ip) {
+				addr = &addresses[i]
+				break
+			}
+		}
+
+		switch {
+		case addr == nil:
+			return fmt.Errorf("address %s is removed from %s", ip, link.Attrs().Name)
+		case addr.Flags&unix.IFA_F_DADFAILED != 0:
+			if err := netlink.AddrDel(link, addr); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+				return fmt.Errorf("delete duplicate address %s failed, error: %w", ip, err)
+			}
+			return &AddressConflictError{IP: ip}
+		case addr.Flags&unix.IFA_F_TENTATIVE == 0:
+			return nil
+		case time.Now().After(deadline):
+			return fmt.Errorf("duplicate address detection of %s on %s timed out", ip, link.Attrs().Name)
+		}
+
+		time.Sleep(linkLocalCheckInterval)
+	}
+}
+
+func randDuration(lower, upper time.Duration) time.Duration {
+	if upper <= lower {
+		return lower
+	}
+	return lower + rand.N(upper-lower)
+}
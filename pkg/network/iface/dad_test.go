@@ -0,0 +1,93 @@
+package iface
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	testDADIfName = "dad-test0"
+	testDADPeer   = "dad-test1"
+)
+
+// shortenDADTimers speeds up the address conflict detection in the tests
+func shortenDADTimers(t *testing.T) {
+	probeWait, probeMin, probeMax, announceWait := arpProbeWait, arpProbeMin, arpProbeMax, arpAnnounceWait
+	arpProbeWait, arpProbeMin, arpProbeMax, arpAnnounceWait = 0, 50*time.Millisecond, 100*time.Millisecond, 200*time.Millisecond
+	t.Cleanup(func() {
+		arpProbeWait, arpProbeMin, arpProbeMax, arpAnnounceWait = probeWait, probeMin, probeMax, announceWait
+	})
+}
+
+func setupDADVeth(t *testing.T) netlink.Link {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testDADIfName},
+		PeerName:  testDADPeer,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(veth) })
+	for _, ifName := range []string{testDADIfName, testDADPeer} {
+		assert.NoError(t, setLinkUp(ifName))
+	}
+
+	peer, err := netlink.LinkByName(testDADPeer)
+	assert.NoError(t, err)
+	return peer
+}
+
+func TestProbeIPv4(t *testing.T) {
+	shortenDADTimers(t)
+	peer := setupDADVeth(t)
+	addr, err := netlink.ParseAddr("192.168.20.2/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(peer, addr))
+
+	// the peer answers the probe of its address
+	err = ProbeIPv4(testDADIfName, net.ParseIP("192.168.20.2"))
+	var conflict *AddressConflictError
+	if assert.True(t, errors.As(err, &conflict)) {
+		assert.Equal(t, peer.Attrs().HardwareAddr.String(), conflict.MAC.String())
+		assert.Contains(t, err.Error(), peer.Attrs().HardwareAddr.String())
+	}
+
+	assert.NoError(t, ProbeIPv4(testDADIfName, net.ParseIP("192.168.20.3")))
+}
+
+func TestWaitIPv6DAD(t *testing.T) {
+	peer := setupDADVeth(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitLinkLocalAddress(ctx, testDADIfName); err != nil {
+		t.Skipf("ipv6 link-local address isn't available, error: %s", err.Error())
+	}
+	assert.NoError(t, WaitLinkLocalAddress(ctx, testDADPeer))
+
+	addr, err := netlink.ParseAddr("fd00:20::2/64")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(peer, addr))
+	assert.NoError(t, waitIPv6DAD(peer, addr.IP))
+
+	// the duplicate address is refused and deleted
+	link, err := netlink.LinkByName(testDADIfName)
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(link, addr))
+	err = waitIPv6DAD(link, addr.IP)
+	var conflict *AddressConflictError
+	assert.True(t, errors.As(err, &conflict))
+	assigned, err := hasAddress(link, addr.IP)
+	assert.NoError(t, err)
+	assert.False(t, assigned)
+
+	other, err := netlink.ParseAddr("fd00:20::3/64")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(link, other))
+	assert.NoError(t, waitIPv6DAD(link, other.IP))
+}
@@ -0,0 +1,103 @@
+package iface
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ipv4ConfDir = "/proc/sys/net/ipv4/conf"
+	ipv6ConfDir = "/proc/sys/net/ipv6/conf"
+
+	// accept router advertisements even if forwarding is enabled on the node
+	acceptRAIgnoreForwarding = "2"
+
+	linkLocalCheckInterval = 200 * time.Millisecond
+)
+
+// SetIPv6AutoConf configures how the interface handles the router advertisements. The routes and the on-link prefixes
+// are learnt from the router advertisements if acceptRA is true, and the addresses are generated from the advertised
+// prefixes (SLAAC) if autoconf is true as well.
+func SetIPv6AutoConf(ifName string, acceptRA, autoconf bool) error {
+	acceptRAValue, autoconfValue := "0", "0"
+	if acceptRA {
+		acceptRAValue = acceptRAIgnoreForwarding
+		if autoconf {
+			autoconfValue = "1"
+		}
+	}
+
+	if err := ensureConfValue(ipv6ConfDir, ifName, "accept_ra", acceptRAValue); err != nil {
+		return err
+	}
+
+	return ensureConfValue(ipv6ConfDir, ifName, "autoconf", autoconfValue)
+}
+
+// ensureConfValue writes the per-interface sysctl file directly because the interface name may contain dots
+// which can't be used in the dotted sysctl name, e.g. net.ipv6.conf.cn-br.100.autoconf
+func ensureConfValue(confDir, ifName, key, value string) error {
+	path := filepath.Join(confDir, ifName, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read %s failed, error: %w", path, err)
+	}
+	if strings.TrimSpace(string(data)) == value {
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(value), 0644); err != nil {
+		return fmt.Errorf("set %s to %s failed, error: %w", path, value, err)
+	}
+
+	return nil
+}
+
+// WaitLinkLocalAddress waits until the IPv6 link-local address of the interface passes the duplicate address
+// detection, the sockets can't be bound to the address before that
+func WaitLinkLocalAddress(ctx context.Context, ifName string) error {
+	ticker := time.NewTicker(linkLocalCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		ready, err := hasLinkLocalAddress(ifName)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("wait for the ipv6 link-local address of %s failed, error: %w", ifName, ctx.Err())
+		}
+	}
+}
+
+func hasLinkLocalAddress(ifName string) (bool, error) {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return false, err
+	}
+
+	addresses, err := netlink.AddrList(link, netlink.FAMILY_V6)
+	if err != nil {
+		return false, fmt.Errorf("list addresses of %s failed, error: %w", ifName, err)
+	}
+	for _, address := range addresses {
+		if address.IP.IsLinkLocalUnicast() && address.Flags&unix.IFA_F_TENTATIVE == 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
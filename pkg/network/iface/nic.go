@@ -0,0 +1,82 @@
+package iface
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const sysClassNet = "/sys/class/net"
+
+// GetPCIAddress returns the PCI bus address of the NIC, empty if the NIC is not a PCI device
+func GetPCIAddress(name string) string {
+	device, err := filepath.EvalSymlinks(filepath.Join(sysClassNet, name, "device"))
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(device, "vendor")); err != nil {
+		return ""
+	}
+	return filepath.Base(device)
+}
+
+// GetDriver returns the kernel driver name of the NIC, empty if the NIC has no device driver
+func GetDriver(name string) string {
+	driver, err := filepath.EvalSymlinks(filepath.Join(sysClassNet, name, "device", "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(driver)
+}
+
+// GetPermanentMAC returns the factory MAC address of the link, the MAC address of a NIC is rewritten to the one of
+// the bond once it's enslaved. The current MAC address is returned if the link has no permanent one.
+func GetPermanentMAC(l netlink.Link) net.HardwareAddr {
+	if mac := l.Attrs().PermHWAddr; len(mac) > 0 {
+		return mac
+	}
+	// the kernels before 5.5 report the permanent MAC address of the bond slaves only
+	if slave, ok := l.Attrs().Slave.(*netlink.BondSlave); ok && len(slave.PermHardwareAddr) > 0 {
+		return slave.PermHardwareAddr
+	}
+	return l.Attrs().HardwareAddr
+}
+
+// ListNICs lists the physical NICs which are free or enslaved by the given bond
+func ListNICs(bondName string) ([]utils.NICInfo, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("list links failed, error: %w", err)
+	}
+
+	bondIndex := 0
+	for _, l := range links {
+		if l.Attrs().Name == bondName {
+			bondIndex = l.Attrs().Index
+			break
+		}
+	}
+
+	nics := make([]utils.NICInfo, 0, len(links))
+	for _, l := range links {
+		if l.Type() != TypeDevice || l.Attrs().Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if l.Attrs().MasterIndex != 0 && l.Attrs().MasterIndex != bondIndex {
+			continue
+		}
+		nics = append(nics, utils.NICInfo{
+			Name:       l.Attrs().Name,
+			MAC:        GetPermanentMAC(l).String(),
+			PCIAddress: GetPCIAddress(l.Attrs().Name),
+			Driver:     GetDriver(l.Attrs().Name),
+		})
+	}
+
+	return nics, nil
+}
@@ -0,0 +1,194 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	// the routing table of a host network interface is numbered after the interface index, the tables from 253 to
+	// 255 are reserved by the kernel
+	policyRouteTableBase = 10000
+	// the rules are looked up before the rule of the main table whose priority is 32766
+	policyRulePriority = 1000
+)
+
+// PolicyRouteTable returns the routing table dedicated to the host network interface
+func PolicyRouteTable(link netlink.Link) int {
+	return policyRouteTableBase + link.Attrs().Index
+}
+
+// EnsurePolicyRouting copies the routes of the address family on the host network interface, including the connected
+// subnets and the gateways, into the dedicated routing table of the interface, and adds a rule looking up the table
+// for the traffic from each host address. The replies then leave through the interface where the requests arrive
+// rather than the default route of the node. The extra routes are installed only in the table, e.g. the default
+// route of the host network which must not replace the default route of the node in the main table.
+func EnsurePolicyRouting(ifName string, family int, extraRoutes []HostRoute) error {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return err
+	}
+	table := PolicyRouteTable(link)
+
+	routes, err := netlink.RouteListFiltered(family, &netlink.Route{LinkIndex: link.Attrs().Index},
+		netlink.RT_FILTER_OIF)
+	if err != nil {
+		return fmt.Errorf("list routes of %s failed, error: %w", ifName, err)
+	}
+
+	for _, r := range withDefaultMetric(family, extraRoutes) {
+		routes = append(routes, netlink.Route{Dst: r.Dst, Gw: r.Gw, Priority: r.Metric})
+	}
+
+	wantedRoutes := make(map[string]bool, len(routes))
+	for i := range routes {
+		route := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       routes[i].Dst,
+			Src:       routes[i].Src,
+			Gw:        routes[i].Gw,
+			Scope:     routes[i].Scope,
+			Priority:  routes[i].Priority,
+			Protocol:  routeProtocolHostNetwork,
+			Table:     table,
+		}
+		if route.Dst == nil {
+			route.Dst = defaultDst(family)
+		}
+		wantedRoutes[policyRouteKey(route)] = true
+		if err := netlink.RouteReplace(route); err != nil {
+			return fmt.Errorf("add route %s to table %d failed, error: %w", route, table, err)
+		}
+	}
+
+	existingRoutes, err := listPolicyRoutes(family, table)
+	if err != nil {
+		return err
+	}
+	for i := range existingRoutes {
+		if existingRoutes[i].Dst == nil {
+			existingRoutes[i].Dst = defaultDst(family)
+		}
+		if wantedRoutes[policyRouteKey(&existingRoutes[i])] {
+			continue
+		}
+		if err := netlink.RouteDel(&existingRoutes[i]); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("delete route %s from table %d failed, error: %w", existingRoutes[i], table, err)
+		}
+	}
+
+	addresses, err := GetHostAddresses(ifName, family)
+	if err != nil {
+		return err
+	}
+	srcs := make([]*net.IPNet, 0, len(addresses))
+	for _, address := range addresses {
+		ip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+		srcs = append(srcs, hostIPNet(ip))
+	}
+
+	return ensurePolicyRules(family, table, srcs)
+}
+
+// DelPolicyRouting removes the routing table and the rules of the host network interface in both address families.
+// The rules left by the removed interfaces are harmless as the lookup of the empty table falls through to the next
+// rule.
+func DelPolicyRouting(ifName string) error {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		if errors.As(err, &netlink.LinkNotFoundError{}) {
+			return nil
+		}
+		return err
+	}
+	table := PolicyRouteTable(link)
+
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		routes, err := listPolicyRoutes(family, table)
+		if err != nil {
+			return err
+		}
+		for i := range routes {
+			if err := netlink.RouteDel(&routes[i]); err != nil && !errors.Is(err, syscall.ESRCH) {
+				return fmt.Errorf("delete route %s from table %d failed, error: %w", routes[i], table, err)
+			}
+		}
+
+		if err := ensurePolicyRules(family, table, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listPolicyRoutes(family, table int) ([]netlink.Route, error) {
+	routes, err := netlink.RouteListFiltered(family, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return nil, fmt.Errorf("list routes of table %d failed, error: %w", table, err)
+	}
+	return routes, nil
+}
+
+// ensurePolicyRules adds the rules from the sources to the table and removes the other rules to the table
+func ensurePolicyRules(family, table int, srcs []*net.IPNet) error {
+	existing, err := netlink.RuleListFiltered(family, &netlink.Rule{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("list rules of table %d failed, error: %w", table, err)
+	}
+
+	found := make(map[string]bool, len(existing))
+	for i := range existing {
+		rule := &existing[i]
+		if rule.Src != nil && rule.Priority == policyRulePriority && containsIPNet(srcs, rule.Src) {
+			found[rule.Src.String()] = true
+			continue
+		}
+		if err := netlink.RuleDel(rule); err != nil && !errors.Is(err, syscall.ENOENT) {
+			return fmt.Errorf("delete rule %s failed, error: %w", rule, err)
+		}
+	}
+
+	for _, src := range srcs {
+		if found[src.String()] {
+			continue
+		}
+		rule := netlink.NewRule()
+		rule.Family = family
+		rule.Src = src
+		rule.Table = table
+		rule.Priority = policyRulePriority
+		if err := netlink.RuleAdd(rule); err != nil && !errors.Is(err, syscall.EEXIST) {
+			return fmt.Errorf("add rule from %s to table %d failed, error: %w", src, table, err)
+		}
+	}
+
+	return nil
+}
+
+func policyRouteKey(route *netlink.Route) string {
+	return fmt.Sprintf("%s via %s src %s metric %d", route.Dst, route.Gw, route.Src, route.Priority)
+}
+
+func hostIPNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+func containsIPNet(ipNets []*net.IPNet, ipNet *net.IPNet) bool {
+	for _, n := range ipNets {
+		if n.String() == ipNet.String() {
+			return true
+		}
+	}
+	return false
+}
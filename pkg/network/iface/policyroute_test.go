@@ -0,0 +1,92 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func listPolicyRules(t *testing.T, table int) []string {
+	rules, err := netlink.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: table}, netlink.RT_FILTER_TABLE)
+	assert.NoError(t, err)
+
+	var result []string
+	for _, rule := range rules {
+		result = append(result, rule.Src.String())
+	}
+	return result
+}
+
+func listPolicyRouteDsts(t *testing.T, table int) []string {
+	routes, err := listPolicyRoutes(netlink.FAMILY_V4, table)
+	assert.NoError(t, err)
+
+	var result []string
+	for _, route := range routes {
+		dst := route.Dst
+		if dst == nil {
+			dst = defaultDst(netlink.FAMILY_V4)
+		}
+		result = append(result, dst.String())
+	}
+	return result
+}
+
+func TestEnsurePolicyRouting(t *testing.T) {
+	const ifName, peerName = "policy-test0", "policy-test1"
+
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: ifName},
+		PeerName:  peerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	defer func() { _ = netlink.LinkDel(veth) }()
+	for _, name := range []string{ifName, peerName} {
+		assert.NoError(t, setLinkUp(name))
+	}
+	link, err := netlink.LinkByName(ifName)
+	assert.NoError(t, err)
+	table := PolicyRouteTable(link)
+
+	addr, err := netlink.ParseAddr("172.16.120.2/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(link, addr))
+	gateway := mustParseRoute(t, "0.0.0.0/0", "172.16.120.1", 100)
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{gateway}))
+
+	// the table holds the connected subnet and the gateway
+	assert.NoError(t, EnsurePolicyRouting(ifName, netlink.FAMILY_V4, nil))
+	assert.ElementsMatch(t, []string{"172.16.120.0/24", "0.0.0.0/0"}, listPolicyRouteDsts(t, table))
+	assert.Equal(t, []string{"172.16.120.2/32"}, listPolicyRules(t, table))
+
+	// the rules and the routes follow the address
+	assert.NoError(t, netlink.AddrDel(link, addr))
+	addr, err = netlink.ParseAddr("172.16.121.2/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(link, addr))
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{
+		{Dst: gateway.Dst, Gw: net.ParseIP("172.16.121.1"), Metric: 100},
+	}))
+	assert.NoError(t, EnsurePolicyRouting(ifName, netlink.FAMILY_V4, nil))
+	assert.ElementsMatch(t, []string{"172.16.121.0/24", "0.0.0.0/0"}, listPolicyRouteDsts(t, table))
+	assert.Equal(t, []string{"172.16.121.2/32"}, listPolicyRules(t, table))
+
+	// the extra default route is installed only in the table
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, nil))
+	assert.NoError(t, EnsurePolicyRouting(ifName, netlink.FAMILY_V4, []HostRoute{
+		mustParseRoute(t, "0.0.0.0/0", "172.16.121.1", 0),
+	}))
+	assert.ElementsMatch(t, []string{"172.16.121.0/24", "0.0.0.0/0"}, listPolicyRouteDsts(t, table))
+	assert.Empty(t, listHostRoutes(t, link, netlink.FAMILY_V4))
+
+	assert.NoError(t, DelPolicyRouting(ifName))
+	assert.Empty(t, listPolicyRouteDsts(t, table))
+	assert.Empty(t, listPolicyRules(t, table))
+
+	// removing the policy routing of the absent interface is a no-op
+	assert.NoError(t, DelPolicyRouting("policy-absent0"))
+}
@@ -3,6 +3,7 @@ package iface
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"syscall"
 
@@ -123,3 +124,125 @@ func DeleteRouteViaGateway(cidr string) error {
 
 	return nil
 }
+
+// routeProtocolHostNetwork marks the routes installed for the host networks to tell them apart from the routes added
+// by the kernel, the router advertisements and the other agents
+const routeProtocolHostNetwork netlink.RouteProtocol = 0x4e
+
+// the kernel sets the metric of the IPv6 routes to 1024 if it's not specified
+const defaultIPv6RouteMetric = 1024
+
+// HostRoute is a route on the host network interface, the destination is reachable on the link directly if the
+// gateway is nil
+type HostRoute struct {
+	Dst    *net.IPNet
+	Gw     net.IP
+	Metric int
+}
+
+func (r HostRoute) String() string {
+	return fmt.Sprintf("%s via %s metric %d", r.Dst, r.Gw, r.Metric)
+}
+
+// EnsureHostRoutes installs the routes of the address family on the host network interface and removes the other
+// routes of the family installed before. A route conflicting with an existing route of the node, e.g. the default
+// route of the management network, isn't ours and is skipped rather than replaced.
+func EnsureHostRoutes(ifName string, family int, routes []HostRoute) error {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return err
+	}
+
+	existing, err := netlink.RouteListFiltered(family, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Protocol:  routeProtocolHostNetwork,
+	}, netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL)
+	if err != nil {
+		return fmt.Errorf("list routes of %s failed, error: %w", ifName, err)
+	}
+
+	routes = withDefaultMetric(family, routes)
+	wanted := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		wanted[r.String()] = true
+	}
+
+	// the stale routes are removed first as the changed gateway of the same destination and metric is a conflict
+	installed := make(map[string]bool, len(existing))
+	for i := range existing {
+		route := &existing[i]
+		r := HostRoute{Dst: route.Dst, Gw: route.Gw, Metric: route.Priority}
+		if r.Dst == nil {
+			r.Dst = defaultDst(family)
+		}
+		if wanted[r.String()] {
+			installed[r.String()] = true
+			continue
+		}
+		if err := netlink.RouteDel(route); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("delete route %s from %s failed, error: %w", r, ifName, err)
+		}
+	}
+
+	for _, r := range routes {
+		if installed[r.String()] {
+			continue
+		}
+		route := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       r.Dst,
+			Gw:        r.Gw,
+			Priority:  r.Metric,
+			Protocol:  routeProtocolHostNetwork,
+		}
+		if err := netlink.RouteAdd(route); err != nil {
+			if errors.Is(err, syscall.EEXIST) {
+				logrus.Warnf("route %s conflicts with an existing route of the node, skip it on %s", r, ifName)
+				continue
+			}
+			return fmt.Errorf("add route %s to %s failed, error: %w", r, ifName, err)
+		}
+	}
+
+	return nil
+}
+
+// DelHostRoutes removes the routes of both address families installed on the host network interface
+func DelHostRoutes(ifName string) error {
+	for _, family := range []int{netlink.FAMILY_V4, netlink.FAMILY_V6} {
+		if err := EnsureHostRoutes(ifName, family, nil); err != nil {
+			if errors.As(err, &netlink.LinkNotFoundError{}) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withDefaultMetric returns a copy of the routes with the metric set by the kernel if it's not specified, so that
+// they're comparable with the installed routes
+func withDefaultMetric(family int, routes []HostRoute) []HostRoute {
+	result := make([]HostRoute, 0, len(routes))
+	for _, r := range routes {
+		if r.Metric == 0 && family == netlink.FAMILY_V6 {
+			r.Metric = defaultIPv6RouteMetric
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// IsDefaultRoute tells whether the destination of the route is 0.0.0.0/0 or ::/0
+func IsDefaultRoute(r HostRoute) bool {
+	ones, _ := r.Dst.Mask.Size()
+	return ones == 0
+}
+
+func defaultDst(family int) *net.IPNet {
+	if family == netlink.FAMILY_V6 {
+		return &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(0, 128)}
+	}
+	return &net.IPNet{IP: net.IPv4zero.To4(), Mask: net.CIDRMask(0, 32)}
+}
@@ -0,0 +1,88 @@
+package iface
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func mustParseRoute(t *testing.T, dst, gw string, metric int) HostRoute {
+	_, ipNet, err := net.ParseCIDR(dst)
+	assert.NoError(t, err)
+	return HostRoute{Dst: ipNet, Gw: net.ParseIP(gw), Metric: metric}
+}
+
+func listHostRoutes(t *testing.T, link netlink.Link, family int) []string {
+	routes, err := netlink.RouteListFiltered(family, &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Protocol:  routeProtocolHostNetwork,
+	}, netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL)
+	assert.NoError(t, err)
+
+	var result []string
+	for _, r := range routes {
+		dst := r.Dst
+		if dst == nil {
+			dst = defaultDst(family)
+		}
+		result = append(result, HostRoute{Dst: dst, Gw: r.Gw, Metric: r.Priority}.String())
+	}
+	return result
+}
+
+func TestEnsureHostRoutes(t *testing.T) {
+	const ifName, peerName = "route-test0", "route-test1"
+
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: ifName},
+		PeerName:  peerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	defer func() { _ = netlink.LinkDel(veth) }()
+	for _, name := range []string{ifName, peerName} {
+		assert.NoError(t, setLinkUp(name))
+	}
+	addr, err := netlink.ParseAddr("172.16.110.2/24")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.AddrAdd(veth, addr))
+
+	remote := mustParseRoute(t, "10.110.0.0/16", "172.16.110.1", 0)
+	backup := mustParseRoute(t, "10.111.0.0/16", "172.16.110.1", 100)
+	onLink := mustParseRoute(t, "172.16.111.0/24", "", 0)
+
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{remote, backup, onLink}))
+	assert.ElementsMatch(t, []string{remote.String(), backup.String(), onLink.String()}, listHostRoutes(t, veth, netlink.FAMILY_V4))
+
+	// ensuring the same routes is a no-op, and the removed routes are deleted
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{remote, backup, onLink}))
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{remote}))
+	assert.ElementsMatch(t, []string{remote.String()}, listHostRoutes(t, veth, netlink.FAMILY_V4))
+
+	// the changed gateway of the same destination and metric replaces the route
+	moved := mustParseRoute(t, "10.110.0.0/16", "172.16.110.254", 0)
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{moved}))
+	assert.ElementsMatch(t, []string{moved.String()}, listHostRoutes(t, veth, netlink.FAMILY_V4))
+
+	// the routes not installed for the host network are kept
+	_, other, err := net.ParseCIDR("10.112.0.0/16")
+	assert.NoError(t, err)
+	assert.NoError(t, netlink.RouteAdd(&netlink.Route{LinkIndex: veth.Attrs().Index, Dst: other, Gw: net.ParseIP("172.16.110.1")}))
+
+	// the conflicting route is skipped rather than replacing the route of the node
+	conflict := mustParseRoute(t, "10.112.0.0/16", "172.16.110.254", 0)
+	assert.NoError(t, EnsureHostRoutes(ifName, netlink.FAMILY_V4, []HostRoute{moved, conflict}))
+	assert.ElementsMatch(t, []string{moved.String()}, listHostRoutes(t, veth, netlink.FAMILY_V4))
+
+	assert.NoError(t, DelHostRoutes(ifName))
+	assert.Empty(t, listHostRoutes(t, veth, netlink.FAMILY_V4))
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: other}, netlink.RT_FILTER_DST)
+	assert.NoError(t, err)
+	assert.Len(t, routes, 1)
+
+	// deleting the routes of the absent interface is a no-op
+	assert.NoError(t, DelHostRoutes("route-absent0"))
+}
@@ -0,0 +1,102 @@
+package iface
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// EnsureProbeEndpoint creates a veth pair whose port end is attached to the bridge with the VLANs allowed,
+// the frames sent by the endpoint end with a VLAN tag go through the bridge as if they are from a VM
+func EnsureProbeEndpoint(br *Bridge, name, portName string, vids []uint16) error {
+	port, err := addProbeVeth(br, name, portName)
+	if err != nil {
+		return err
+	}
+	for _, vid := range vids {
+		if err := port.AddBridgeVlan(vid); err != nil {
+			return err
+		}
+	}
+
+	return setProbeVethUp(name, portName)
+}
+
+// EnsureAccessEndpoint creates a veth pair whose port end is attached to the bridge as an access port of the VLAN,
+// the endpoint end sends and receives the untagged frames of the VLAN as a VM on the VLAN network does
+func EnsureAccessEndpoint(br *Bridge, name, portName string, vid uint16) error {
+	port, err := addProbeVeth(br, name, portName)
+	if err != nil {
+		return err
+	}
+	// the port is an access port of the default PVID already
+	if vid != defaultPVID && vid != minVlanID {
+		if err := netlink.BridgeVlanAdd(port, vid, true, true, false, true); err != nil {
+			return fmt.Errorf("add access vlan %d to %s failed, error: %w", vid, portName, err)
+		}
+		if err := netlink.BridgeVlanDel(port, defaultPVID, false, false, false, true); err != nil {
+			return fmt.Errorf("delete default vlan from %s failed, error: %w", portName, err)
+		}
+	}
+
+	return setProbeVethUp(name, portName)
+}
+
+func addProbeVeth(br *Bridge, name, portName string) (*Link, error) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: name},
+		PeerName:  portName,
+	}
+	err := netlink.LinkAdd(veth)
+	if errors.Is(err, syscall.EEXIST) {
+		// the remaining endpoint may be attached to another bridge or has other VLANs, recreate it
+		if err := RemoveProbeEndpoint(name); err != nil {
+			return nil, err
+		}
+		err = netlink.LinkAdd(veth)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("add probe endpoint %s failed, error: %w", name, err)
+	}
+
+	l, err := netlink.LinkByName(portName)
+	if err != nil {
+		return nil, fmt.Errorf("get probe port %s failed, error: %w", portName, err)
+	}
+	port := NewLink(l)
+	if err := port.SetMaster(br); err != nil {
+		return nil, err
+	}
+
+	return port, nil
+}
+
+func setProbeVethUp(name, portName string) error {
+	for _, ifName := range []string{portName, name} {
+		if err := setLinkUp(ifName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveProbeEndpoint deletes the veth pair, the peer is deleted together with the endpoint
+func RemoveProbeEndpoint(name string) error {
+	l, err := netlink.LinkByName(name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("get probe endpoint %s failed, error: %w", name, err)
+	}
+
+	if err := netlink.LinkDel(l); err != nil {
+		return fmt.Errorf("delete probe endpoint %s failed, error: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package iface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+func TestEnsureAccessEndpoint(t *testing.T) {
+	br := NewBridge("veth-test-br")
+	if err := br.Ensure(); err != nil {
+		t.Skipf("create bridge failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(br) })
+
+	assert.NoError(t, EnsureAccessEndpoint(br, "veth-test-gw", "veth-test-gp", 100))
+	// the endpoint is recreated if it exists
+	assert.NoError(t, EnsureAccessEndpoint(br, "veth-test-gw", "veth-test-gp", 200))
+	t.Cleanup(func() { _ = RemoveProbeEndpoint("veth-test-gw") })
+
+	port, err := netlink.LinkByName("veth-test-gp")
+	assert.NoError(t, err)
+	vlans, err := netlink.BridgeVlanList()
+	assert.NoError(t, err)
+	infos := vlans[int32(port.Attrs().Index)]
+	if assert.Len(t, infos, 1) {
+		assert.Equal(t, uint16(200), infos[0].Vid)
+		assert.Equal(t, uint16(nl.BRIDGE_VLAN_INFO_PVID|nl.BRIDGE_VLAN_INFO_UNTAGGED), infos[0].Flags)
+	}
+
+	assert.NoError(t, RemoveProbeEndpoint("veth-test-gw"))
+	_, err = netlink.LinkByName("veth-test-gp")
+	assert.Error(t, err)
+}
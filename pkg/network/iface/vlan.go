@@ -3,8 +3,10 @@ package iface
 import (
 	"errors"
 	"fmt"
+	"net"
 
 	"github.com/harvester/harvester-network-controller/pkg/utils"
+	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
@@ -92,23 +94,135 @@ func (l *Link) SetIPAddress(cidr string, vid uint16) error {
 		return err
 	}
 
+	// the new address is a secondary one if the old address is in the same subnet, promote it rather than deleting
+	// it together with the old primary address. The virtual IPs are secondary addresses as well.
+	if ipAddr.IP.To4() != nil {
+		if err := ensureConfValue(ipv4ConfDir, linkName, "promote_secondaries", "1"); err != nil {
+			return err
+		}
+	}
+
+	assigned, err := hasAddress(vlanLink, ipAddr.IP)
+	if err != nil {
+		return err
+	}
+
+	// the address in use by another host is refused, it's detected only before the address is assigned
+	if !assigned && ipAddr.IP.To4() != nil {
+		if err := ProbeIPv4(linkName, ipAddr.IP); err != nil {
+			return err
+		}
+	}
+	if !assigned && ipAddr.IP.To4() == nil {
+		if err := ensureConfValue(ipv6ConfDir, linkName, "accept_dad", "1"); err != nil {
+			return err
+		}
+	}
+
 	if err := netlink.AddrReplace(vlanLink, ipAddr); err != nil {
 		return fmt.Errorf("set ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, ipAddr)
 	}
 
-	//delete other ip addresses (configured by previous DHCP lease or other static IPs)
-	addresses, err := netlink.AddrList(vlanLink, netlink.FAMILY_V4)
+	if !assigned && ipAddr.IP.To4() != nil {
+		go func() {
+			if err := AnnounceIPv4(linkName, ipAddr.IP); err != nil {
+				logrus.Warnf("announce %s on %s failed, error: %s", ipAddr.IP, linkName, err.Error())
+			}
+		}()
+	}
+	// the kernel detects the duplicate IPv6 address after the address is assigned
+	if !assigned && ipAddr.IP.To4() == nil {
+		if err := waitIPv6DAD(vlanLink, ipAddr.IP); err != nil {
+			return err
+		}
+	}
+
+	//delete other ip addresses of the same family (configured by previous DHCP lease or other static IPs)
+	addresses, err := netlink.AddrList(vlanLink, addrFamily(ipAddr.IP))
 	if err != nil {
 		return err
 	}
 
 	for _, address := range addresses {
-		if !ipAddr.IP.Equal(address.IP) {
-			if err := netlink.AddrDel(vlanLink, &address); err != nil {
-				return fmt.Errorf("delete ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, address)
-			}
+		if ipAddr.IP.Equal(address.IP) || !isHostAddress(&address) {
+			continue
+		}
+		if err := netlink.AddrDel(vlanLink, &address); err != nil {
+			return fmt.Errorf("delete ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, address)
 		}
 	}
 
 	return nil
 }
+
+// DelIPAddress removes the address from the vlan sub-interface, e.g. when the DHCP lease expires
+func (l *Link) DelIPAddress(cidr string, vid uint16) error {
+	linkName := utils.GetClusterNetworkBrVlanDevice(l.Attrs().Name, vid)
+	vlanLink, err := netlink.LinkByName(linkName)
+	if err != nil {
+		return fmt.Errorf("finding vlan subinterface failed, error: %v, link: %s, vid: %d", err, linkName, vid)
+	}
+
+	ipAddr, err := netlink.ParseAddr(cidr)
+	if err != nil {
+		return err
+	}
+
+	if err := netlink.AddrDel(vlanLink, ipAddr); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+		return fmt.Errorf("delete ip address failed, error: %v, link: %s, ipNet: %v", err, l.Attrs().Name, ipAddr)
+	}
+
+	return nil
+}
+
+// GetHostAddresses returns the addresses of the family assigned to the host network interface, the link-local
+// addresses and the virtual IPs are excluded
+func GetHostAddresses(ifName string, family int) ([]string, error) {
+	link, err := netlink.LinkByName(ifName)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := netlink.AddrList(link, family)
+	if err != nil {
+		return nil, fmt.Errorf("list addresses of %s failed, error: %w", ifName, err)
+	}
+
+	var cidrs []string
+	for i := range addresses {
+		if isHostAddress(&addresses[i]) {
+			cidrs = append(cidrs, addresses[i].IPNet.String())
+		}
+	}
+
+	return cidrs, nil
+}
+
+// hasAddress returns true if the IP is assigned to the link
+func hasAddress(link netlink.Link, ip net.IP) (bool, error) {
+	addresses, err := netlink.AddrList(link, addrFamily(ip))
+	if err != nil {
+		return false, fmt.Errorf("list addresses of %s failed, error: %w", link.Attrs().Name, err)
+	}
+
+	for _, address := range addresses {
+		if address.IP.Equal(ip) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isHostAddress excludes the link-local addresses generated by the kernel and the virtual IPs managed by the vip
+// controller
+func isHostAddress(addr *netlink.Addr) bool {
+	return !addr.IP.IsLinkLocalUnicast() && !isVirtualIP(addr)
+}
+
+func addrFamily(ip net.IP) int {
+	if ip.To4() != nil {
+		return netlink.FAMILY_V4
+	}
+	return netlink.FAMILY_V6
+}
@@ -0,0 +1,121 @@
+package iface
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	testBrName     = "hnc-test"
+	testVlanID     = 10
+	testVlanIfName = "hnc-test.10"
+	testVlanPeer   = "hnc-test-p"
+)
+
+func TestSetIPAddressDualStack(t *testing.T) {
+	shortenDADTimers(t)
+	// a veth named as the vlan sub-interface of the bridge stands for the host network interface
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testVlanIfName},
+		PeerName:  testVlanPeer,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	defer func() { _ = netlink.LinkDel(veth) }()
+	// the link-local address stays tentative until the carrier is up
+	for _, ifName := range []string{testVlanIfName, testVlanPeer} {
+		assert.NoError(t, setLinkUp(ifName))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitLinkLocalAddress(ctx, testVlanIfName); err != nil {
+		t.Skipf("ipv6 link-local address isn't available, error: %s", err.Error())
+	}
+
+	br := NewLink(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Name: testBrName}})
+
+	assert.NoError(t, br.SetIPAddress("192.168.10.2/24", testVlanID))
+	assert.NoError(t, br.SetIPAddress("fd00:10::2/64", testVlanID))
+	// the virtual IP is kept when the host address is replaced
+	_, err := AddVirtualIP(testVlanIfName, "192.168.10.10/24")
+	assert.NoError(t, err)
+
+	ipv4Addrs, err := GetHostAddresses(testVlanIfName, netlink.FAMILY_V4)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.10.2/24"}, ipv4Addrs)
+	ipv6Addrs, err := GetHostAddresses(testVlanIfName, netlink.FAMILY_V6)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fd00:10::2/64"}, ipv6Addrs)
+
+	// replacing the address of one family keeps the address of the other family
+	assert.NoError(t, br.SetIPAddress("fd00:10::3/64", testVlanID))
+	ipv6Addrs, err = GetHostAddresses(testVlanIfName, netlink.FAMILY_V6)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fd00:10::3/64"}, ipv6Addrs)
+	ipv4Addrs, err = GetHostAddresses(testVlanIfName, netlink.FAMILY_V4)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.10.2/24"}, ipv4Addrs)
+
+	assert.NoError(t, br.SetIPAddress("192.168.10.3/24", testVlanID))
+	ipv4Addrs, err = GetHostAddresses(testVlanIfName, netlink.FAMILY_V4)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"192.168.10.3/24"}, ipv4Addrs)
+
+	// the link-local address and the virtual IP are neither removed nor reported
+	ok, err := hasLinkLocalAddress(testVlanIfName)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	added, err := AddVirtualIP(testVlanIfName, "192.168.10.10/24")
+	assert.NoError(t, err)
+	assert.False(t, added)
+}
+
+func TestSetIPv6AutoConf(t *testing.T) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testVlanIfName},
+		PeerName:  testVlanPeer,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	defer func() { _ = netlink.LinkDel(veth) }()
+
+	tests := []struct {
+		name         string
+		acceptRA     bool
+		autoconf     bool
+		wantAcceptRA string
+		wantAutoconf string
+	}{
+		{name: "slaac", acceptRA: true, autoconf: true, wantAcceptRA: "2", wantAutoconf: "1"},
+		{name: "router advertisements only", acceptRA: true, wantAcceptRA: "2", wantAutoconf: "0"},
+		{name: "disabled", wantAcceptRA: "0", wantAutoconf: "0"},
+		// autoconf doesn't take effect without accepting the router advertisements
+		{name: "autoconf only", autoconf: true, wantAcceptRA: "0", wantAutoconf: "0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetIPv6AutoConf(testVlanIfName, tc.acceptRA, tc.autoconf); err != nil {
+				t.Skipf("set ipv6 sysctl failed, error: %s", err.Error())
+			}
+			assert.Equal(t, tc.wantAcceptRA, readIPv6Conf(t, testVlanIfName, "accept_ra"))
+			assert.Equal(t, tc.wantAutoconf, readIPv6Conf(t, testVlanIfName, "autoconf"))
+		})
+	}
+}
+
+func readIPv6Conf(t *testing.T, ifName, key string) string {
+	data, err := os.ReadFile(filepath.Join(ipv6ConfDir, ifName, key))
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,201 @@
+package lldp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// EtherType is the ethernet type of the LLDP frames
+	EtherType = 0x88cc
+
+	etherTypeVlan   = 0x8100
+	etherHeaderLen  = 14
+	vlanTagLen      = 4
+	tlvHeaderLen    = 2
+	maxTLVLength    = 0x1ff
+	orgSpecificOUIs = 3
+)
+
+// NearestBridgeMAC is the destination MAC address of the LLDP frames sent by the switches
+var NearestBridgeMAC = net.HardwareAddr{0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e}
+
+// TLV types defined in IEEE 802.1AB
+const (
+	tlvTypeEnd             = 0
+	tlvTypeChassisID       = 1
+	tlvTypePortID          = 2
+	tlvTypeTTL             = 3
+	tlvTypePortDescription = 4
+	tlvTypeSystemName      = 5
+	tlvTypeOrgSpecific     = 127
+)
+
+// chassis ID and port ID subtypes which are not displayed as strings
+const (
+	chassisIDSubtypeMAC     = 4
+	chassisIDSubtypeNetwork = 5
+	portIDSubtypeMAC        = 3
+	portIDSubtypeNetwork    = 4
+)
+
+// IEEE 802.1 organizationally specific TLVs
+var oui8021 = [orgSpecificOUIs]byte{0x00, 0x80, 0xc2}
+
+const (
+	subtype8021PortVlanID = 1
+	subtype8021VlanName   = 3
+)
+
+// Neighbor is the information advertised by the LLDP agent of the link partner
+type Neighbor struct {
+	ChassisID       string
+	PortID          string
+	PortDescription string
+	SystemName      string
+	// PortVlanID is 0 if the port VLAN ID TLV is absent
+	PortVlanID uint16
+	Vlans      []Vlan
+	// TTL 0 means the neighbor is going to shut down and its information should be removed
+	TTL time.Duration
+}
+
+type Vlan struct {
+	ID   uint16
+	Name string
+}
+
+// ParseFrame parses the ethernet frame which carries a LLDPDU
+func ParseFrame(frame []byte) (*Neighbor, error) {
+	if len(frame) < etherHeaderLen {
+		return nil, fmt.Errorf("frame length %d is too short", len(frame))
+	}
+
+	offset := etherHeaderLen
+	etherType := binary.BigEndian.Uint16(frame[offset-2 : offset])
+	if etherType == etherTypeVlan {
+		if len(frame) < etherHeaderLen+vlanTagLen {
+			return nil, fmt.Errorf("frame length %d is too short", len(frame))
+		}
+		offset += vlanTagLen
+		etherType = binary.BigEndian.Uint16(frame[offset-2 : offset])
+	}
+	if etherType != EtherType {
+		return nil, fmt.Errorf("ether type %#04x is not LLDP", etherType)
+	}
+
+	return ParseLLDPDU(frame[offset:])
+}
+
+// ParseLLDPDU parses the LLDPDU, the mandatory chassis ID, port ID and TTL TLVs must be present
+func ParseLLDPDU(b []byte) (*Neighbor, error) {
+	n := &Neighbor{}
+	var hasChassisID, hasPortID, hasTTL bool
+
+	for len(b) > 0 {
+		if len(b) < tlvHeaderLen {
+			return nil, fmt.Errorf("truncated TLV header")
+		}
+		header := binary.BigEndian.Uint16(b[:tlvHeaderLen])
+		tlvType, length := int(header>>9), int(header&maxTLVLength)
+		if len(b) < tlvHeaderLen+length {
+			return nil, fmt.Errorf("TLV type %d with length %d is truncated", tlvType, length)
+		}
+		value := b[tlvHeaderLen : tlvHeaderLen+length]
+		b = b[tlvHeaderLen+length:]
+
+		switch tlvType {
+		case tlvTypeEnd:
+			b = nil
+		case tlvTypeChassisID:
+			id, err := parseID(value, chassisIDSubtypeMAC, chassisIDSubtypeNetwork)
+			if err != nil {
+				return nil, fmt.Errorf("invalid chassis ID TLV, error: %w", err)
+			}
+			n.ChassisID, hasChassisID = id, true
+		case tlvTypePortID:
+			id, err := parseID(value, portIDSubtypeMAC, portIDSubtypeNetwork)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port ID TLV, error: %w", err)
+			}
+			n.PortID, hasPortID = id, true
+		case tlvTypeTTL:
+			if length != 2 {
+				return nil, fmt.Errorf("invalid TTL TLV length %d", length)
+			}
+			n.TTL, hasTTL = time.Duration(binary.BigEndian.Uint16(value))*time.Second, true
+		case tlvTypePortDescription:
+			n.PortDescription = string(value)
+		case tlvTypeSystemName:
+			n.SystemName = string(value)
+		case tlvTypeOrgSpecific:
+			if err := n.parseOrgSpecific(value); err != nil {
+				return nil, err
+			}
+		default:
+			// ignore the TLVs which are not reported
+		}
+	}
+
+	if !hasChassisID || !hasPortID || !hasTTL {
+		return nil, fmt.Errorf("missing mandatory TLVs, chassis ID: %t, port ID: %t, TTL: %t", hasChassisID, hasPortID, hasTTL)
+	}
+
+	return n, nil
+}
+
+func (n *Neighbor) parseOrgSpecific(value []byte) error {
+	if len(value) < orgSpecificOUIs+1 {
+		return fmt.Errorf("invalid organizationally specific TLV length %d", len(value))
+	}
+	if [orgSpecificOUIs]byte(value[:orgSpecificOUIs]) != oui8021 {
+		return nil
+	}
+
+	subtype, info := value[orgSpecificOUIs], value[orgSpecificOUIs+1:]
+	switch subtype {
+	case subtype8021PortVlanID:
+		if len(info) != 2 {
+			return fmt.Errorf("invalid port VLAN ID TLV length %d", len(info))
+		}
+		n.PortVlanID = binary.BigEndian.Uint16(info)
+	case subtype8021VlanName:
+		// VLAN ID(2 bytes), VLAN name length(1 byte), VLAN name
+		if len(info) < 3 || len(info) != 3+int(info[2]) {
+			return fmt.Errorf("invalid VLAN name TLV length %d", len(info))
+		}
+		n.Vlans = append(n.Vlans, Vlan{
+			ID:   binary.BigEndian.Uint16(info[:2]),
+			Name: string(info[3:]),
+		})
+	}
+
+	return nil
+}
+
+// parseID parses the chassis ID or port ID, the MAC and network address subtypes are formatted,
+// the others are regarded as strings
+func parseID(value []byte, macSubtype, networkSubtype byte) (string, error) {
+	if len(value) < 2 {
+		return "", fmt.Errorf("length %d is too short", len(value))
+	}
+
+	subtype, id := value[0], value[1:]
+	switch subtype {
+	case macSubtype:
+		if len(id) != 6 {
+			return "", fmt.Errorf("invalid MAC address length %d", len(id))
+		}
+		return net.HardwareAddr(id).String(), nil
+	case networkSubtype:
+		// the first byte is the IANA address family number
+		if len(id) == 1+net.IPv4len || len(id) == 1+net.IPv6len {
+			return net.IP(id[1:]).String(), nil
+		}
+		return "", fmt.Errorf("invalid network address length %d", len(id))
+	default:
+		return string(id), nil
+	}
+}
@@ -0,0 +1,160 @@
+package lldp
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSrcMAC = []byte{0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+
+func tlv(tlvType int, value []byte) []byte {
+	b := make([]byte, tlvHeaderLen, tlvHeaderLen+len(value))
+	binary.BigEndian.PutUint16(b, uint16(tlvType<<9|len(value))) //nolint:gosec
+	return append(b, value...)
+}
+
+func uint16Bytes(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func portVlanIDTLV(vid uint16) []byte {
+	return tlv(tlvTypeOrgSpecific, append([]byte{0x00, 0x80, 0xc2, subtype8021PortVlanID}, uint16Bytes(vid)...))
+}
+
+func vlanNameTLV(vid uint16, name string) []byte {
+	value := append([]byte{0x00, 0x80, 0xc2, subtype8021VlanName}, uint16Bytes(vid)...)
+	value = append(value, byte(len(name)))
+	return tlv(tlvTypeOrgSpecific, append(value, name...))
+}
+
+func mandatoryTLVs(ttl uint16) [][]byte {
+	return [][]byte{
+		tlv(tlvTypeChassisID, []byte{chassisIDSubtypeMAC, 0x00, 0x1c, 0x73, 0xaa, 0xbb, 0xcc}),
+		tlv(tlvTypePortID, append([]byte{5}, "Ethernet12"...)),
+		tlv(tlvTypeTTL, uint16Bytes(ttl)),
+	}
+}
+
+func buildFrame(tlvs ...[]byte) []byte {
+	frame := append([]byte{}, NearestBridgeMAC...)
+	frame = append(frame, testSrcMAC...)
+	frame = append(frame, uint16Bytes(EtherType)...)
+	for _, t := range tlvs {
+		frame = append(frame, t...)
+	}
+	return append(frame, tlv(tlvTypeEnd, nil)...)
+}
+
+func TestParseFrame(t *testing.T) {
+	tests := []struct {
+		name     string
+		frame    []byte
+		expected *Neighbor
+		errKey   string // empty means no error
+	}{
+		{
+			name:  "mandatory TLVs only",
+			frame: buildFrame(mandatoryTLVs(120)...),
+			expected: &Neighbor{
+				ChassisID: "00:1c:73:aa:bb:cc",
+				PortID:    "Ethernet12",
+				TTL:       120 * time.Second,
+			},
+		},
+		{
+			name: "all reported TLVs",
+			frame: buildFrame(append(mandatoryTLVs(120),
+				tlv(tlvTypePortDescription, []byte("server rack 3")),
+				tlv(tlvTypeSystemName, []byte("tor-switch-1")),
+				// the system capabilities TLV is ignored
+				tlv(7, []byte{0x00, 0x14, 0x00, 0x14}),
+				portVlanIDTLV(100),
+				vlanNameTLV(100, "mgmt"),
+				vlanNameTLV(2012, "vm-network"),
+				// the 802.3 organizationally specific TLVs are ignored
+				tlv(tlvTypeOrgSpecific, []byte{0x00, 0x12, 0x0f, 0x04, 0x05, 0xee}),
+			)...),
+			expected: &Neighbor{
+				ChassisID:       "00:1c:73:aa:bb:cc",
+				PortID:          "Ethernet12",
+				PortDescription: "server rack 3",
+				SystemName:      "tor-switch-1",
+				PortVlanID:      100,
+				Vlans:           []Vlan{{ID: 100, Name: "mgmt"}, {ID: 2012, Name: "vm-network"}},
+				TTL:             120 * time.Second,
+			},
+		},
+		{
+			name: "MAC port ID and network address chassis ID",
+			frame: buildFrame(
+				tlv(tlvTypeChassisID, []byte{chassisIDSubtypeNetwork, 1, 192, 168, 10, 1}),
+				tlv(tlvTypePortID, []byte{portIDSubtypeMAC, 0x00, 0x1c, 0x73, 0xaa, 0xbb, 0xcd}),
+				tlv(tlvTypeTTL, uint16Bytes(0)),
+			),
+			expected: &Neighbor{
+				ChassisID: "192.168.10.1",
+				PortID:    "00:1c:73:aa:bb:cd",
+			},
+		},
+		{
+			name: "VLAN tagged frame",
+			frame: func() []byte {
+				frame := buildFrame(mandatoryTLVs(120)...)
+				tag := append(uint16Bytes(etherTypeVlan), uint16Bytes(100)...)
+				return append(frame[:12], append(tag, frame[12:]...)...)
+			}(),
+			expected: &Neighbor{
+				ChassisID: "00:1c:73:aa:bb:cc",
+				PortID:    "Ethernet12",
+				TTL:       120 * time.Second,
+			},
+		},
+		{
+			name: "not a LLDP frame",
+			frame: func() []byte {
+				frame := buildFrame(mandatoryTLVs(120)...)
+				binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+				return frame
+			}(),
+			errKey: "is not LLDP",
+		},
+		{
+			name:   "missing TTL TLV",
+			frame:  buildFrame(mandatoryTLVs(120)[:2]...),
+			errKey: "missing mandatory TLVs",
+		},
+		{
+			name: "truncated TLV",
+			frame: func() []byte {
+				frame := buildFrame(mandatoryTLVs(120)...)
+				return frame[:len(frame)-4]
+			}(),
+			errKey: "truncated",
+		},
+		{
+			name:   "invalid VLAN name length",
+			frame:  buildFrame(append(mandatoryTLVs(120), tlv(tlvTypeOrgSpecific, []byte{0x00, 0x80, 0xc2, subtype8021VlanName, 0x00, 0x64, 0x08, 'm'}))...),
+			errKey: "invalid VLAN name TLV length",
+		},
+		{
+			name:   "invalid MAC chassis ID",
+			frame:  buildFrame(append([][]byte{tlv(tlvTypeChassisID, []byte{chassisIDSubtypeMAC, 0x00, 0x1c})}, mandatoryTLVs(120)[1:]...)...),
+			errKey: "invalid chassis ID TLV",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			neighbor, err := ParseFrame(tc.frame)
+			if tc.errKey != "" {
+				assert.ErrorContains(t, err, tc.errKey)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, neighbor)
+		})
+	}
+}
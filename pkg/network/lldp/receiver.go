@@ -0,0 +1,269 @@
+package lldp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	maxFrameSize  = 9216
+	checkInterval = 5 * time.Second
+)
+
+// Receiver listens to the LLDP frames on a set of interfaces and keeps the latest neighbor of every interface
+// until its TTL expires
+type Receiver struct {
+	mu        sync.RWMutex
+	listeners map[string]*listener
+	neighbors map[string]*neighborEntry
+
+	// onChange is called with the interface name after the neighbor of the interface is changed
+	onChange func(ifName string)
+
+	startOnce sync.Once
+}
+
+type listener struct {
+	ifIndex int
+	conn    *packet.Conn
+	done    chan struct{}
+}
+
+type neighborEntry struct {
+	neighbor *Neighbor
+	expireAt time.Time
+}
+
+func NewReceiver(onChange func(ifName string)) *Receiver {
+	return &Receiver{
+		listeners: make(map[string]*listener),
+		neighbors: make(map[string]*neighborEntry),
+		onChange:  onChange,
+	}
+}
+
+// Start removes the expired neighbors periodically until the context is done, then stops all listeners
+func (r *Receiver) Start(ctx context.Context) {
+	r.startOnce.Do(func() {
+		ticker := time.NewTicker(checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.removeExpiredNeighbors(time.Now())
+			case <-ctx.Done():
+				_ = r.SetInterfaces(nil)
+				return
+			}
+		}
+	})
+}
+
+// SetInterfaces starts listening on the given interfaces and stops listening on the others
+func (r *Receiver) SetInterfaces(names []string) error {
+	desired := make(map[string]int, len(names))
+	var errs []error
+	for _, name := range names {
+		ifi, err := net.InterfaceByName(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("get interface %s failed, error: %w", name, err))
+			continue
+		}
+		desired[name] = ifi.Index
+	}
+
+	r.mu.Lock()
+	var changed []string
+	for name, l := range r.listeners {
+		index, ok := desired[name]
+		// restart the listener if the interface is recreated or the listener exits because of read errors
+		if ok && index == l.ifIndex && !l.isDone() {
+			continue
+		}
+		l.conn.Close()
+		delete(r.listeners, name)
+		if _, ok := r.neighbors[name]; ok {
+			delete(r.neighbors, name)
+			changed = append(changed, name)
+		}
+	}
+	for name, index := range desired {
+		if _, ok := r.listeners[name]; ok {
+			continue
+		}
+		l, err := r.listen(name, index)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.listeners[name] = l
+	}
+	r.mu.Unlock()
+
+	r.notify(changed...)
+
+	return errors.Join(errs...)
+}
+
+// GetNeighbor returns a copy of the neighbor of the interface, nil if no neighbor is discovered
+func (r *Receiver) GetNeighbor(ifName string) *Neighbor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.neighbors[ifName]
+	if !ok {
+		return nil
+	}
+	n := *entry.neighbor
+	n.Vlans = append([]Vlan(nil), entry.neighbor.Vlans...)
+
+	return &n
+}
+
+func (r *Receiver) listen(name string, index int) (*listener, error) {
+	conn, err := packet.Listen(&net.Interface{Name: name, Index: index}, packet.Raw, EtherType, nil)
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s failed, error: %w", name, err)
+	}
+	if err := joinNearestBridgeGroup(conn, index); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("join LLDP multicast group on %s failed, error: %w", name, err)
+	}
+
+	l := &listener{
+		ifIndex: index,
+		conn:    conn,
+		done:    make(chan struct{}),
+	}
+	go r.receive(name, l)
+
+	logrus.Infof("start receiving LLDP frames on %s", name)
+	return l, nil
+}
+
+func (r *Receiver) receive(name string, l *listener) {
+	defer close(l.done)
+
+	buf := make([]byte, maxFrameSize)
+	for {
+		n, _, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if !r.isStopped(name, l) {
+				logrus.Errorf("receive LLDP frames on %s failed, error: %s", name, err.Error())
+			}
+			return
+		}
+
+		neighbor, err := ParseFrame(buf[:n])
+		if err != nil {
+			logrus.Debugf("ignore invalid LLDP frame on %s, error: %s", name, err.Error())
+			continue
+		}
+		r.updateNeighbor(name, l, neighbor, time.Now())
+	}
+}
+
+func (r *Receiver) updateNeighbor(name string, l *listener, neighbor *Neighbor, now time.Time) {
+	r.mu.Lock()
+	// the listener has been stopped
+	if r.listeners[name] != l {
+		r.mu.Unlock()
+		return
+	}
+
+	old, exists := r.neighbors[name]
+	changed := false
+	switch {
+	case neighbor.TTL == 0:
+		delete(r.neighbors, name)
+		changed = exists
+	default:
+		r.neighbors[name] = &neighborEntry{neighbor: neighbor, expireAt: now.Add(neighbor.TTL)}
+		changed = !exists || !equalNeighbors(old.neighbor, neighbor)
+	}
+	r.mu.Unlock()
+
+	if changed {
+		r.notify(name)
+	}
+}
+
+// isStopped returns true if the listener is closed by SetInterfaces
+func (r *Receiver) isStopped(name string, l *listener) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.listeners[name] != l
+}
+
+func (r *Receiver) removeExpiredNeighbors(now time.Time) {
+	r.mu.Lock()
+	var expired []string
+	for name, entry := range r.neighbors {
+		if now.After(entry.expireAt) {
+			delete(r.neighbors, name)
+			expired = append(expired, name)
+		}
+	}
+	r.mu.Unlock()
+
+	r.notify(expired...)
+}
+
+func (r *Receiver) notify(names ...string) {
+	if r.onChange == nil {
+		return
+	}
+	for _, name := range names {
+		r.onChange(name)
+	}
+}
+
+func (l *listener) isDone() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// equalNeighbors ignores the TTL which only affects the expiration
+func equalNeighbors(a, b *Neighbor) bool {
+	aCopy, bCopy := *a, *b
+	aCopy.TTL, bCopy.TTL = 0, 0
+	return reflect.DeepEqual(aCopy, bCopy)
+}
+
+// joinNearestBridgeGroup makes the NIC accept the frames sent to the nearest bridge group address
+func joinNearestBridgeGroup(conn *packet.Conn, index int) error {
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	mreq := &unix.PacketMreq{
+		Ifindex: int32(index), //nolint:gosec
+		Type:    unix.PACKET_MR_MULTICAST,
+		Alen:    uint16(len(NearestBridgeMAC)),
+	}
+	copy(mreq.Address[:], NearestBridgeMAC)
+
+	var sockErr error
+	if err := rc.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptPacketMreq(int(fd), unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, mreq)
+	}); err != nil {
+		return err
+	}
+
+	return sockErr
+}
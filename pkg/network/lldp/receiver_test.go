@@ -0,0 +1,113 @@
+package lldp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	testVethName = "lldp-test0"
+	testPeerName = "lldp-test1"
+	testTimeout  = 5 * time.Second
+)
+
+// setupVeth creates a veth pair, the receiver listens on one end and the crafted frames are sent from the peer
+func setupVeth(t *testing.T) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testVethName},
+		PeerName:  testPeerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(veth) })
+
+	for _, name := range []string{testVethName, testPeerName} {
+		l, err := netlink.LinkByName(name)
+		mustSucceed(t, err)
+		mustSucceed(t, netlink.LinkSetUp(l))
+	}
+}
+
+func sendFrame(t *testing.T, conn *packet.Conn, frame []byte) {
+	_, err := conn.WriteTo(frame, &packet.Addr{HardwareAddr: NearestBridgeMAC})
+	mustSucceed(t, err)
+}
+
+func waitForChange(t *testing.T, changes <-chan string) {
+	select {
+	case name := <-changes:
+		assert.Equal(t, testVethName, name)
+	case <-time.After(testTimeout):
+		t.Fatal("timeout waiting for the neighbor change")
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	setupVeth(t)
+
+	changes := make(chan string, 10)
+	r := NewReceiver(func(ifName string) { changes <- ifName })
+	mustSucceed(t, r.SetInterfaces([]string{testVethName}))
+	t.Cleanup(func() { _ = r.SetInterfaces(nil) })
+
+	peer, err := net.InterfaceByName(testPeerName)
+	mustSucceed(t, err)
+	conn, err := packet.Listen(peer, packet.Raw, EtherType, nil)
+	mustSucceed(t, err)
+	defer conn.Close()
+
+	// a new neighbor is discovered
+	sendFrame(t, conn, buildFrame(append(mandatoryTLVs(120), tlv(tlvTypeSystemName, []byte("tor-switch-1")), vlanNameTLV(2012, "vm-network"))...))
+	waitForChange(t, changes)
+	assert.Equal(t, &Neighbor{
+		ChassisID:  "00:1c:73:aa:bb:cc",
+		PortID:     "Ethernet12",
+		SystemName: "tor-switch-1",
+		Vlans:      []Vlan{{ID: 2012, Name: "vm-network"}},
+		TTL:        120 * time.Second,
+	}, r.GetNeighbor(testVethName))
+
+	// the refreshed neighbor doesn't trigger a change, the invalid frame is ignored
+	sendFrame(t, conn, buildFrame(append(mandatoryTLVs(90), tlv(tlvTypeSystemName, []byte("tor-switch-1")), vlanNameTLV(2012, "vm-network"))...))
+	sendFrame(t, conn, buildFrame(mandatoryTLVs(120)[:2]...))
+	// the VLANs of the neighbor are changed
+	sendFrame(t, conn, buildFrame(append(mandatoryTLVs(120), tlv(tlvTypeSystemName, []byte("tor-switch-1")), portVlanIDTLV(1))...))
+	waitForChange(t, changes)
+	neighbor := r.GetNeighbor(testVethName)
+	assert.Equal(t, uint16(1), neighbor.PortVlanID)
+	assert.Empty(t, neighbor.Vlans)
+
+	// the neighbor shuts down
+	sendFrame(t, conn, buildFrame(mandatoryTLVs(0)...))
+	waitForChange(t, changes)
+	assert.Nil(t, r.GetNeighbor(testVethName))
+
+	// the neighbor expires
+	sendFrame(t, conn, buildFrame(mandatoryTLVs(1)...))
+	waitForChange(t, changes)
+	assert.NotNil(t, r.GetNeighbor(testVethName))
+	r.removeExpiredNeighbors(time.Now().Add(2 * time.Second))
+	waitForChange(t, changes)
+	assert.Nil(t, r.GetNeighbor(testVethName))
+
+	// the neighbor is removed after the receiver stops listening on the interface
+	sendFrame(t, conn, buildFrame(mandatoryTLVs(120)...))
+	waitForChange(t, changes)
+	mustSucceed(t, r.SetInterfaces(nil))
+	waitForChange(t, changes)
+	assert.Nil(t, r.GetNeighbor(testVethName))
+	assert.Empty(t, changes)
+}
+
+func mustSucceed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	// EtherType is the IEEE 802 local experimental ethertype 1 which carries the probes
+	EtherType = 0x88b5
+
+	etherTypeVlan  = 0x8100
+	etherHeaderLen = 14
+	vlanTagLen     = 4
+	minFrameLen    = 60
+	version        = 1
+	// magic(4) + version(1) + type(1) + VID(2) + sequence(4) + sent time(8), followed by 3 length prefixed names
+	fixedPayloadLen = 20
+)
+
+var (
+	magic = []byte("HNCP")
+	// BroadcastMAC is the destination of the announcements and requests
+	BroadcastMAC = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+)
+
+type Type byte
+
+const (
+	// TypeAnnounce is broadcast periodically without reply
+	TypeAnnounce Type = iota
+	// TypeRequest is broadcast to ask the other nodes for replies
+	TypeRequest
+	// TypeReply is sent back to the node which sends the request
+	TypeReply
+)
+
+// Probe is broadcast by the agent on every VLAN of a cluster network to tell the agents on the other nodes
+// that the VLAN is carried between them
+type Probe struct {
+	Type           Type
+	ClusterNetwork string
+	Node           string
+	// Target is the node which sends the request, only for replies
+	Target string
+	// VID is the VLAN which the probe is sent on, 0 means untagged
+	VID uint16
+	Seq uint32
+	// SentAt of a reply is copied from the request to calculate the round trip time
+	SentAt time.Time
+}
+
+// MarshalFrame returns the ethernet frame of the probe, the frame is tagged with the VID if it is not 0
+func (p *Probe) MarshalFrame(dst, src net.HardwareAddr) ([]byte, error) {
+	for _, name := range []string{p.ClusterNetwork, p.Node, p.Target} {
+		if len(name) > 0xff {
+			return nil, fmt.Errorf("name %s is too long", name)
+		}
+	}
+
+	frame := make([]byte, 0, minFrameLen)
+	frame = append(frame, dst...)
+	frame = append(frame, src...)
+	if p.VID != 0 {
+		frame = binary.BigEndian.AppendUint16(frame, etherTypeVlan)
+		frame = binary.BigEndian.AppendUint16(frame, p.VID)
+	}
+	frame = binary.BigEndian.AppendUint16(frame, EtherType)
+
+	frame = append(frame, magic...)
+	frame = append(frame, version, byte(p.Type))
+	frame = binary.BigEndian.AppendUint16(frame, p.VID)
+	frame = binary.BigEndian.AppendUint32(frame, p.Seq)
+	frame = binary.BigEndian.AppendUint64(frame, uint64(p.SentAt.UnixNano())) //nolint:gosec
+	frame = append(frame, byte(len(p.ClusterNetwork)))
+	frame = append(frame, p.ClusterNetwork...)
+	frame = append(frame, byte(len(p.Node)))
+	frame = append(frame, p.Node...)
+	frame = append(frame, byte(len(p.Target)))
+	frame = append(frame, p.Target...)
+
+	// pad the short frame with zeros
+	for len(frame) < minFrameLen {
+		frame = append(frame, 0)
+	}
+
+	return frame, nil
+}
+
+// ParseFrame parses the ethernet frame which carries a probe, the VLAN tag is optional because the kernel
+// strips it on receiving
+func ParseFrame(frame []byte) (*Probe, error) {
+	if len(frame) < etherHeaderLen {
+		return nil, fmt.Errorf("frame length %d is too short", len(frame))
+	}
+
+	offset := etherHeaderLen
+	etherType := binary.BigEndian.Uint16(frame[offset-2 : offset])
+	if etherType == etherTypeVlan {
+		if len(frame) < etherHeaderLen+vlanTagLen {
+			return nil, fmt.Errorf("frame length %d is too short", len(frame))
+		}
+		offset += vlanTagLen
+		etherType = binary.BigEndian.Uint16(frame[offset-2 : offset])
+	}
+	if etherType != EtherType {
+		return nil, fmt.Errorf("ether type %#04x is not probe", etherType)
+	}
+
+	return parsePayload(frame[offset:])
+}
+
+func parsePayload(b []byte) (*Probe, error) {
+	if len(b) < fixedPayloadLen {
+		return nil, fmt.Errorf("payload length %d is too short", len(b))
+	}
+	if !bytes.Equal(b[:len(magic)], magic) {
+		return nil, fmt.Errorf("invalid magic %x", b[:len(magic)])
+	}
+	if b[4] != version {
+		return nil, fmt.Errorf("unsupported version %d", b[4])
+	}
+
+	p := &Probe{
+		Type:   Type(b[5]),
+		VID:    binary.BigEndian.Uint16(b[6:8]),
+		Seq:    binary.BigEndian.Uint32(b[8:12]),
+		SentAt: time.Unix(0, int64(binary.BigEndian.Uint64(b[12:20]))), //nolint:gosec
+	}
+	if p.Type > TypeReply {
+		return nil, fmt.Errorf("unsupported type %d", p.Type)
+	}
+
+	names := b[fixedPayloadLen:]
+	var err error
+	if p.ClusterNetwork, names, err = parseName(names); err != nil {
+		return nil, fmt.Errorf("invalid cluster network name, error: %w", err)
+	}
+	if p.Node, names, err = parseName(names); err != nil {
+		return nil, fmt.Errorf("invalid node name, error: %w", err)
+	}
+	if p.Target, _, err = parseName(names); err != nil {
+		return nil, fmt.Errorf("invalid target name, error: %w", err)
+	}
+	if p.ClusterNetwork == "" || p.Node == "" {
+		return nil, fmt.Errorf("cluster network name or node name is empty")
+	}
+
+	return p, nil
+}
+
+// parseName parses the length prefixed name and returns the remaining bytes
+func parseName(b []byte) (string, []byte, error) {
+	if len(b) < 1 || len(b) < 1+int(b[0]) {
+		return "", nil, fmt.Errorf("truncated name")
+	}
+	return string(b[1 : 1+int(b[0])]), b[1+int(b[0]):], nil
+}
@@ -0,0 +1,128 @@
+package probe
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSrcMAC = net.HardwareAddr{0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+
+func TestMarshalAndParseFrame(t *testing.T) {
+	sentAt := time.Unix(0, 1700000000123456789)
+	tests := []struct {
+		name   string
+		probe  *Probe
+		tagged bool
+	}{
+		{
+			name:   "tagged probe",
+			probe:  &Probe{ClusterNetwork: "data", Node: "node1", VID: 100, Seq: 1, SentAt: sentAt},
+			tagged: true,
+		},
+		{
+			name:  "untagged probe",
+			probe: &Probe{ClusterNetwork: "data", Node: "node1", Seq: 2, SentAt: sentAt},
+		},
+		{
+			name:   "probe with long names",
+			probe:  &Probe{ClusterNetwork: "storage-network-1", Node: "harvester-node-with-a-long-name", VID: 4094, Seq: 3, SentAt: sentAt},
+			tagged: true,
+		},
+		{
+			name:   "reply",
+			probe:  &Probe{Type: TypeReply, ClusterNetwork: "data", Node: "node2", Target: "node1", VID: 100, Seq: 4, SentAt: sentAt},
+			tagged: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := tc.probe.MarshalFrame(BroadcastMAC, testSrcMAC)
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, len(frame), minFrameLen)
+			assert.Equal(t, tc.tagged, binary.BigEndian.Uint16(frame[12:14]) == etherTypeVlan)
+
+			probe, err := ParseFrame(frame)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.probe.Type, probe.Type)
+			assert.Equal(t, tc.probe.Target, probe.Target)
+			assert.Equal(t, tc.probe.ClusterNetwork, probe.ClusterNetwork)
+			assert.Equal(t, tc.probe.Node, probe.Node)
+			assert.Equal(t, tc.probe.VID, probe.VID)
+			assert.Equal(t, tc.probe.Seq, probe.Seq)
+			assert.True(t, tc.probe.SentAt.Equal(probe.SentAt))
+		})
+	}
+}
+
+func TestParseInvalidFrame(t *testing.T) {
+	valid, err := (&Probe{ClusterNetwork: "data", Node: "node1", Seq: 1, SentAt: time.Now()}).MarshalFrame(BroadcastMAC, testSrcMAC)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		frame  func() []byte
+		errKey string
+	}{
+		{
+			name:   "short frame",
+			frame:  func() []byte { return valid[:10] },
+			errKey: "too short",
+		},
+		{
+			name: "other ether type",
+			frame: func() []byte {
+				frame := append([]byte{}, valid...)
+				binary.BigEndian.PutUint16(frame[12:14], 0x0806)
+				return frame
+			},
+			errKey: "is not probe",
+		},
+		{
+			name: "invalid magic",
+			frame: func() []byte {
+				frame := append([]byte{}, valid...)
+				frame[etherHeaderLen] = 'X'
+				return frame
+			},
+			errKey: "invalid magic",
+		},
+		{
+			name: "unsupported version",
+			frame: func() []byte {
+				frame := append([]byte{}, valid...)
+				frame[etherHeaderLen+4] = version + 1
+				return frame
+			},
+			errKey: "unsupported version",
+		},
+		{
+			name: "unsupported type",
+			frame: func() []byte {
+				frame := append([]byte{}, valid...)
+				frame[etherHeaderLen+5] = byte(TypeReply) + 1
+				return frame
+			},
+			errKey: "unsupported type",
+		},
+		{
+			name: "truncated node name",
+			frame: func() []byte {
+				// cut the frame in the middle of the node name
+				return append([]byte{}, valid[:etherHeaderLen+fixedPayloadLen+1+len("data")+3]...)
+			},
+			errKey: "invalid node name",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseFrame(tc.frame())
+			assert.ErrorContains(t, err, tc.errKey)
+		})
+	}
+}
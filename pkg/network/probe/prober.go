@@ -0,0 +1,278 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	maxFrameSize = 1518
+	// maxProbesPerInterval caps the probes broadcast by a node in an interval, the VLANs of a large trunk are
+	// probed in turn over several intervals
+	maxProbesPerInterval = 256
+)
+
+// Prober broadcasts the probes of a cluster network on a set of VLANs periodically and records the probes
+// received from the other nodes
+type Prober struct {
+	cnName   string
+	nodeName string
+	ifName   string
+	interval time.Duration
+
+	mu        sync.RWMutex
+	vids      []uint16
+	heard     map[uint16]map[string]time.Time
+	startedAt time.Time
+	ifIndex   int
+	seq       uint32
+	// next is the index of the VLAN to probe first in the next interval
+	next int
+
+	conn   *packet.Conn
+	cancel context.CancelFunc
+}
+
+func NewProber(cnName, nodeName, ifName string, interval time.Duration) *Prober {
+	return &Prober{
+		cnName:   cnName,
+		nodeName: nodeName,
+		ifName:   ifName,
+		interval: interval,
+		heard:    make(map[uint16]map[string]time.Time),
+	}
+}
+
+// Start opens a packet socket on the interface, then sends and receives the probes until the context is done
+// or the prober is stopped
+func (p *Prober) Start(ctx context.Context) error {
+	ifi, err := net.InterfaceByName(p.ifName)
+	if err != nil {
+		return fmt.Errorf("get interface %s failed, error: %w", p.ifName, err)
+	}
+
+	// the packet socket of the ETH_P_ALL protocol receives the frames before the bridge handles them
+	filter, err := etherTypeFilter()
+	if err != nil {
+		return err
+	}
+	conn, err := packet.Listen(ifi, packet.Raw, unix.ETH_P_ALL, &packet.Config{Filter: filter})
+	if err != nil {
+		return fmt.Errorf("listen on %s failed, error: %w", p.ifName, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	p.conn = conn
+	p.cancel = cancel
+	p.startedAt = time.Now()
+	p.ifIndex = ifi.Index
+	p.mu.Unlock()
+
+	go p.receive()
+	go p.send(ctx, ifi.HardwareAddr)
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	logrus.Infof("start probing cluster network %s on %s", p.cnName, p.ifName)
+	return nil
+}
+
+func (p *Prober) Stop() {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// StartedAt returns the time when the prober starts, zero if it isn't started
+func (p *Prober) StartedAt() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.startedAt
+}
+
+// IfIndex returns the index of the interface which the prober is bound to, the prober has to be restarted
+// if the interface is recreated
+func (p *Prober) IfIndex() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ifIndex
+}
+
+// SetVIDs sets the VLANs which the probes are sent on
+func (p *Prober) SetVIDs(vis *utils.VlanIDSet) error {
+	var vids []uint16
+	if err := vis.WalkVIDs(p.cnName, func(vid uint16) error {
+		vids = append(vids, vid)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vids = vids
+	for vid := range p.heard {
+		if _, ok := slices.BinarySearch(vids, vid); !ok {
+			delete(p.heard, vid)
+		}
+	}
+
+	return nil
+}
+
+// Heard returns the sorted names of the nodes whose probes are received after the given time on every VLAN
+func (p *Prober) Heard(since time.Time) map[uint16][]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	heard := make(map[uint16][]string, len(p.heard))
+	for vid, nodes := range p.heard {
+		for node, t := range nodes {
+			if t.After(since) {
+				heard[vid] = append(heard[vid], node)
+			}
+		}
+		sort.Strings(heard[vid])
+	}
+
+	return heard
+}
+
+// Cycle returns the time in which the probes are sent on every VLAN once
+func (p *Prober) Cycle() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	rounds := max((len(p.vids)+maxProbesPerInterval-1)/maxProbesPerInterval, 1)
+	return time.Duration(rounds) * p.interval
+}
+
+// send spreads the probes of each interval evenly across it rather than bursting them
+func (p *Prober) send(ctx context.Context, src net.HardwareAddr) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		seq, vids := p.nextRound()
+		gap := p.interval
+		if len(vids) > 0 {
+			gap = p.interval / time.Duration(len(vids))
+		}
+
+		for i := 0; i < max(len(vids), 1); i++ {
+			if i < len(vids) {
+				p.sendProbe(src, seq, vids[i])
+			}
+			timer.Reset(gap)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// nextRound returns the VLANs to probe in the next interval, at most maxProbesPerInterval of them starting from
+// where the last interval stops
+func (p *Prober) nextRound() (uint32, []uint16) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.seq++
+	if len(p.vids) <= maxProbesPerInterval {
+		p.next = 0
+		return p.seq, p.vids
+	}
+
+	if p.next >= len(p.vids) {
+		p.next = 0
+	}
+	end := min(p.next+maxProbesPerInterval, len(p.vids))
+	vids := p.vids[p.next:end]
+	p.next = end
+
+	return p.seq, vids
+}
+
+func (p *Prober) sendProbe(src net.HardwareAddr, seq uint32, vid uint16) {
+	probe := &Probe{
+		Type:           TypeAnnounce,
+		ClusterNetwork: p.cnName,
+		Node:           p.nodeName,
+		VID:            vid,
+		Seq:            seq,
+		SentAt:         time.Now(),
+	}
+	frame, err := probe.MarshalFrame(BroadcastMAC, src)
+	if err != nil {
+		logrus.Errorf("marshal probe of cluster network %s failed, error: %s", p.cnName, err.Error())
+		return
+	}
+	if _, err := p.conn.WriteTo(frame, &packet.Addr{HardwareAddr: BroadcastMAC}); err != nil {
+		logrus.Debugf("send probe on vlan %d of %s failed, error: %s", vid, p.ifName, err.Error())
+	}
+}
+
+func (p *Prober) receive() {
+	buf := make([]byte, maxFrameSize)
+	for {
+		n, _, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			logrus.Infof("stop probing cluster network %s on %s, error: %s", p.cnName, p.ifName, err.Error())
+			return
+		}
+
+		probe, err := ParseFrame(buf[:n])
+		if err != nil {
+			logrus.Debugf("ignore invalid probe on %s, error: %s", p.ifName, err.Error())
+			continue
+		}
+		if probe.ClusterNetwork != p.cnName || probe.Node == p.nodeName {
+			continue
+		}
+		p.record(probe, time.Now())
+	}
+}
+
+func (p *Prober) record(probe *Probe, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.heard[probe.VID]; !ok {
+		p.heard[probe.VID] = make(map[string]time.Time)
+	}
+	p.heard[probe.VID][probe.Node] = now
+}
+
+// etherTypeFilter only accepts the untagged probe frames, the received frames have no VLAN tag because
+// the kernel strips it, and the sent frames with the VLAN tag are filtered out
+func etherTypeFilter() ([]bpf.RawInstruction, error) {
+	filter, err := bpf.Assemble([]bpf.Instruction{
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: EtherType, SkipFalse: 1},
+		bpf.RetConstant{Val: maxFrameSize},
+		bpf.RetConstant{Val: 0},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assemble BPF filter failed, error: %w", err)
+	}
+	return filter, nil
+}
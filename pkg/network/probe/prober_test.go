@@ -0,0 +1,132 @@
+package probe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+const (
+	testVethName    = "probe-test0"
+	testPeerName    = "probe-test1"
+	testCnName      = "test"
+	testInterval    = 100 * time.Millisecond
+	testWaitTimeout = 5 * time.Second
+)
+
+// setupVeth creates a veth pair to simulate the uplinks of two nodes connected by a trunk
+func setupVeth(t *testing.T) {
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{Name: testVethName},
+		PeerName:  testPeerName,
+	}
+	if err := netlink.LinkAdd(veth); err != nil {
+		t.Skipf("create veth pair failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = netlink.LinkDel(veth) })
+
+	for _, name := range []string{testVethName, testPeerName} {
+		l, err := netlink.LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := netlink.LinkSetUp(l); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newVlanIDSet(t *testing.T, vids ...int) *utils.VlanIDSet {
+	vis := utils.NewVlanIDSet()
+	for _, vid := range vids {
+		if err := vis.SetVID(vid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vis
+}
+
+func startProber(ctx context.Context, t *testing.T, nodeName, ifName string, vids ...int) *Prober {
+	p := NewProber(testCnName, nodeName, ifName, testInterval)
+	if err := p.SetVIDs(newVlanIDSet(t, vids...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(p.Stop)
+	return p
+}
+
+func TestProber(t *testing.T) {
+	setupVeth(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	since := time.Now()
+	// node2 doesn't send probes on vlan 300
+	node1 := startProber(ctx, t, "node1", testVethName, 100, 200, 300)
+	node2 := startProber(ctx, t, "node2", testPeerName, 100, 200)
+	// the probes of other cluster networks are ignored
+	other := NewProber("other", "node3", testPeerName, testInterval)
+	assert.NoError(t, other.SetVIDs(newVlanIDSet(t, 300)))
+	assert.NoError(t, other.Start(ctx))
+	defer other.Stop()
+
+	assert.Eventually(t, func() bool {
+		heard := node1.Heard(since)
+		return len(heard[100]) > 0 && len(heard[200]) > 0
+	}, testWaitTimeout, testInterval)
+	assert.Eventually(t, func() bool {
+		return len(node2.Heard(since)[300]) > 0
+	}, testWaitTimeout, testInterval)
+
+	heard := node1.Heard(since)
+	assert.Equal(t, map[uint16][]string{100: {"node2"}, 200: {"node2"}}, heard)
+	// the node doesn't record its own probes
+	assert.Equal(t, []string{"node1"}, node2.Heard(since)[100])
+	assert.True(t, node1.StartedAt().After(since))
+
+	// the records of the removed vlans are cleared
+	assert.NoError(t, node1.SetVIDs(newVlanIDSet(t, 100)))
+	assert.NotContains(t, node1.Heard(since), uint16(200))
+
+	// nothing is heard after the peer stops
+	node2.Stop()
+	time.Sleep(2 * testInterval)
+	assert.Empty(t, node1.Heard(time.Now()))
+}
+
+func TestProberRounds(t *testing.T) {
+	vids := make([]int, 0, 600)
+	// the default VLAN 1 isn't probed
+	for vid := 2; vid <= 601; vid++ {
+		vids = append(vids, vid)
+	}
+	p := NewProber(testCnName, "node1", testVethName, testInterval)
+	assert.NoError(t, p.SetVIDs(newVlanIDSet(t, vids...)))
+	assert.Equal(t, 3*testInterval, p.Cycle())
+
+	// the VLANs beyond the cap are probed in the next intervals in turn
+	var sizes []int
+	var first []uint16
+	for i := 0; i < 4; i++ {
+		_, round := p.nextRound()
+		sizes = append(sizes, len(round))
+		first = append(first, round[0])
+	}
+	assert.Equal(t, []int{256, 256, 88, 256}, sizes)
+	assert.Equal(t, []uint16{2, 258, 514, 2}, first)
+
+	// a small trunk is probed in every interval
+	assert.NoError(t, p.SetVIDs(newVlanIDSet(t, 100, 200)))
+	assert.Equal(t, testInterval, p.Cycle())
+	_, round := p.nextRound()
+	assert.Equal(t, []uint16{100, 200}, round)
+}
@@ -0,0 +1,253 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mdlayher/packet"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// SessionConfig configures a probe session in which the nodes send requests to each other and reply
+// the requests at the same time
+type SessionConfig struct {
+	ClusterNetwork string
+	Node           string
+	// IfName is the probe endpoint which sends tagged frames
+	IfName string
+	VIDs   []uint16
+	// Peers are the nodes expected to reply, the results of the peers without any reply are reported as well
+	Peers []string
+	// Count of the requests sent on every VLAN
+	Count int
+	// Settle is the time to wait for the peers to get ready before sending the first requests
+	Settle time.Duration
+	// Gap is the time between two requests on the same VLAN
+	Gap time.Duration
+	// Wait is the time to wait for the replies after the last requests are sent
+	Wait time.Duration
+}
+
+// Duration returns how long the session lasts
+func (c *SessionConfig) Duration() time.Duration {
+	return c.Settle + time.Duration(c.Count)*c.Gap + c.Wait
+}
+
+// Result is the statistics of the requests sent on a VLAN to a peer
+type Result struct {
+	VID        uint16
+	Node       string
+	Sent       int
+	Received   int
+	AverageRTT time.Duration
+}
+
+// LossPercent returns the percentage of the requests without reply
+func (r *Result) LossPercent() int {
+	if r.Sent == 0 {
+		return 0
+	}
+	return (r.Sent - r.Received) * 100 / r.Sent
+}
+
+type replyKey struct {
+	vid  uint16
+	node string
+}
+
+type session struct {
+	cfg  *SessionConfig
+	conn *packet.Conn
+	src  net.HardwareAddr
+
+	mu sync.Mutex
+	// round trip times of the replies, indexed by the sequence to ignore the duplicated replies
+	rtts map[replyKey]map[uint32]time.Duration
+}
+
+// RunSession sends the requests and replies the requests from the peers on the interface until the session ends
+func RunSession(ctx context.Context, cfg *SessionConfig) ([]Result, error) {
+	ifi, err := net.InterfaceByName(cfg.IfName)
+	if err != nil {
+		return nil, fmt.Errorf("get interface %s failed, error: %w", cfg.IfName, err)
+	}
+	filter, err := etherTypeFilter()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := packet.Listen(ifi, packet.Raw, unix.ETH_P_ALL, &packet.Config{Filter: filter})
+	if err != nil {
+		return nil, fmt.Errorf("listen on %s failed, error: %w", cfg.IfName, err)
+	}
+
+	s := &session{
+		cfg:  cfg,
+		conn: conn,
+		src:  ifi.HardwareAddr,
+		rtts: make(map[replyKey]map[uint32]time.Duration),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.receive()
+	}()
+
+	err = s.sendRequests(ctx)
+	conn.Close()
+	<-done
+	if err != nil {
+		return nil, err
+	}
+
+	return s.results(), nil
+}
+
+func (s *session) sendRequests(ctx context.Context) error {
+	timer := time.NewTimer(s.cfg.Settle)
+	defer timer.Stop()
+
+	for seq := 0; seq <= s.cfg.Count; seq++ {
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		// wait for the replies of the last requests
+		if seq == s.cfg.Count {
+			break
+		}
+
+		for _, vid := range s.cfg.VIDs {
+			request := &Probe{
+				Type:           TypeRequest,
+				ClusterNetwork: s.cfg.ClusterNetwork,
+				Node:           s.cfg.Node,
+				VID:            vid,
+				Seq:            uint32(seq), //nolint:gosec
+				SentAt:         time.Now(),
+			}
+			if err := s.send(request, BroadcastMAC); err != nil {
+				return err
+			}
+		}
+
+		if seq == s.cfg.Count-1 {
+			timer.Reset(s.cfg.Wait)
+		} else {
+			timer.Reset(s.cfg.Gap)
+		}
+	}
+
+	return nil
+}
+
+func (s *session) send(probe *Probe, dst net.HardwareAddr) error {
+	frame, err := probe.MarshalFrame(dst, s.src)
+	if err != nil {
+		return err
+	}
+	if _, err := s.conn.WriteTo(frame, &packet.Addr{HardwareAddr: dst}); err != nil {
+		return fmt.Errorf("send probe on vlan %d of %s failed, error: %w", probe.VID, s.cfg.IfName, err)
+	}
+	return nil
+}
+
+func (s *session) receive() {
+	buf := make([]byte, maxFrameSize)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+
+		probe, err := ParseFrame(buf[:n])
+		if err != nil {
+			logrus.Debugf("ignore invalid probe on %s, error: %s", s.cfg.IfName, err.Error())
+			continue
+		}
+		if probe.ClusterNetwork != s.cfg.ClusterNetwork || probe.Node == s.cfg.Node {
+			continue
+		}
+
+		switch probe.Type {
+		case TypeRequest:
+			reply := &Probe{
+				Type:           TypeReply,
+				ClusterNetwork: s.cfg.ClusterNetwork,
+				Node:           s.cfg.Node,
+				Target:         probe.Node,
+				VID:            probe.VID,
+				Seq:            probe.Seq,
+				SentAt:         probe.SentAt,
+			}
+			src, ok := addr.(*packet.Addr)
+			if !ok {
+				continue
+			}
+			if err := s.send(reply, src.HardwareAddr); err != nil {
+				logrus.Debugf("reply probe of %s failed, error: %s", probe.Node, err.Error())
+			}
+		case TypeReply:
+			if probe.Target == s.cfg.Node {
+				s.record(probe, now)
+			}
+		}
+	}
+}
+
+func (s *session) record(reply *Probe, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := replyKey{vid: reply.VID, node: reply.Node}
+	if _, ok := s.rtts[key]; !ok {
+		s.rtts[key] = make(map[uint32]time.Duration)
+	}
+	s.rtts[key][reply.Seq] = now.Sub(reply.SentAt)
+}
+
+// results returns the results sorted by the VID and the node name
+func (s *session) results() []Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nodes := make(map[string]bool, len(s.cfg.Peers))
+	for _, peer := range s.cfg.Peers {
+		nodes[peer] = true
+	}
+	for key := range s.rtts {
+		nodes[key.node] = true
+	}
+	sortedNodes := make([]string, 0, len(nodes))
+	for node := range nodes {
+		sortedNodes = append(sortedNodes, node)
+	}
+	sort.Strings(sortedNodes)
+
+	results := make([]Result, 0, len(s.cfg.VIDs)*len(sortedNodes))
+	for _, vid := range s.cfg.VIDs {
+		for _, node := range sortedNodes {
+			result := Result{VID: vid, Node: node, Sent: s.cfg.Count}
+			var total time.Duration
+			for seq, rtt := range s.rtts[replyKey{vid: vid, node: node}] {
+				if int(seq) < s.cfg.Count {
+					result.Received++
+					total += rtt
+				}
+			}
+			if result.Received > 0 {
+				result.AverageRTT = total / time.Duration(result.Received)
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,212 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	"github.com/harvester/harvester-network-controller/pkg/network/iface"
+)
+
+const (
+	testBridgeName   = "test-br"
+	testUplinkName   = "test-up0"
+	testUplinkPeer   = "test-up1"
+	testEndpointName = "test-pr"
+	testPortName     = "test-pp"
+)
+
+// onLockedThread runs the function on a locked thread which is terminated afterwards instead of being reused,
+// so switching the network namespace of the thread doesn't affect the others
+func onLockedThread(f func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		errCh <- f()
+	}()
+	return <-errCh
+}
+
+func inNetns(ns netns.NsHandle, f func() error) error {
+	return onLockedThread(func() error {
+		if err := netns.Set(ns); err != nil {
+			return err
+		}
+		return f()
+	})
+}
+
+func newNetns(t *testing.T) netns.NsHandle {
+	var ns netns.NsHandle
+	if err := onLockedThread(func() (err error) {
+		ns, err = netns.New()
+		return err
+	}); err != nil {
+		t.Skipf("create network namespace failed, error: %s", err.Error())
+	}
+	t.Cleanup(func() { _ = ns.Close() })
+	return ns
+}
+
+// setupNode creates the bridge in the namespace and attaches the uplink with the VLANs allowed
+func setupNode(ns netns.NsHandle, uplinkName string, uplinkVIDs ...uint16) error {
+	return inNetns(ns, func() error {
+		br := iface.NewBridge(testBridgeName)
+		if err := br.Ensure(); err != nil {
+			return err
+		}
+		l, err := netlink.LinkByName(uplinkName)
+		if err != nil {
+			return err
+		}
+		uplink := iface.NewLink(l)
+		if err := uplink.SetMaster(br); err != nil {
+			return err
+		}
+		for _, vid := range uplinkVIDs {
+			if err := uplink.AddBridgeVlan(vid); err != nil {
+				return err
+			}
+		}
+		return netlink.LinkSetUp(uplink)
+	})
+}
+
+// setupNodes creates two nodes in the network namespaces whose uplinks are connected by a veth pair,
+// the uplink of node2 doesn't allow vlan 200
+func setupNodes(t *testing.T) (netns.NsHandle, netns.NsHandle) {
+	ns1, ns2 := newNetns(t), newNetns(t)
+
+	if err := inNetns(ns1, func() error {
+		if err := netlink.LinkAdd(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: testUplinkName},
+			PeerName:  testUplinkPeer,
+		}); err != nil {
+			return err
+		}
+		peer, err := netlink.LinkByName(testUplinkPeer)
+		if err != nil {
+			return err
+		}
+		return netlink.LinkSetNsFd(peer, int(ns2))
+	}); err != nil {
+		t.Skipf("create uplinks failed, error: %s", err.Error())
+	}
+
+	if err := setupNode(ns1, testUplinkName, 100, 200); err != nil {
+		if errors.Is(err, syscall.EOPNOTSUPP) {
+			t.Skipf("bridge vlan filtering isn't supported, error: %s", err.Error())
+		}
+		t.Fatal(err)
+	}
+	if err := setupNode(ns2, testUplinkPeer, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	return ns1, ns2
+}
+
+func runSession(ctx context.Context, ns netns.NsHandle, cfg *SessionConfig) ([]Result, error) {
+	var results []Result
+	err := inNetns(ns, func() error {
+		br := iface.NewBridge(testBridgeName)
+		if err := br.Fetch(); err != nil {
+			return err
+		}
+		if err := iface.EnsureProbeEndpoint(br, testEndpointName, testPortName, cfg.VIDs); err != nil {
+			return err
+		}
+		defer func() { _ = iface.RemoveProbeEndpoint(testEndpointName) }()
+
+		var err error
+		results, err = RunSession(ctx, cfg)
+		return err
+	})
+	return results, err
+}
+
+func TestRunSession(t *testing.T) {
+	ns1, ns2 := setupNodes(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testWaitTimeout)
+	defer cancel()
+
+	newConfig := func(node, peer string) *SessionConfig {
+		return &SessionConfig{
+			ClusterNetwork: testCnName,
+			Node:           node,
+			IfName:         testEndpointName,
+			VIDs:           []uint16{100, 200},
+			Peers:          []string{peer},
+			Count:          3,
+			Settle:         300 * time.Millisecond,
+			Gap:            50 * time.Millisecond,
+			Wait:           300 * time.Millisecond,
+		}
+	}
+
+	type output struct {
+		results []Result
+		err     error
+	}
+	outputs := make([]chan output, 2)
+	for i, ns := range []netns.NsHandle{ns1, ns2} {
+		cfg := newConfig("node1", "node2")
+		if i == 1 {
+			cfg = newConfig("node2", "node1")
+		}
+		outputs[i] = make(chan output, 1)
+		go func(ch chan output) {
+			results, err := runSession(ctx, ns, cfg)
+			ch <- output{results: results, err: err}
+		}(outputs[i])
+	}
+
+	for i, peer := range []string{"node2", "node1"} {
+		out := <-outputs[i]
+		if !assert.NoError(t, out.err) || !assert.Len(t, out.results, 2) {
+			continue
+		}
+
+		vlan100, vlan200 := out.results[0], out.results[1]
+		assert.Equal(t, uint16(100), vlan100.VID)
+		assert.Equal(t, peer, vlan100.Node)
+		assert.Equal(t, 3, vlan100.Sent)
+		assert.Equal(t, 3, vlan100.Received)
+		assert.Equal(t, 0, vlan100.LossPercent())
+		assert.Positive(t, vlan100.AverageRTT)
+
+		// vlan 200 isn't allowed by the uplink of node2
+		assert.Equal(t, uint16(200), vlan200.VID)
+		assert.Equal(t, peer, vlan200.Node)
+		assert.Equal(t, 0, vlan200.Received)
+		assert.Equal(t, 100, vlan200.LossPercent())
+		assert.Zero(t, vlan200.AverageRTT)
+	}
+}
+
+func TestResultLossPercent(t *testing.T) {
+	tests := []struct {
+		name   string
+		result Result
+		want   int
+	}{
+		{name: "nothing sent", result: Result{}, want: 0},
+		{name: "all received", result: Result{Sent: 5, Received: 5}, want: 0},
+		{name: "partially received", result: Result{Sent: 5, Received: 3}, want: 40},
+		{name: "nothing received", result: Result{Sent: 5}, want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.result.LossPercent())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Package resolvconf manages the DNS settings of the host networks in the resolv.conf of the node. The settings are
+// kept in a section of the file which is rewritten on every update, the other lines of the file are left untouched.
+package resolvconf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	sectionBegin    = "# BEGIN harvester host networks"
+	sectionEnd      = "# END harvester host networks"
+	interfacePrefix = "# interface "
+	searchPrefix    = "# search "
+
+	keyNameserver = "nameserver"
+	keySearch     = "search"
+)
+
+// Config is the DNS settings of a host network interface
+type Config struct {
+	Nameservers []string
+	Searches    []string
+}
+
+func (c *Config) isEmpty() bool {
+	return c == nil || (len(c.Nameservers) == 0 && len(c.Searches) == 0)
+}
+
+// the lease managers and the controller of the host networks update the file concurrently
+var mu sync.Mutex
+
+// Update sets the DNS settings of the interface in the resolv.conf, and removes them if the config is empty.
+// It does nothing if the path is empty.
+func Update(path, ifName string, cfg *Config) error {
+	if path == "" {
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read %s failed, error: %w", path, err)
+	}
+
+	base, configs := parse(string(data))
+	if cfg.isEmpty() {
+		if _, ok := configs[ifName]; !ok {
+			return nil
+		}
+		delete(configs, ifName)
+	} else {
+		configs[ifName] = cfg
+	}
+
+	content := render(base, configs)
+	if content == string(data) {
+		return nil
+	}
+
+	// the file is written in place rather than renamed because it may be bind-mounted from the host
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("write %s failed, error: %w", path, err)
+	}
+
+	return nil
+}
+
+// parse splits the file into the lines out of the managed section and the DNS settings of each interface in it
+func parse(data string) ([]string, map[string]*Config) {
+	var base []string
+	configs := make(map[string]*Config)
+
+	inSection := false
+	var current *Config
+	for _, line := range strings.Split(strings.TrimRight(data, "\n"), "\n") {
+		switch {
+		case line == sectionBegin:
+			inSection = true
+		case line == sectionEnd:
+			inSection, current = false, nil
+		case !inSection:
+			if line != "" || len(base) > 0 {
+				base = append(base, line)
+			}
+		case strings.HasPrefix(line, interfacePrefix):
+			current = &Config{}
+			configs[strings.TrimPrefix(line, interfacePrefix)] = current
+		case current == nil:
+			// the merged search line doesn't belong to any interface
+		case strings.HasPrefix(line, searchPrefix):
+			current.Searches = strings.Fields(strings.TrimPrefix(line, searchPrefix))
+		case strings.HasPrefix(line, keyNameserver+" "):
+			current.Nameservers = append(current.Nameservers, strings.TrimSpace(strings.TrimPrefix(line, keyNameserver)))
+		}
+	}
+
+	return base, configs
+}
+
+// render appends the managed section to the lines out of it. The resolver only takes the last search line, so the
+// search domains of the interfaces are merged with the original ones into a search line at the end of the section.
+func render(base []string, configs map[string]*Config) string {
+	lines := append([]string{}, base...)
+	if len(configs) == 0 {
+		if len(lines) == 0 {
+			return ""
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	ifNames := make([]string, 0, len(configs))
+	for ifName := range configs {
+		ifNames = append(ifNames, ifName)
+	}
+	sort.Strings(ifNames)
+
+	searches := newOrderedSet(originalSearches(base)...)
+	managedSearches := false
+
+	lines = append(lines, sectionBegin)
+	for _, ifName := range ifNames {
+		cfg := configs[ifName]
+		lines = append(lines, interfacePrefix+ifName)
+		for _, ns := range cfg.Nameservers {
+			lines = append(lines, keyNameserver+" "+ns)
+		}
+		if len(cfg.Searches) > 0 {
+			lines = append(lines, searchPrefix+strings.Join(cfg.Searches, " "))
+			searches.add(cfg.Searches...)
+			managedSearches = true
+		}
+	}
+	if managedSearches {
+		lines = append(lines, keySearch+" "+strings.Join(searches.items, " "))
+	}
+	lines = append(lines, sectionEnd)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func originalSearches(base []string) []string {
+	var searches []string
+	for _, line := range base {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == keySearch {
+			searches = fields[1:]
+		}
+	}
+	return searches
+}
+
+type orderedSet struct {
+	items []string
+	seen  map[string]bool
+}
+
+func newOrderedSet(items ...string) *orderedSet {
+	s := &orderedSet{seen: make(map[string]bool)}
+	s.add(items...)
+	return s
+}
+
+func (s *orderedSet) add(items ...string) {
+	for _, item := range items {
+		if !s.seen[item] {
+			s.seen[item] = true
+			s.items = append(s.items, item)
+		}
+	}
+}
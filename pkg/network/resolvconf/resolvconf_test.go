@@ -0,0 +1,77 @@
+package resolvconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const original = `# Generated by NetworkManager
+search example.com
+nameserver 10.0.0.53
+`
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	assert.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+	read := func() string {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		return string(data)
+	}
+
+	assert.NoError(t, Update(path, "storage-br.100", &Config{
+		Nameservers: []string{"172.16.100.53"},
+		Searches:    []string{"storage.local"},
+	}))
+	assert.NoError(t, Update(path, "backup-br.200", &Config{
+		Nameservers: []string{"172.16.200.53", "172.16.200.54"},
+	}))
+	assert.Equal(t, original+`# BEGIN harvester host networks
+# interface backup-br.200
+nameserver 172.16.200.53
+nameserver 172.16.200.54
+# interface storage-br.100
+nameserver 172.16.100.53
+# search storage.local
+search example.com storage.local
+# END harvester host networks
+`, read())
+
+	// updating the settings of an interface keeps the others
+	assert.NoError(t, Update(path, "storage-br.100", &Config{
+		Nameservers: []string{"172.16.100.54"},
+		Searches:    []string{"example.com", "replica.local"},
+	}))
+	assert.Equal(t, original+`# BEGIN harvester host networks
+# interface backup-br.200
+nameserver 172.16.200.53
+nameserver 172.16.200.54
+# interface storage-br.100
+nameserver 172.16.100.54
+# search example.com replica.local
+search example.com replica.local
+# END harvester host networks
+`, read())
+
+	assert.NoError(t, Update(path, "storage-br.100", nil))
+	assert.Equal(t, original+`# BEGIN harvester host networks
+# interface backup-br.200
+nameserver 172.16.200.53
+nameserver 172.16.200.54
+# END harvester host networks
+`, read())
+
+	// the file is restored once all the settings are removed
+	assert.NoError(t, Update(path, "backup-br.200", &Config{}))
+	assert.Equal(t, original, read())
+	assert.NoError(t, Update(path, "absent-br.300", nil))
+	assert.Equal(t, original, read())
+}
+
+func TestUpdateWithoutPath(t *testing.T) {
+	assert.NoError(t, Update("", "storage-br.100", &Config{Nameservers: []string{"172.16.100.53"}}))
+}
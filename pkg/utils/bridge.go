@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"hash/crc32"
 	"strings"
 )
 
@@ -13,6 +14,13 @@ const (
 	LenOfBridgeSuffix = 3 // length of BridgeSuffix
 	LenOfBondSuffix   = 3 // length of BondSuffix
 
+	ProbeEndpointSuffix = "-pr"
+	ProbePortSuffix     = "-pp"
+	LenOfProbeSuffix    = 3 // length of ProbeEndpointSuffix and ProbePortSuffix
+
+	GatewayEndpointSuffix = "-gw"
+	GatewayPortSuffix     = "-gp"
+
 	MaxDeviceNameLen = 15
 
 	VlanSubInterfaceSpliter = "."
@@ -111,3 +119,27 @@ func IsHostNetworkIntfNameValid(cn string, vlanid uint16) error {
 
 	return nil
 }
+
+// GenerateL2ReachabilityProbePrefix returns the prefix of the probe endpoint of the L2Reachability, the
+// L2Reachabilities on the same cluster network probe at the same time with the endpoints apart by the name checksum
+func GenerateL2ReachabilityProbePrefix(l2rName string) string {
+	return fmt.Sprintf("l2r%08x", crc32.ChecksumIEEE([]byte(l2rName)))
+}
+
+// the veth pair of the probe endpoint, e.g. cn2-pr is the endpoint and cn2-pp is the bridge port
+func GenerateProbeEndpointName(prefix string) string {
+	return generateName(prefix, ProbeEndpointSuffix, LenOfProbeSuffix)
+}
+
+func GenerateProbePortName(prefix string) string {
+	return generateName(prefix, ProbePortSuffix, LenOfProbeSuffix)
+}
+
+// the veth pair of the gateway check endpoint, e.g. cn2-gw is the endpoint and cn2-gp is the bridge port
+func GenerateGatewayEndpointName(prefix string) string {
+	return generateName(prefix, GatewayEndpointSuffix, LenOfProbeSuffix)
+}
+
+func GenerateGatewayPortName(prefix string) string {
+	return generateName(prefix, GatewayPortSuffix, LenOfProbeSuffix)
+}
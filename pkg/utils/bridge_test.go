@@ -37,6 +37,11 @@ func TestBridgeRelatedFunctions(t *testing.T) {
 			assert.False(t, HasClusterNetworkDevicePrefix("cn22-br.", prefix))
 			assert.False(t, HasClusterNetworkDevicePrefix("cn22-br.2025", prefix))
 			assert.False(t, HasClusterNetworkDevicePrefix("cn22.2025", prefix))
+
+			l2rPrefix := GenerateL2ReachabilityProbePrefix("cn2-check")
+			assert.NotEqual(t, l2rPrefix, GenerateL2ReachabilityProbePrefix("cn2-check2"))
+			assert.LessOrEqual(t, len(GenerateProbeEndpointName(l2rPrefix)), MaxDeviceNameLen)
+			assert.Equal(t, l2rPrefix+ProbeEndpointSuffix, GenerateProbeEndpointName(l2rPrefix))
 		})
 	}
 }
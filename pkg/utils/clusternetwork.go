@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
@@ -43,3 +44,100 @@ func GetClusterNetworkName(iface string) (string, error) {
 	}
 	return cnName, nil
 }
+
+// GetMTUFromClusterNetwork returns the uplink MTU of the given cluster network.
+// The legacy annotation KeyUplinkMTU takes precedence over the spec until it is
+// migrated by the controller. It returns 0 if no MTU is set.
+func GetMTUFromClusterNetwork(cn *networkv1.ClusterNetwork) (int, error) {
+	if cn == nil {
+		return 0, nil
+	}
+	if mtuStr, ok := cn.Annotations[KeyUplinkMTU]; ok {
+		MTU, err := GetMTUFromString(mtuStr)
+		if err != nil {
+			return 0, fmt.Errorf("cluster network %v has invalid MTU annotation %v/%v %w", cn.Name, KeyUplinkMTU, mtuStr, err)
+		}
+		return MTU, nil
+	}
+	if !IsValidMTU(cn.Spec.MTU) {
+		return 0, fmt.Errorf("cluster network %v has MTU %v which is not in range [0, %v..%v]", cn.Name, cn.Spec.MTU, MinMTU, MaxMTU)
+	}
+	return cn.Spec.MTU, nil
+}
+
+// MigrateClusterNetworkMTUAnnotation moves the legacy MTU annotation to the spec,
+// it returns true if the cluster network is changed.
+func MigrateClusterNetworkMTUAnnotation(cn *networkv1.ClusterNetwork) (bool, error) {
+	if cn == nil || cn.Annotations == nil {
+		return false, nil
+	}
+	if _, ok := cn.Annotations[KeyUplinkMTU]; !ok {
+		return false, nil
+	}
+	MTU, err := GetMTUFromClusterNetwork(cn)
+	if err != nil {
+		return false, err
+	}
+	cn.Spec.MTU = MTU
+	delete(cn.Annotations, KeyUplinkMTU)
+	return true, nil
+}
+
+func IsVlanRangeValid(r networkv1.VlanRange) error {
+	if r.Start < MinTrunkVlanID || r.Start > MaxVlanID {
+		return fmt.Errorf("vlan range start %v is out of range [%v .. %v]", r.Start, MinTrunkVlanID, MaxVlanID)
+	}
+	if r.End == 0 {
+		return nil
+	}
+	if r.End > MaxVlanID {
+		return fmt.Errorf("vlan range end %v is out of range [%v .. %v]", r.End, MinTrunkVlanID, MaxVlanID)
+	}
+	if r.End < r.Start {
+		return fmt.Errorf("vlan range start %v is greater than end %v", r.Start, r.End)
+	}
+	return nil
+}
+
+func vlanRangeEnd(r networkv1.VlanRange) uint16 {
+	if r.End == 0 {
+		return r.Start
+	}
+	return r.End
+}
+
+// IsVlanIDAllowed checks if the vid is covered by the allowed VLAN ranges of the cluster network,
+// the untagged vid 0 and the default vid 1 are always allowed
+func IsVlanIDAllowed(cn *networkv1.ClusterNetwork, vid uint16) bool {
+	if cn == nil || len(cn.Spec.AllowedVlanRanges) == 0 || vid <= DefaultVlanID {
+		return true
+	}
+	for _, r := range cn.Spec.AllowedVlanRanges {
+		if vid >= r.Start && vid <= vlanRangeEnd(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckVlansAllowedOnClusterNetwork ensures all vids of the nad are allowed on the cluster network
+func CheckVlansAllowedOnClusterNetwork(cn *networkv1.ClusterNetwork, nc *NetConf) error {
+	if cn == nil || nc == nil || len(cn.Spec.AllowedVlanRanges) == 0 || !nc.IsBridgeCNI() {
+		return nil
+	}
+	vis, err := nc.dumpVlanIDSet()
+	if err != nil {
+		return err
+	}
+	var notAllowed []string
+	_ = vis.WalkVIDs("allowed vlan ranges", func(vid uint16) error {
+		if !IsVlanIDAllowed(cn, vid) {
+			notAllowed = append(notAllowed, strconv.Itoa(int(vid)))
+		}
+		return nil
+	})
+	if len(notAllowed) > 0 {
+		return fmt.Errorf("vlan(s) %v are not in the allowed vlan ranges of cluster network %v", strings.Join(notAllowed, VlanIDStringJoinChar), cn.Name)
+	}
+	return nil
+}
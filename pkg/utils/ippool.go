@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// IPPool allocates the IPs of the nodes from the pool of a host network config
+type IPPool struct {
+	prefix   netip.Prefix
+	start    netip.Addr
+	end      netip.Addr
+	excludes []netip.Prefix
+}
+
+func NewIPPool(pool *networkv1.IPPool) (*IPPool, error) {
+	if pool == nil {
+		return nil, fmt.Errorf("pool is not specified")
+	}
+
+	prefix, err := netip.ParsePrefix(string(pool.CIDR))
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool cidr %s: %w", pool.CIDR, err)
+	}
+	if !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("pool cidr %s is not an IPv4 subnet", pool.CIDR)
+	}
+	prefix = prefix.Masked()
+
+	p := &IPPool{
+		prefix: prefix,
+		start:  prefix.Addr(),
+		end:    lastAddr(prefix),
+	}
+	// the network and the broadcast addresses can't be assigned to the hosts
+	if prefix.Bits() < 31 {
+		p.start, p.end = p.start.Next(), p.end.Prev()
+	}
+
+	if pool.RangeStart != "" {
+		if p.start, err = p.parseRangeIP(pool.RangeStart); err != nil {
+			return nil, err
+		}
+	}
+	if pool.RangeEnd != "" {
+		if p.end, err = p.parseRangeIP(pool.RangeEnd); err != nil {
+			return nil, err
+		}
+	}
+	if p.end.Less(p.start) {
+		return nil, fmt.Errorf("pool range start %s is after the range end %s", p.start, p.end)
+	}
+
+	for _, exclude := range pool.Exclude {
+		excludePrefix, err := parseExclude(exclude)
+		if err != nil {
+			return nil, err
+		}
+		p.excludes = append(p.excludes, excludePrefix)
+	}
+
+	return p, nil
+}
+
+func (p *IPPool) parseRangeIP(s string) (netip.Addr, error) {
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid pool range ip %s: %w", s, err)
+	}
+	if !p.prefix.Contains(ip) {
+		return netip.Addr{}, fmt.Errorf("pool range ip %s is not in the pool cidr %s", s, p.prefix)
+	}
+	return ip, nil
+}
+
+// parseExclude accepts either an IP or a subnet
+func parseExclude(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid pool exclude %s: %w", s, err)
+		}
+		return prefix.Masked(), nil
+	}
+
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid pool exclude %s: %w", s, err)
+	}
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+// Prefix returns the subnet of the pool
+func (p *IPPool) Prefix() netip.Prefix {
+	return p.prefix
+}
+
+// Contains tells whether the IP can be allocated from the pool
+func (p *IPPool) Contains(ip netip.Addr) bool {
+	if !p.prefix.Contains(ip) || ip.Less(p.start) || p.end.Less(ip) {
+		return false
+	}
+	return p.excludedBy(ip) == nil
+}
+
+func (p *IPPool) excludedBy(ip netip.Addr) *netip.Prefix {
+	for i := range p.excludes {
+		if p.excludes[i].Contains(ip) {
+			return &p.excludes[i]
+		}
+	}
+	return nil
+}
+
+// Allocate returns the IPs of the nodes in CIDR notation. The IPs allocated before are kept as long as they are still
+// in the pool, and the other nodes are allocated the lowest free IPs in the order of the node names. The allocations
+// are returned together with an error if the pool is exhausted.
+func (p *IPPool) Allocate(allocated map[string]networkv1.IPAddr, nodes []string) (map[string]networkv1.IPAddr, error) {
+	sortedNodes := append([]string{}, nodes...)
+	sort.Strings(sortedNodes)
+
+	allocations := make(map[string]networkv1.IPAddr, len(sortedNodes))
+	used := make(map[netip.Addr]bool, len(sortedNodes))
+	var pending []string
+	for _, node := range sortedNodes {
+		prefix, err := netip.ParsePrefix(string(allocated[node]))
+		if err == nil && prefix.Bits() == p.prefix.Bits() && p.Contains(prefix.Addr()) && !used[prefix.Addr()] {
+			allocations[node] = allocated[node]
+			used[prefix.Addr()] = true
+			continue
+		}
+		pending = append(pending, node)
+	}
+
+	ip := p.start
+	for i, node := range pending {
+		for ; ip.IsValid() && !p.end.Less(ip); ip = ip.Next() {
+			if exclude := p.excludedBy(ip); exclude != nil {
+				// skip the whole excluded subnet
+				ip = lastAddr(*exclude)
+				continue
+			}
+			if !used[ip] {
+				break
+			}
+		}
+		if !ip.IsValid() || p.end.Less(ip) {
+			return allocations, fmt.Errorf("pool %s is exhausted, %d of %d nodes are not allocated an IP", p.prefix, len(pending)-i, len(sortedNodes))
+		}
+
+		allocations[node] = networkv1.IPAddr(netip.PrefixFrom(ip, p.prefix.Bits()).String())
+		used[ip] = true
+	}
+
+	return allocations, nil
+}
+
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Masked().Addr().AsSlice()
+	for i := prefix.Bits(); i < len(bytes)*8; i++ {
+		bytes[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestNewIPPool(t *testing.T) {
+	tests := []struct {
+		name   string
+		pool   *networkv1.IPPool
+		errKey string
+	}{
+		{
+			name: "valid pool with range and excludes",
+			pool: &networkv1.IPPool{
+				CIDR:       "172.16.0.0/24",
+				RangeStart: "172.16.0.10",
+				RangeEnd:   "172.16.0.100",
+				Exclude:    []string{"172.16.0.20", "172.16.0.32/28"},
+			},
+		},
+		{
+			name:   "ipv6 pool",
+			pool:   &networkv1.IPPool{CIDR: "fd00::/64"},
+			errKey: "is not an IPv4 subnet",
+		},
+		{
+			name:   "range start out of the cidr",
+			pool:   &networkv1.IPPool{CIDR: "172.16.0.0/24", RangeStart: "172.16.1.10"},
+			errKey: "is not in the pool cidr",
+		},
+		{
+			name:   "range start after the range end",
+			pool:   &networkv1.IPPool{CIDR: "172.16.0.0/24", RangeStart: "172.16.0.100", RangeEnd: "172.16.0.10"},
+			errKey: "is after the range end",
+		},
+		{
+			name:   "invalid exclude",
+			pool:   &networkv1.IPPool{CIDR: "172.16.0.0/24", Exclude: []string{"172.16.0"}},
+			errKey: "invalid pool exclude",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewIPPool(tc.pool)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
+
+func TestIPPoolAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		pool      *networkv1.IPPool
+		allocated map[string]networkv1.IPAddr
+		nodes     []string
+		expected  map[string]networkv1.IPAddr
+		errKey    string
+	}{
+		{
+			name:  "allocate the lowest IPs in the order of the node names",
+			pool:  &networkv1.IPPool{CIDR: "172.16.0.0/24"},
+			nodes: []string{"node2", "node1"},
+			expected: map[string]networkv1.IPAddr{
+				"node1": "172.16.0.1/24",
+				"node2": "172.16.0.2/24",
+			},
+		},
+		{
+			name:      "keep the allocated IPs and release the IPs of the absent nodes",
+			pool:      &networkv1.IPPool{CIDR: "172.16.0.0/24"},
+			allocated: map[string]networkv1.IPAddr{"node2": "172.16.0.1/24", "node3": "172.16.0.2/24"},
+			nodes:     []string{"node1", "node2"},
+			expected: map[string]networkv1.IPAddr{
+				"node1": "172.16.0.2/24",
+				"node2": "172.16.0.1/24",
+			},
+		},
+		{
+			name: "skip the excluded IPs and subnets",
+			pool: &networkv1.IPPool{
+				CIDR:       "172.16.0.0/24",
+				RangeStart: "172.16.0.10",
+				Exclude:    []string{"172.16.0.10", "172.16.0.12/31"},
+			},
+			nodes: []string{"node1", "node2", "node3"},
+			expected: map[string]networkv1.IPAddr{
+				"node1": "172.16.0.11/24",
+				"node2": "172.16.0.14/24",
+				"node3": "172.16.0.15/24",
+			},
+		},
+		{
+			name:      "reallocate the IPs out of the pool",
+			pool:      &networkv1.IPPool{CIDR: "172.16.0.0/24", Exclude: []string{"172.16.0.1"}},
+			allocated: map[string]networkv1.IPAddr{"node1": "172.16.0.1/24", "node2": "172.16.1.2/24"},
+			nodes:     []string{"node1", "node2"},
+			expected: map[string]networkv1.IPAddr{
+				"node1": "172.16.0.2/24",
+				"node2": "172.16.0.3/24",
+			},
+		},
+		{
+			name:  "pool exhausted",
+			pool:  &networkv1.IPPool{CIDR: "172.16.0.0/30"},
+			nodes: []string{"node1", "node2", "node3"},
+			expected: map[string]networkv1.IPAddr{
+				"node1": "172.16.0.1/30",
+				"node2": "172.16.0.2/30",
+			},
+			errKey: "1 of 3 nodes are not allocated an IP",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool, err := NewIPPool(tc.pool)
+			assert.NoError(t, err)
+
+			allocations, err := pool.Allocate(tc.allocated, tc.nodes)
+			assert.Equal(t, tc.expected, allocations)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ const (
 	KeyNetworkRouteSourceVID = network.GroupName + "/route-source-vid" // the source vid of this route
 	KeyMTUSourceVlanConfig   = network.GroupName + "/mtu-source-vc"    // the VC which syncs MTU to CN
 	KeyUplinkMTU             = network.GroupName + "/uplink-mtu"       // configured MTU on the VC'uplink
+	KeyL2Reachability        = network.GroupName + "/l2reachability"   // "true" to probe the VLANs of the CN by default
+	KeyL3DiscoveryJob        = network.GroupName + "/l3-discovery-job" // "true" on the mgmt CN if the manager's jobs discover the layer 3 networks
 
 	KeyMatchedNodes = network.GroupName + "/matched-nodes"
 
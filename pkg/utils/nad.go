@@ -32,6 +32,9 @@ const (
 	Unconnectable Connectivity = "false"
 	DHCPFailed    Connectivity = "DHCP failed"
 	PingFailed    Connectivity = "ping failed"
+
+	// MultipleDHCPServers means the DHCP servers answering on the VLAN disagree or are not in the allow-list
+	MultipleDHCPServers Connectivity = "multiple DHCP servers"
 )
 
 type Mode string
@@ -61,6 +64,41 @@ type Layer3NetworkConf struct {
 	ServerIPAddr string       `json:"serverIPAddr,omitempty"`
 	Connectivity Connectivity `json:"connectivity,omitempty"`
 	Outdated     bool         `json:"outdated,omitempty"`
+	// IPv6Prefix and IPv6Gateway are the IPv6 subnet and the default router of the dual-stack or IPv6 only networks,
+	// the gateway is usually the link-local address of the router
+	IPv6Prefix  string `json:"ipv6Prefix,omitempty"`
+	IPv6Gateway string `json:"ipv6Gateway,omitempty"`
+	// NodeConnectivity is the connectivity to the gateway checked from the VLAN on each node, key = node name
+	NodeConnectivity map[string]Connectivity `json:"nodeConnectivity,omitempty"`
+	// CheckedGateway is the gateway which the node connectivity is checked against
+	CheckedGateway string `json:"checkedGateway,omitempty"`
+
+	// the following settings are obtained from the DHCP server in the auto mode, so that the guests of the network
+	// could be configured accordingly
+	DNSServers []string `json:"dnsServers,omitempty"`
+	DomainName string   `json:"domainName,omitempty"`
+	NTPServers []string `json:"ntpServers,omitempty"`
+	// MTU is the interface MTU of option 26
+	MTU int `json:"mtu,omitempty"`
+	// Routes are the classless static routes of option 121
+	Routes []StaticRoute `json:"routes,omitempty"`
+	// ServerIdentifier is the identifier of the DHCP server answering the discovery
+	ServerIdentifier string `json:"serverIdentifier,omitempty"`
+	// DHCPServers are all the DHCP servers answering the discovery on the VLAN
+	DHCPServers []DHCPServer `json:"dhcpServers,omitempty"`
+}
+
+// DHCPServer is a DHCP server answering the discovery and the network it offers
+type DHCPServer struct {
+	ServerIdentifier string `json:"serverIdentifier"`
+	CIDR             string `json:"cidr,omitempty"`
+	Gateway          string `json:"gateway,omitempty"`
+}
+
+// StaticRoute is a route of the network, the destination is on the link if the gateway is empty
+type StaticRoute struct {
+	Destination string `json:"destination"`
+	Gateway     string `json:"gateway,omitempty"`
 }
 
 func NewLayer3NetworkConf(conf string) (*Layer3NetworkConf, error) {
@@ -74,24 +112,71 @@ func NewLayer3NetworkConf(conf string) (*Layer3NetworkConf, error) {
 		return nil, fmt.Errorf("unmarshal %s faield, error: %w", conf, err)
 	}
 
-	// validate
-	if networkConf.Mode != "" && networkConf.Mode != Auto && networkConf.Mode != Manual {
-		return nil, fmt.Errorf("unknown mode %s", networkConf.Mode)
+	if err := networkConf.validate(); err != nil {
+		return nil, err
+	}
+
+	return networkConf, nil
+}
+
+// validate checks the mode, the network of the manual mode and the DHCP servers of the auto mode
+func (c *Layer3NetworkConf) validate() error {
+	if c.Mode != "" && c.Mode != Auto && c.Mode != Manual {
+		return fmt.Errorf("unknown mode %s", c.Mode)
 	}
 
-	// validate cidr and gateway when the mode is manual
-	if networkConf.Mode == Manual {
-		_, ipnet, err := net.ParseCIDR(networkConf.CIDR)
-		if err != nil || (ipnet != nil && isMaskZero(ipnet)) {
-			return nil, fmt.Errorf("the CIDR %s is invalid", networkConf.CIDR)
+	// validate cidr and gateway when the mode is manual, the IPv4 ones can be omitted in the IPv6 only networks
+	if c.Mode == Manual {
+		if c.CIDR != "" || c.Gateway != "" || c.IPv6Prefix == "" {
+			_, ipnet, err := net.ParseCIDR(c.CIDR)
+			if err != nil || (ipnet != nil && isMaskZero(ipnet)) {
+				return fmt.Errorf("the CIDR %s is invalid", c.CIDR)
+			}
+
+			if net.ParseIP(c.Gateway) == nil {
+				return fmt.Errorf("the gateway %s is invalid", c.Gateway)
+			}
+		}
+
+		if err := validateIPv6Network(c.IPv6Prefix, c.IPv6Gateway); err != nil {
+			return err
 		}
+	}
 
-		if net.ParseIP(networkConf.Gateway) == nil {
-			return nil, fmt.Errorf("the gateway %s is invalid", networkConf.Gateway)
+	// the server address is a comma separated allow-list of the DHCP servers
+	for _, server := range splitServerIPAddr(c.ServerIPAddr) {
+		if net.ParseIP(server).To4() == nil {
+			return fmt.Errorf("the DHCP server address %s is invalid", server)
 		}
 	}
 
-	return networkConf, nil
+	return nil
+}
+
+// validateIPv6Network validates the IPv6 prefix and gateway of the manual mode, the gateway is either a link-local
+// address or an address in the prefix
+func validateIPv6Network(prefix, gateway string) error {
+	if prefix == "" {
+		if gateway != "" {
+			return fmt.Errorf("the IPv6 gateway %s is set without the IPv6 prefix", gateway)
+		}
+		return nil
+	}
+
+	ip, ipnet, err := net.ParseCIDR(prefix)
+	if err != nil || ip.To4() != nil || isMaskZero(ipnet) {
+		return fmt.Errorf("the IPv6 prefix %s is invalid", prefix)
+	}
+
+	gw := net.ParseIP(gateway)
+	if gw == nil || gw.To4() != nil || gw.IsUnspecified() || gw.IsMulticast() {
+		return fmt.Errorf("the IPv6 gateway %s is invalid", gateway)
+	}
+	if !gw.IsLinkLocalUnicast() && !ipnet.Contains(gw) {
+		return fmt.Errorf("the IPv6 gateway %s is not in the prefix %s", gateway, prefix)
+	}
+
+	return nil
 }
 
 func OutdateLayer3NetworkConfPerMode(conf string) (string, error) {
@@ -207,21 +292,8 @@ func NewLayer3NetworkConfFromNad(nad *nadv1.NetworkAttachmentDefinition) (*Layer
 		return nil, fmt.Errorf("unmarshal nad %v/%v annotation %v %s faield, error: %w", nad.Namespace, nad.Name, KeyNetworkRoute, routeStr, err)
 	}
 
-	// validate
-	if networkConf.Mode != "" && networkConf.Mode != Auto && networkConf.Mode != Manual {
-		return nil, fmt.Errorf("unknown mode %s", networkConf.Mode)
-	}
-
-	// validate cidr and gateway when the mode is manual
-	if networkConf.Mode == Manual {
-		_, ipnet, err := net.ParseCIDR(networkConf.CIDR)
-		if err != nil || (ipnet != nil && isMaskZero(ipnet)) {
-			return nil, fmt.Errorf("the CIDR %s is invalid", networkConf.CIDR)
-		}
-
-		if net.ParseIP(networkConf.Gateway) == nil {
-			return nil, fmt.Errorf("the gateway %s is invalid", networkConf.Gateway)
-		}
+	if err := networkConf.validate(); err != nil {
+		return nil, err
 	}
 
 	return networkConf, nil
@@ -269,6 +341,74 @@ func (c *Layer3NetworkConf) ToString() (string, error) {
 	return string(bytes), nil
 }
 
+// SetDHCPSettings copies the settings obtained from the DHCP server, the settings missing in the source are cleared
+func (c *Layer3NetworkConf) SetDHCPSettings(from *Layer3NetworkConf) {
+	c.CIDR = from.CIDR
+	c.Gateway = from.Gateway
+	c.IPv6Prefix = from.IPv6Prefix
+	c.IPv6Gateway = from.IPv6Gateway
+	c.DNSServers = from.DNSServers
+	c.DomainName = from.DomainName
+	c.NTPServers = from.NTPServers
+	c.MTU = from.MTU
+	c.Routes = from.Routes
+	c.ServerIdentifier = from.ServerIdentifier
+	c.DHCPServers = from.DHCPServers
+}
+
+// GetNodeConnectivity returns the connectivity checked on the node, it's empty if the current gateway is not checked
+// on the node yet
+func (c *Layer3NetworkConf) GetNodeConnectivity(node string) Connectivity {
+	if c.CheckedGateway != c.Gateway {
+		return ""
+	}
+	return c.NodeConnectivity[node]
+}
+
+// SetNodeConnectivity records the connectivity checked on the node and updates the connectivity of the network, the
+// node is removed if the connectivity is empty. The results of the other nodes are dropped if they are checked against
+// another gateway.
+func (c *Layer3NetworkConf) SetNodeConnectivity(node string, connectivity Connectivity) {
+	if c.CheckedGateway != c.Gateway {
+		c.NodeConnectivity = nil
+		c.CheckedGateway = c.Gateway
+	}
+	if connectivity == "" {
+		delete(c.NodeConnectivity, node)
+	} else {
+		if c.NodeConnectivity == nil {
+			c.NodeConnectivity = make(map[string]Connectivity)
+		}
+		c.NodeConnectivity[node] = connectivity
+	}
+	if len(c.NodeConnectivity) == 0 {
+		c.NodeConnectivity = nil
+		c.CheckedGateway = ""
+	}
+
+	c.Connectivity = aggregateConnectivity(c.NodeConnectivity)
+}
+
+// aggregateConnectivity returns the connectivity of the network, it's unconnectable if the gateway is unconnectable
+// from any node
+func aggregateConnectivity(nodeConnectivity map[string]Connectivity) Connectivity {
+	if len(nodeConnectivity) == 0 {
+		return ""
+	}
+
+	connectivity := PingFailed
+	for _, c := range nodeConnectivity {
+		switch c {
+		case Unconnectable:
+			return Unconnectable
+		case Connectable:
+			connectivity = Connectable
+		}
+	}
+
+	return connectivity
+}
+
 func (c *Layer3NetworkConf) GetDHCPServerIPAddr() string {
 	if c == nil {
 		return ""
@@ -276,6 +416,35 @@ func (c *Layer3NetworkConf) GetDHCPServerIPAddr() string {
 	return c.ServerIPAddr
 }
 
+// GetDHCPServerIPAddrs returns the allow-list of the DHCP servers, any server is allowed if it's empty
+func (c *Layer3NetworkConf) GetDHCPServerIPAddrs() []net.IP {
+	if c == nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, server := range splitServerIPAddr(c.ServerIPAddr) {
+		if ip := net.ParseIP(server); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+func splitServerIPAddr(serverIPAddr string) []string {
+	var servers []string
+	for _, server := range strings.Split(serverIPAddr, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// dhcpServerLabelValue returns the server address as a label value, the commas are not allowed in the label values
+func dhcpServerLabelValue(l3netconf *Layer3NetworkConf) string {
+	return strings.ReplaceAll(strings.ReplaceAll(l3netconf.GetDHCPServerIPAddr(), " ", ""), ",", "_")
+}
+
 func SetDHCPInfo2JobLabels(lb map[string]string, l2netconf *NetConf, l3netconf *Layer3NetworkConf) {
 	if lb == nil {
 		return
@@ -284,7 +453,7 @@ func SetDHCPInfo2JobLabels(lb map[string]string, l2netconf *NetConf, l3netconf *
 		lb[KeyVlanLabel] = l2netconf.GetVlanString()
 	}
 	if l3netconf != nil {
-		lb[KeyVlanDHCPServerIP] = l3netconf.GetDHCPServerIPAddr()
+		lb[KeyVlanDHCPServerIP] = dhcpServerLabelValue(l3netconf)
 	}
 }
 
@@ -293,7 +462,7 @@ func AreJobLabelsDHCPInfoUnchanged(lb map[string]string, l2netconf *NetConf, l3n
 		return false
 	}
 	if l2netconf != nil && l3netconf != nil {
-		return lb[KeyVlanLabel] == l2netconf.GetVlanString() && lb[KeyVlanDHCPServerIP] == l3netconf.GetDHCPServerIPAddr()
+		return lb[KeyVlanLabel] == l2netconf.GetVlanString() && lb[KeyVlanDHCPServerIP] == dhcpServerLabelValue(l3netconf)
 	}
 	return false
 }
@@ -738,6 +907,31 @@ func GeVlanIDSetFromClusterNetwork(cnName string, nadCache ctlcniv1.NetworkAttac
 	return vis, nil
 }
 
+// GetVlanCIDRsFromNadList returns the CIDR of each l2 vlan nad, which is from the layer 3 network configuration of the nad
+// trunk mode, untagged and non-bridge nads are skipped, so are the nads without a known CIDR
+func GetVlanCIDRsFromNadList(nads []*nadv1.NetworkAttachmentDefinition) map[uint16]string {
+	cidrs := make(map[uint16]string)
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil {
+			continue
+		}
+		nc, err := DecodeNadConfigToNetConf(nad)
+		if err != nil || !nc.IsBridgeCNI() || !nc.IsVlanAccessMode() {
+			continue
+		}
+		vid := nc.GetVlanID()
+		if vid <= DefaultVlanID || vid > MaxVlanID {
+			continue
+		}
+		l3, err := NewLayer3NetworkConfFromNad(nad)
+		if err != nil || l3.CIDR == "" {
+			continue
+		}
+		cidrs[uint16(vid)] = l3.CIDR // nolint: gosec
+	}
+	return cidrs
+}
+
 func generateNadNameList(nads []*nadv1.NetworkAttachmentDefinition) []string {
 	if len(nads) == 0 {
 		return nil
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"net"
 	"testing"
 	"time"
 
@@ -351,3 +352,164 @@ func TestFilterFirstActiveSystemNetworkNad(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVlanCIDRsFromNadList(t *testing.T) {
+	newNad := func(name, config, route string) *nadv1.NetworkAttachmentDefinition {
+		nad := &nadv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: testNamespace,
+				Labels:    map[string]string{KeyClusterNetworkLabel: testCnName},
+			},
+			Spec: nadv1.NetworkAttachmentDefinitionSpec{
+				Config: config,
+			},
+		}
+		if route != "" {
+			nad.Annotations = map[string]string{KeyNetworkRoute: route}
+		}
+		return nad
+	}
+	deletionTime := metav1.NewTime(time.Now())
+	deletingNad := newNad("deleting", testNadConfigVlan350, "{\"mode\":\"auto\",\"cidr\":\"192.168.35.0/24\"}")
+	deletingNad.DeletionTimestamp = &deletionTime
+
+	tests := []struct {
+		name     string
+		nads     []*nadv1.NetworkAttachmentDefinition
+		expected map[uint16]string
+	}{
+		{
+			name:     "empty list returns empty map",
+			nads:     nil,
+			expected: map[uint16]string{},
+		},
+		{
+			name: "CIDR of vlan nad is returned",
+			nads: []*nadv1.NetworkAttachmentDefinition{
+				newNad("vlan300", testNadConfigVlan300, "{\"mode\":\"auto\",\"cidr\":\"192.168.30.0/24\",\"gateway\":\"192.168.30.1\"}"),
+			},
+			expected: map[uint16]string{300: "192.168.30.0/24"},
+		},
+		{
+			name: "nads without CIDR, untagged, trunk, non-bridge and deleting nads are skipped",
+			nads: []*nadv1.NetworkAttachmentDefinition{
+				newNad("vlan300", testNadConfigVlan300, testNadConfigRoute),
+				newNad("untag", testNadConfigVlanUntag, "{\"mode\":\"auto\",\"cidr\":\"192.168.0.0/24\"}"),
+				newNad("trunk", testNadConfigVlanTrunk, "{\"mode\":\"auto\",\"cidr\":\"192.168.1.0/24\"}"),
+				newNad("ovn", testNadConfigOVN, "{\"mode\":\"auto\",\"cidr\":\"192.168.2.0/24\"}"),
+				newNad("invalid", testNadConfigVlan350, testNadConfigRouteInvalid),
+				deletingNad,
+			},
+			expected: map[uint16]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, GetVlanCIDRsFromNadList(tc.nads))
+		})
+	}
+}
+
+func TestSetNodeConnectivity(t *testing.T) {
+	conf := &Layer3NetworkConf{Mode: Manual, CIDR: "192.168.30.0/24", Gateway: "192.168.30.1"}
+	assert.Equal(t, Connectivity(""), conf.GetNodeConnectivity("node1"))
+
+	conf.SetNodeConnectivity("node1", Connectable)
+	assert.Equal(t, Connectable, conf.GetNodeConnectivity("node1"))
+	assert.Equal(t, Connectable, conf.Connectivity)
+	assert.Equal(t, "192.168.30.1", conf.CheckedGateway)
+
+	conf.SetNodeConnectivity("node2", PingFailed)
+	assert.Equal(t, Connectable, conf.Connectivity)
+	conf.SetNodeConnectivity("node3", Unconnectable)
+	assert.Equal(t, Unconnectable, conf.Connectivity)
+	conf.SetNodeConnectivity("node3", "")
+	assert.Equal(t, Connectable, conf.Connectivity)
+	assert.Len(t, conf.NodeConnectivity, 2)
+
+	// the results of the old gateway are dropped
+	conf.Gateway = "192.168.30.254"
+	assert.Equal(t, Connectivity(""), conf.GetNodeConnectivity("node1"))
+	conf.SetNodeConnectivity("node2", Unconnectable)
+	assert.Equal(t, map[string]Connectivity{"node2": Unconnectable}, conf.NodeConnectivity)
+	assert.Equal(t, Unconnectable, conf.Connectivity)
+
+	conf.SetNodeConnectivity("node2", "")
+	assert.Nil(t, conf.NodeConnectivity)
+	assert.Equal(t, "", conf.CheckedGateway)
+	assert.Equal(t, Connectivity(""), conf.Connectivity)
+}
+
+func TestDHCPServerAllowList(t *testing.T) {
+	conf, err := NewLayer3NetworkConf(`{"mode":"auto","serverIPAddr":"192.168.30.2, 192.168.30.3"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("192.168.30.2"), net.ParseIP("192.168.30.3")}, conf.GetDHCPServerIPAddrs())
+
+	labels := map[string]string{}
+	SetDHCPInfo2JobLabels(labels, nil, conf)
+	assert.Equal(t, "192.168.30.2_192.168.30.3", labels[KeyVlanDHCPServerIP])
+
+	_, err = NewLayer3NetworkConf(`{"mode":"auto","serverIPAddr":"192.168.30.2,dhcp.example.com"}`)
+	assert.Error(t, err)
+
+	conf, err = NewLayer3NetworkConf(`{"mode":"auto"}`)
+	assert.NoError(t, err)
+	assert.Nil(t, conf.GetDHCPServerIPAddrs())
+}
+
+func TestIPv6Layer3NetworkConf(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    string
+		wantErr bool
+	}{
+		{
+			name: "ipv6 only",
+			conf: `{"mode":"manual","ipv6Prefix":"2001:db8:1::/64","ipv6Gateway":"2001:db8:1::1"}`,
+		},
+		{
+			name: "dual stack",
+			conf: `{"mode":"manual","cidr":"172.16.1.0/24","gateway":"172.16.1.1","ipv6Prefix":"2001:db8:1::/64","ipv6Gateway":"fe80::1"}`,
+		},
+		{
+			name:    "gateway outside the prefix",
+			conf:    `{"mode":"manual","ipv6Prefix":"2001:db8:1::/64","ipv6Gateway":"2001:db8:2::1"}`,
+			wantErr: true,
+		},
+		{
+			name:    "gateway without prefix",
+			conf:    `{"mode":"manual","cidr":"172.16.1.0/24","gateway":"172.16.1.1","ipv6Gateway":"fe80::1"}`,
+			wantErr: true,
+		},
+		{
+			name:    "ipv4 prefix",
+			conf:    `{"mode":"manual","ipv6Prefix":"172.16.1.0/24","ipv6Gateway":"fe80::1"}`,
+			wantErr: true,
+		},
+		{
+			name:    "incomplete ipv4 network",
+			conf:    `{"mode":"manual","gateway":"172.16.1.1","ipv6Prefix":"2001:db8:1::/64","ipv6Gateway":"fe80::1"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewLayer3NetworkConf(tc.conf)
+			// the annotation of the nad is validated the same way
+			nad := &nadv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{KeyNetworkRoute: tc.conf}},
+			}
+			_, nadErr := NewLayer3NetworkConfFromNad(nad)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Error(t, nadErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, nadErr)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// NICLinkMonitorName is the link monitor which reports the NICs of all nodes
+const NICLinkMonitorName = "nic"
+
+// NICInfo is the attributes of a NIC which a nic selector matches on
+type NICInfo struct {
+	Name       string
+	MAC        string
+	PCIAddress string
+	Driver     string
+}
+
+func NewNICInfoFromLinkStatus(ls *networkv1.LinkStatus) NICInfo {
+	return NICInfo{
+		Name:       ls.Name,
+		MAC:        ls.MAC,
+		PCIAddress: ls.PCIAddress,
+		Driver:     ls.Driver,
+	}
+}
+
+func ValidateNICSelector(selector *networkv1.NICSelector) error {
+	if selector == nil {
+		return nil
+	}
+	if len(selector.MACs) == 0 && len(selector.PCIAddresses) == 0 && len(selector.Drivers) == 0 && selector.NameRegex == "" {
+		return fmt.Errorf("nic selector has no criteria")
+	}
+	for _, mac := range selector.MACs {
+		if _, err := net.ParseMAC(mac); err != nil {
+			return fmt.Errorf("nic selector has an invalid MAC %s, error: %w", mac, err)
+		}
+	}
+	if selector.NameRegex != "" {
+		if _, err := regexp.Compile(selector.NameRegex); err != nil {
+			return fmt.Errorf("nic selector has an invalid name regex %s, error: %w", selector.NameRegex, err)
+		}
+	}
+	if selector.MaxCount < 0 {
+		return fmt.Errorf("nic selector maxCount %v can't be negative", selector.MaxCount)
+	}
+	return nil
+}
+
+// MatchNICs returns the sorted names of the NICs matched by the selector, at most selector.MaxCount names are returned
+func MatchNICs(selector *networkv1.NICSelector, nics []NICInfo) ([]string, error) {
+	if err := ValidateNICSelector(selector); err != nil {
+		return nil, err
+	}
+	if selector == nil {
+		return nil, nil
+	}
+
+	var nameRegex *regexp.Regexp
+	if selector.NameRegex != "" {
+		nameRegex = regexp.MustCompile(selector.NameRegex)
+	}
+
+	names := make([]string, 0, len(nics))
+	for _, nic := range nics {
+		if len(selector.MACs) > 0 && !slices.ContainsFunc(selector.MACs, func(mac string) bool { return equalMACs(mac, nic.MAC) }) {
+			continue
+		}
+		if len(selector.PCIAddresses) > 0 && !slices.ContainsFunc(selector.PCIAddresses, func(addr string) bool { return strings.EqualFold(addr, nic.PCIAddress) }) {
+			continue
+		}
+		if len(selector.Drivers) > 0 && !slices.Contains(selector.Drivers, nic.Driver) {
+			continue
+		}
+		if nameRegex != nil && !nameRegex.MatchString(nic.Name) {
+			continue
+		}
+		names = append(names, nic.Name)
+	}
+
+	sort.Strings(names)
+	if selector.MaxCount > 0 && len(names) > selector.MaxCount {
+		names = names[:selector.MaxCount]
+	}
+
+	return names, nil
+}
+
+func equalMACs(a, b string) bool {
+	macA, errA := net.ParseMAC(a)
+	macB, errB := net.ParseMAC(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return macA.String() == macB.String()
+}
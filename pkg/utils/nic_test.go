@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestMatchNICs(t *testing.T) {
+	nics := []NICInfo{
+		{Name: "ens3f1", MAC: "52:54:00:00:00:02", PCIAddress: "0000:41:00.1", Driver: "ixgbe"},
+		{Name: "ens3f0", MAC: "52:54:00:00:00:01", PCIAddress: "0000:41:00.0", Driver: "ixgbe"},
+		{Name: "eno1", MAC: "52:54:00:00:00:03", PCIAddress: "0000:01:00.0", Driver: "tg3"},
+	}
+
+	tests := []struct {
+		name      string
+		selector  *networkv1.NICSelector
+		returnErr bool
+		expected  []string
+	}{
+		{
+			name:     "nil selector matches nothing",
+			selector: nil,
+			expected: nil,
+		},
+		{
+			name:      "selector without criteria is invalid",
+			selector:  &networkv1.NICSelector{MaxCount: 1},
+			returnErr: true,
+		},
+		{
+			name:     "match by MAC regardless of the case",
+			selector: &networkv1.NICSelector{MACs: []string{"52:54:00:00:00:0A", "52-54-00-00-00-03"}},
+			expected: []string{"eno1"},
+		},
+		{
+			name:     "match by PCI address",
+			selector: &networkv1.NICSelector{PCIAddresses: []string{"0000:41:00.1"}},
+			expected: []string{"ens3f1"},
+		},
+		{
+			name:     "match by driver and the result is sorted",
+			selector: &networkv1.NICSelector{Drivers: []string{"ixgbe"}},
+			expected: []string{"ens3f0", "ens3f1"},
+		},
+		{
+			name:     "all criteria must match",
+			selector: &networkv1.NICSelector{Drivers: []string{"ixgbe"}, NameRegex: "^eno"},
+			expected: []string{},
+		},
+		{
+			name:     "match by name regex with max count",
+			selector: &networkv1.NICSelector{NameRegex: "^e", MaxCount: 2},
+			expected: []string{"eno1", "ens3f0"},
+		},
+		{
+			name:      "invalid name regex",
+			selector:  &networkv1.NICSelector{NameRegex: "ens3f[0-"},
+			returnErr: true,
+		},
+		{
+			name:      "invalid MAC",
+			selector:  &networkv1.NICSelector{MACs: []string{"52:54:00"}},
+			returnErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := MatchNICs(tc.selector, nics)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if !tc.returnErr {
+				assert.Equal(t, tc.expected, names)
+			}
+		})
+	}
+}
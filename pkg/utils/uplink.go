@@ -0,0 +1,56 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+// IsNodeOverrideMatched returns true if the node override selects the node by the node name or the node labels
+func IsNodeOverrideMatched(o *networkv1.NodeOverride, node *corev1.Node) bool {
+	if node == nil {
+		return false
+	}
+	if o.NodeName != "" {
+		return o.NodeName == node.Name
+	}
+	if len(o.NodeSelector) == 0 {
+		return false
+	}
+	return labels.SelectorFromSet(o.NodeSelector).Matches(labels.Set(node.Labels))
+}
+
+// MergeUplink returns a copy of the uplink whose parts are replaced by the node override
+func MergeUplink(uplink *networkv1.Uplink, o *networkv1.NodeOverride) *networkv1.Uplink {
+	merged := uplink.DeepCopy()
+	if o == nil {
+		return merged
+	}
+
+	if len(o.NICs) > 0 || o.NICSelector != nil {
+		merged.NICs = append([]string(nil), o.NICs...)
+		merged.NICSelector = o.NICSelector.DeepCopy()
+	}
+	if o.LinkAttrs != nil {
+		merged.LinkAttrs = o.LinkAttrs.DeepCopy()
+	}
+	if o.BondOptions != nil {
+		merged.BondOptions = o.BondOptions.DeepCopy()
+	}
+
+	return merged
+}
+
+// GetEffectiveUplink returns the uplink of the vlanconfig on the node and the name of the applied node override,
+// the name is empty if no node override matches the node
+func GetEffectiveUplink(vc *networkv1.VlanConfig, node *corev1.Node) (*networkv1.Uplink, string) {
+	for i := range vc.Spec.NodeOverrides {
+		o := &vc.Spec.NodeOverrides[i]
+		if IsNodeOverrideMatched(o, node) {
+			return MergeUplink(&vc.Spec.Uplink, o), o.Name
+		}
+	}
+
+	return vc.Spec.Uplink.DeepCopy(), ""
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+)
+
+func TestGetEffectiveUplink(t *testing.T) {
+	vc := &networkv1.VlanConfig{
+		Spec: networkv1.VlanConfigSpec{
+			ClusterNetwork: testCnName,
+			Uplink: networkv1.Uplink{
+				NICs:        []string{"ens3f0", "ens3f1"},
+				LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1},
+			},
+			NodeOverrides: []networkv1.NodeOverride{
+				{
+					Name:     "node1",
+					NodeName: "node1",
+					NICs:     []string{"enp65s0f0"},
+				},
+				{
+					Name:         "lacp",
+					NodeSelector: map[string]string{"switch": "lacp"},
+					NICSelector:  &networkv1.NICSelector{Drivers: []string{"ice"}},
+					BondOptions:  &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		node             *corev1.Node
+		expectedOverride string
+		expectedUplink   *networkv1.Uplink
+	}{
+		{
+			name:             "no override matches the node",
+			node:             &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node3"}},
+			expectedOverride: "",
+			expectedUplink:   vc.Spec.Uplink.DeepCopy(),
+		},
+		{
+			name:             "override by node name replaces NICs only",
+			node:             &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"switch": "lacp"}}},
+			expectedOverride: "node1",
+			expectedUplink: &networkv1.Uplink{
+				NICs:        []string{"enp65s0f0"},
+				LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1},
+			},
+		},
+		{
+			name:             "override by node selector replaces NIC selection and bond options",
+			node:             &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2", Labels: map[string]string{"switch": "lacp"}}},
+			expectedOverride: "lacp",
+			expectedUplink: &networkv1.Uplink{
+				NICSelector: &networkv1.NICSelector{Drivers: []string{"ice"}},
+				LinkAttrs:   &networkv1.LinkAttrs{MTU: 9000, TxQLen: -1},
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			uplink, override := GetEffectiveUplink(vc, tc.node)
+			assert.Equal(t, tc.expectedOverride, override)
+			assert.Equal(t, tc.expectedUplink, uplink)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package utils
+
+const (
+	// VirtualIPLeaseNamespace is where the Leases to elect the owners of the virtual IPs are
+	VirtualIPLeaseNamespace = "kube-system"
+	virtualIPLeasePrefix    = "harvester-vip-"
+)
+
+// e.g. harvester-vip-storage
+func GetVirtualIPLeaseName(vipName string) string {
+	return virtualIPLeasePrefix + vipName
+}
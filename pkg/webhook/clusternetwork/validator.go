@@ -2,6 +2,7 @@ package clusternetwork
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/harvester/webhook/pkg/server/admission"
@@ -52,6 +53,10 @@ func (c *CnValidator) Create(_ *admission.Request, newObj runtime.Object) error
 		return fmt.Errorf(createErr, cn.Name, err)
 	}
 
+	if err := checkAllowedVlanRanges(cn); err != nil {
+		return fmt.Errorf(createErr, cn.Name, err)
+	}
+
 	return nil
 }
 
@@ -72,6 +77,10 @@ func (c *CnValidator) Update(_ *admission.Request, oldObj, newObj runtime.Object
 		return fmt.Errorf(updateErr, newCn.Name, err)
 	}
 
+	if err := c.checkAllowedVlanRangesOfUpdatedClusterNetwork(oldCn, newCn); err != nil {
+		return fmt.Errorf(updateErr, newCn.Name, err)
+	}
+
 	return nil
 }
 
@@ -129,14 +138,27 @@ func (c *CnValidator) Resource() admission.Resource {
 }
 
 func checkMTUOfNewClusterNetwork(cn *networkv1.ClusterNetwork) error {
-	if cn == nil || cn.Annotations == nil {
+	if cn == nil {
 		return nil
 	}
 
-	// for non-mgmt cluster network, this annotation can only be operated by controller
+	// for non-mgmt cluster network, the MTU can only be operated by controller
 	if _, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
 		return fmt.Errorf("annotation %v can't be added", utils.KeyUplinkMTU)
 	}
+
+	if cn.Spec.MTU == 0 {
+		return nil
+	}
+
+	if cn.Name != utils.ManagementClusterNetworkName {
+		return fmt.Errorf("spec.mtu can't be set, it is synced from the vlanconfigs")
+	}
+
+	if !utils.IsValidMTU(cn.Spec.MTU) {
+		return fmt.Errorf("the MTU %v is out of range [0, %v..%v]", cn.Spec.MTU, utils.MinMTU, utils.MaxMTU)
+	}
+
 	return nil
 }
 
@@ -146,13 +168,11 @@ func (c *CnValidator) checkMTUOfUpdatedClusterNetwork(oldCn, newCn *networkv1.Cl
 		return nil
 	}
 
-	newMtu := utils.DefaultMTU
-	var err error
-	if mtuStr, ok := newCn.Annotations[utils.KeyUplinkMTU]; ok {
-		if newMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
-			return err
-		}
+	newMtu, err := utils.GetMTUFromClusterNetwork(newCn)
+	if err != nil {
+		return err
 	}
+	newMtu = utils.MTUDefaultTo(newMtu)
 
 	// ensure clusternetwork's MTU is same with all vlanconfigs
 	vcs, err := c.vcCache.List(labels.Set(map[string]string{
@@ -178,27 +198,24 @@ func (c *CnValidator) checkMTUOfUpdatedClusterNetwork(oldCn, newCn *networkv1.Cl
 
 // mgmt cluster network, there is no vlanconfig to configure MTU, the MTU is configured in node installation stage and saved to local file
 // later we need to convert each node's network configuration to a related vlanconfig object
-// currently, if user plans to set a none-default MTU value, then it can be updated via clusternetwork mgmt annotation
+// currently, if user plans to set a none-default MTU value, then it can be updated via clusternetwork mgmt spec.mtu
 func (c *CnValidator) checkMTUOfUpdatedMgmtClusterNetwork(oldCn, newCn *networkv1.ClusterNetwork) error {
 	if oldCn == nil || newCn == nil || newCn.Name != utils.ManagementClusterNetworkName {
 		return nil
 	}
 
 	// mgmt network, MTU can be updated
-	newMtu := utils.DefaultMTU
-	var err error
-	if mtuStr, ok := newCn.Annotations[utils.KeyUplinkMTU]; ok {
-		if newMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
-			return err
-		}
+	newMtu, err := utils.GetMTUFromClusterNetwork(newCn)
+	if err != nil {
+		return err
 	}
+	newMtu = utils.MTUDefaultTo(newMtu)
 
-	oldMtu := utils.DefaultMTU
-	if mtuStr, ok := oldCn.Annotations[utils.KeyUplinkMTU]; ok {
-		if oldMtu, err = utils.GetMTUFromString(mtuStr); err != nil {
-			return err
-		}
+	oldMtu, err := utils.GetMTUFromClusterNetwork(oldCn)
+	if err != nil {
+		return err
 	}
+	oldMtu = utils.MTUDefaultTo(oldMtu)
 
 	// MTU does not change
 	if utils.AreEqualMTUs(oldMtu, newMtu) {
@@ -226,3 +243,44 @@ func (c *CnValidator) checkMTUOfUpdatedMgmtClusterNetwork(oldCn, newCn *networkv
 
 	return nil
 }
+
+func checkAllowedVlanRanges(cn *networkv1.ClusterNetwork) error {
+	for _, r := range cn.Spec.AllowedVlanRanges {
+		if err := utils.IsVlanRangeValid(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the existing nads must be still covered by the updated allowed vlan ranges
+func (c *CnValidator) checkAllowedVlanRangesOfUpdatedClusterNetwork(oldCn, newCn *networkv1.ClusterNetwork) error {
+	if oldCn == nil || newCn == nil || reflect.DeepEqual(oldCn.Spec.AllowedVlanRanges, newCn.Spec.AllowedVlanRanges) {
+		return nil
+	}
+
+	if err := checkAllowedVlanRanges(newCn); err != nil {
+		return err
+	}
+
+	nadGetter := utils.NewNadGetter(c.nadCache)
+	nads, err := nadGetter.ListNadsOnClusterNetwork(newCn.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil {
+			continue
+		}
+		nc, err := utils.DecodeNadConfigToNetConf(nad)
+		if err != nil {
+			return err
+		}
+		if err := utils.CheckVlansAllowedOnClusterNetwork(newCn, nc); err != nil {
+			return fmt.Errorf("nad %s/%s is blocking: %w", nad.Namespace, nad.Name, err)
+		}
+	}
+
+	return nil
+}
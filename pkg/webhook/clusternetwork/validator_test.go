@@ -66,6 +66,57 @@ func TestCreateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork can't be created as the MTU is synced from vlanconfigs",
+			returnErr: true,
+			errKey:    "spec.mtu can't be set",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MTU: 9000},
+			},
+		},
+		{
+			name:      "ClusterNetwork can be created with description and allowed vlan ranges",
+			returnErr: false,
+			errKey:    "",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					Description:       "test",
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 200}, {Start: 300}},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the allowed vlan range is reversed",
+			returnErr: true,
+			errKey:    "is greater than end",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 200, End: 100}},
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be created as the allowed vlan range is out of range",
+			returnErr: true,
+			errKey:    "out of range",
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 4095}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -196,6 +247,80 @@ func TestUpdateClusterNetwork(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "ClusterNetwork can be updated when the legacy MTU annotation is migrated to spec",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{utils.KeyUplinkMTU: "9000"},
+				},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-vc",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 9000},
+					},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MTU: 9000},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be updated as the spec MTU is different with vlanconfig",
+			returnErr: true,
+			errKey:    "has another MTU",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MTU: 9000},
+			},
+			currentVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-vc",
+					Labels: map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						LinkAttrs: &networkv1.LinkAttrs{MTU: 9000},
+					},
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MTU: 1500},
+			},
+		},
+		{
+			name:      "ClusterNetwork mgmt can't be changed as the new spec MTU is not in range",
+			returnErr: true,
+			errKey:    "not in range",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.ManagementClusterNetworkName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.ManagementClusterNetworkName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{MTU: 20000},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -436,6 +561,129 @@ func TestUpdateMgmtClusterNetwork(t *testing.T) {
 	}
 }
 
+func TestUpdateClusterNetworkAllowedVlanRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		returnErr  bool
+		errKey     string
+		currentCN  *networkv1.ClusterNetwork
+		newCN      *networkv1.ClusterNetwork
+		currentNAD *cniv1.NetworkAttachmentDefinition
+	}{
+		{
+			name:      "ClusterNetwork can be updated as the nad vlan is still allowed",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 200, End: 400}},
+				},
+			},
+			currentNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"nad1\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be updated as the nad vlan is not allowed any more",
+			returnErr: true,
+			errKey:    "not in the allowed vlan ranges",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 200}, {Start: 301}},
+				},
+			},
+			currentNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"nad1\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":300,\"ipam\":{}}",
+				},
+			},
+		},
+		{
+			name:      "ClusterNetwork can't be updated as the trunk nad vlans are not allowed any more",
+			returnErr: true,
+			errKey:    "vlan(s) 201,202",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+			},
+			newCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 200}},
+				},
+			},
+			currentNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: "{\"cniVersion\":\"0.3.1\",\"name\":\"nad1\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlanTrunk\":[{\"minID\":199,\"maxID\":202}],\"ipam\":{}}",
+				},
+			},
+		},
+	}
+
+	nadGvr := schema.GroupVersionResource{
+		Group:    "k8s.cni.cncf.io",
+		Version:  "v1",
+		Resource: "network-attachment-definitions",
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
+			vmiCache := fakeclients.VirtualMachineInstanceCache(nchclientset.KubevirtV1().VirtualMachineInstances)
+			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
+			if tc.currentNAD != nil {
+				if err := nchclientset.Tracker().Create(nadGvr, tc.currentNAD.DeepCopy(), tc.currentNAD.Namespace); err != nil {
+					t.Fatalf("failed to add nad %+v", tc.currentNAD)
+				}
+			}
+
+			validator := NewCnValidator(nadCache, vmiCache, vcCache)
+			err := validator.Update(nil, tc.currentCN, tc.newCN)
+			assert.True(t, tc.returnErr == (err != nil))
+			if tc.returnErr && err != nil {
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}
+
 func TestDeleteClusterNetwork(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,292 @@
+package hostnetworkconfig
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/utils"
+)
+
+// defaultVpc is the kube-ovn VPC whose subnets are routed on the hosts through the ovn0 interface
+const defaultVpc = "ovn-cluster"
+
+// ClusterCIDRs are the pod and service CIDRs of the cluster, the service CIDRs are not exposed by the API server and
+// are configured on the webhook
+type ClusterCIDRs struct {
+	PodCIDRs     []string
+	ServiceCIDRs []string
+}
+
+// specSubnets returns the subnets known from the spec, the subnets of the dhcp and slaac modes are unknown until
+// the addresses are obtained
+func specSubnets(spec *networkv1.HostNetworkConfigSpec) []netip.Prefix {
+	var subnets []netip.Prefix
+	if spec.Mode == IPModeStatic {
+		subnets = appendSubnets(subnets, ipAddrsToStrings(spec.HostIPs)...)
+	}
+	if spec.Mode == IPModePool && spec.Pool != nil {
+		subnets = appendSubnets(subnets, string(spec.Pool.CIDR))
+	}
+	if spec.IPv6Mode == IPModeStatic {
+		subnets = appendSubnets(subnets, ipAddrsToStrings(spec.HostIPv6s)...)
+	}
+	return subnets
+}
+
+// observedSubnets returns the subnets of the spec and the subnets of the addresses assigned on the nodes
+func observedSubnets(hnc *networkv1.HostNetworkConfig) []netip.Prefix {
+	subnets := specSubnets(&hnc.Spec)
+	for _, status := range hnc.Status.NodeStatus {
+		subnets = appendSubnets(subnets, status.IPv4Addresses...)
+		subnets = appendSubnets(subnets, status.IPv6Addresses...)
+	}
+	return subnets
+}
+
+func ipAddrsToStrings(ips map[string]networkv1.IPAddr) []string {
+	cidrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		cidrs = append(cidrs, string(ip))
+	}
+	return cidrs
+}
+
+// appendSubnets appends the subnets of the CIDRs which are not in the list yet, invalid CIDRs are skipped as they are
+// reported by the other validations
+func appendSubnets(subnets []netip.Prefix, cidrs ...string) []netip.Prefix {
+	for _, c := range cidrs {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(c))
+		if err != nil {
+			continue
+		}
+		prefix = prefix.Masked()
+		if !containsPrefix(subnets, prefix) {
+			subnets = append(subnets, prefix)
+		}
+	}
+	return subnets
+}
+
+func containsPrefix(prefixes []netip.Prefix, prefix netip.Prefix) bool {
+	for _, p := range prefixes {
+		if p == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapping returns the first subnet overlapping any of the CIDRs, and the CIDR it overlaps
+func overlapping(subnets []netip.Prefix, cidrs []netip.Prefix) (netip.Prefix, netip.Prefix, bool) {
+	for _, subnet := range subnets {
+		for _, c := range cidrs {
+			if subnet.Overlaps(c) {
+				return subnet, c, true
+			}
+		}
+	}
+	return netip.Prefix{}, netip.Prefix{}, false
+}
+
+// checkOverlaps checks the subnets of the host network against the networks of the cluster. The overlaps breaking
+// the routing of the nodes are returned as the error, the overlaps which are merely risky are returned as warnings.
+func (v *Validator) checkOverlaps(hnc *networkv1.HostNetworkConfig) ([]string, error) {
+	subnets := specSubnets(&hnc.Spec)
+	if len(subnets) == 0 {
+		return nil, nil
+	}
+
+	nodes, err := v.nodeCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.checkClusterCIDRs(subnets, nodes); err != nil {
+		return nil, err
+	}
+
+	if err := v.checkHostNetworkConfigs(hnc, subnets); err != nil {
+		return nil, err
+	}
+
+	warnings, err := checkNodeIPs(&hnc.Spec, subnets, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	subnetWarnings, err := v.checkKubeOVNSubnets(subnets)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, subnetWarnings...)
+
+	nadWarnings, err := v.checkNads(hnc, subnets)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(warnings, nadWarnings...), nil
+}
+
+// checkClusterCIDRs rejects the subnets overlapping the pod and service CIDRs, the traffic to the pods and services
+// would be routed to the host network otherwise
+func (v *Validator) checkClusterCIDRs(subnets []netip.Prefix, nodes []*v1.Node) error {
+	if subnet, c, ok := overlapping(subnets, appendSubnets(nil, v.clusterCIDRs.PodCIDRs...)); ok {
+		return fmt.Errorf("subnet %s overlaps the pod CIDR %s of the cluster", subnet, c)
+	}
+	if subnet, c, ok := overlapping(subnets, appendSubnets(nil, v.clusterCIDRs.ServiceCIDRs...)); ok {
+		return fmt.Errorf("subnet %s overlaps the service CIDR %s of the cluster", subnet, c)
+	}
+
+	for _, node := range nodes {
+		if subnet, c, ok := overlapping(subnets, appendSubnets(nil, node.Spec.PodCIDRs...)); ok {
+			return fmt.Errorf("subnet %s overlaps the pod CIDR %s of node %s", subnet, c, node.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkHostNetworkConfigs rejects the subnets overlapping the host networks of the other vlans, a node can't route
+// the traffic of one subnet to two interfaces
+func (v *Validator) checkHostNetworkConfigs(newhnc *networkv1.HostNetworkConfig, subnets []netip.Prefix) error {
+	hostnetworkconfigs, err := v.hncCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, hnc := range hostnetworkconfigs {
+		if hnc.DeletionTimestamp != nil || hnc.Name == newhnc.Name {
+			continue
+		}
+		if hnc.Spec.ClusterNetwork == newhnc.Spec.ClusterNetwork && hnc.Spec.VlanID == newhnc.Spec.VlanID {
+			continue
+		}
+		if subnet, c, ok := overlapping(subnets, observedSubnets(hnc)); ok {
+			return fmt.Errorf("subnet %s overlaps the subnet %s of hostnetworkconfig %s on cluster network %s vlan %d",
+				subnet, c, hnc.Name, hnc.Spec.ClusterNetwork, hnc.Spec.VlanID)
+		}
+	}
+
+	return nil
+}
+
+// checkNodeIPs rejects the static and pool IPs which are the internal IPs of the nodes, and warns if the host network
+// shares the subnet with the internal IPs as the traffic of the subnet may leave from either interface
+func checkNodeIPs(spec *networkv1.HostNetworkConfigSpec, subnets []netip.Prefix, nodes []*v1.Node) ([]string, error) {
+	staticIPs := make(map[netip.Addr]string)
+	if spec.Mode == IPModeStatic {
+		addStaticIPs(staticIPs, spec.HostIPs)
+	}
+	if spec.IPv6Mode == IPModeStatic {
+		addStaticIPs(staticIPs, spec.HostIPv6s)
+	}
+
+	var pool *utils.IPPool
+	if spec.Mode == IPModePool && spec.Pool != nil {
+		pool, _ = utils.NewIPPool(spec.Pool)
+	}
+
+	var warnings []string
+	for _, node := range nodes {
+		for _, address := range node.Status.Addresses {
+			if address.Type != v1.NodeInternalIP {
+				continue
+			}
+			ip, err := netip.ParseAddr(address.Address)
+			if err != nil {
+				continue
+			}
+			if owner, ok := staticIPs[ip]; ok {
+				return nil, fmt.Errorf("static IP %s of node %s is the internal IP of node %s", ip, owner, node.Name)
+			}
+			if pool != nil && pool.Contains(ip) {
+				return nil, fmt.Errorf("pool %s contains the internal IP %s of node %s", pool.Prefix(), ip, node.Name)
+			}
+			for _, subnet := range subnets {
+				if subnet.Contains(ip) {
+					warnings = append(warnings, fmt.Sprintf("subnet %s contains the internal IP %s of node %s, the host network shares the subnet with the management network", subnet, ip, node.Name))
+				}
+			}
+		}
+	}
+
+	return warnings, nil
+}
+
+func addStaticIPs(staticIPs map[netip.Addr]string, hostIPs map[string]networkv1.IPAddr) {
+	for node, c := range hostIPs {
+		if prefix, err := netip.ParsePrefix(string(c)); err == nil {
+			staticIPs[prefix.Addr()] = node
+		}
+	}
+}
+
+// checkKubeOVNSubnets rejects the subnets overlapping the kube-ovn subnets of the default VPC which are routed on the
+// hosts, and warns about the subnets of the custom VPCs which are isolated from the hosts
+func (v *Validator) checkKubeOVNSubnets(subnets []netip.Prefix) ([]string, error) {
+	// the kube-ovn subnet cache is nil if the CRD is not installed
+	if v.subnetCache == nil {
+		return nil, nil
+	}
+
+	ovnSubnets, err := v.subnetCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, ovnSubnet := range ovnSubnets {
+		if ovnSubnet.DeletionTimestamp != nil {
+			continue
+		}
+		subnet, c, ok := overlapping(subnets, appendSubnets(nil, strings.Split(ovnSubnet.Spec.CIDRBlock, ",")...))
+		if !ok {
+			continue
+		}
+		if ovnSubnet.Spec.Vpc == "" || ovnSubnet.Spec.Vpc == defaultVpc {
+			return nil, fmt.Errorf("subnet %s overlaps the CIDR %s of kube-ovn subnet %s", subnet, c, ovnSubnet.Name)
+		}
+		warnings = append(warnings, fmt.Sprintf("subnet %s overlaps the CIDR %s of kube-ovn subnet %s in vpc %s", subnet, c, ovnSubnet.Name, ovnSubnet.Spec.Vpc))
+	}
+
+	return warnings, nil
+}
+
+// checkNads warns about the subnets overlapping the CIDRs of the VM networks on the other vlans, the hosts reach the
+// VMs of these networks by the host network rather than the router of the VM network
+func (v *Validator) checkNads(hnc *networkv1.HostNetworkConfig, subnets []netip.Prefix) ([]string, error) {
+	nads, err := utils.NewNadGetter(v.nadCache).ListAllNads()
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, nad := range nads {
+		if nad.DeletionTimestamp != nil {
+			continue
+		}
+		nc, err := utils.DecodeNadConfigToNetConf(nad)
+		if err != nil || !nc.IsBridgeCNI() || !nc.IsVlanAccessMode() {
+			continue
+		}
+		l3, err := utils.NewLayer3NetworkConfFromNad(nad)
+		if err != nil || l3.CIDR == "" {
+			continue
+		}
+		// the host network is supposed to share the subnet with the VM network of the same vlan
+		if nad.Labels[utils.KeyClusterNetworkLabel] == hnc.Spec.ClusterNetwork && nc.GetVlanID() == int(hnc.Spec.VlanID) {
+			continue
+		}
+		if subnet, c, ok := overlapping(subnets, appendSubnets(nil, l3.CIDR)); ok {
+			warnings = append(warnings, fmt.Sprintf("subnet %s overlaps the CIDR %s of nad %s/%s on vlan %d", subnet, c, nad.Namespace, nad.Name, nc.GetVlanID()))
+		}
+	}
+
+	return warnings, nil
+}
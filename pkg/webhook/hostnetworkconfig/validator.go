@@ -9,10 +9,12 @@ import (
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/harvester/webhook/pkg/server/admission"
+	"github.com/sirupsen/logrus"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 
 	nadv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
@@ -20,6 +22,7 @@ import (
 
 	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
 	ctlcniv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/k8s.cni.cncf.io/v1"
+	kubeovnnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubeovn.io/v1"
 	ctlkubevirtv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/kubevirt.io/v1"
 	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
 	"github.com/harvester/harvester-network-controller/pkg/utils"
@@ -31,6 +34,10 @@ const (
 	deleteErr    = "can't delete hostnetworkConfig %s because %w"
 	IPModeDHCP   = "dhcp"
 	IPModeStatic = "static"
+	IPModePool   = "pool"
+
+	// reasonSubnetOverlap is the reason of the warning events about the risky overlaps of the subnets
+	reasonSubnetOverlap = "SubnetOverlap"
 )
 
 type ValidateOp int
@@ -43,13 +50,16 @@ const (
 type Validator struct {
 	admission.DefaultValidator
 
-	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
-	cnCache   ctlnetworkv1.ClusterNetworkCache
-	hncCache  ctlnetworkv1.HostNetworkConfigCache
-	vcCache   ctlnetworkv1.VlanConfigCache
-	vsCache   ctlnetworkv1.VlanStatusCache
-	nodeCache ctlcorev1.NodeCache
-	vmCache   ctlkubevirtv1.VirtualMachineCache
+	nadCache     ctlcniv1.NetworkAttachmentDefinitionCache
+	cnCache      ctlnetworkv1.ClusterNetworkCache
+	hncCache     ctlnetworkv1.HostNetworkConfigCache
+	vcCache      ctlnetworkv1.VlanConfigCache
+	vsCache      ctlnetworkv1.VlanStatusCache
+	nodeCache    ctlcorev1.NodeCache
+	vmCache      ctlkubevirtv1.VirtualMachineCache
+	subnetCache  kubeovnnetworkv1.SubnetCache
+	clusterCIDRs ClusterCIDRs
+	recorder     record.EventRecorder
 }
 
 func NewHostNetworkConfigValidator(
@@ -60,21 +70,27 @@ func NewHostNetworkConfigValidator(
 	vsCache ctlnetworkv1.VlanStatusCache,
 	nodeCache ctlcorev1.NodeCache,
 	vmCache ctlkubevirtv1.VirtualMachineCache,
+	subnetCache kubeovnnetworkv1.SubnetCache,
+	clusterCIDRs ClusterCIDRs,
+	recorder record.EventRecorder,
 ) *Validator {
 	return &Validator{
-		nadCache:  nadCache,
-		cnCache:   cnCache,
-		hncCache:  hncCache,
-		vcCache:   vcCache,
-		vsCache:   vsCache,
-		nodeCache: nodeCache,
-		vmCache:   vmCache,
+		nadCache:     nadCache,
+		cnCache:      cnCache,
+		hncCache:     hncCache,
+		vcCache:      vcCache,
+		vsCache:      vsCache,
+		nodeCache:    nodeCache,
+		vmCache:      vmCache,
+		subnetCache:  subnetCache,
+		clusterCIDRs: clusterCIDRs,
+		recorder:     recorder,
 	}
 }
 
 var _ admission.Validator = &Validator{}
 
-func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
+func (v *Validator) Create(req *admission.Request, newObj runtime.Object) error {
 	hnc := newObj.(*networkv1.HostNetworkConfig)
 
 	if _, err := utils.IsClusterNetworkNameValid(hnc.Spec.ClusterNetwork); err != nil {
@@ -99,26 +115,30 @@ func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
 		return fmt.Errorf(createErr, hnc.Name, err)
 	}
 
-	if !hnc.Spec.Underlay {
-		return nil
-	}
-	//vlan interface chosen as underlay must have vlanconfig spanning all nodes
-	if err := v.checkVCSpansAllNodes(hnc.Spec.ClusterNetwork); err != nil {
-		return fmt.Errorf(createErr, hnc.Name, err)
-	}
+	if hnc.Spec.Underlay {
+		//vlan interface chosen as underlay must have vlanconfig spanning all nodes
+		if err := v.checkVCSpansAllNodes(hnc.Spec.ClusterNetwork); err != nil {
+			return fmt.Errorf(createErr, hnc.Name, err)
+		}
 
-	matchedNode, err := v.nodeSelectorMatchesAllNodes(hnc.Spec.NodeSelector)
-	if err != nil {
-		return fmt.Errorf(updateErr, hnc.Name, err)
+		matchedNode, err := v.nodeSelectorMatchesAllNodes(hnc.Spec.NodeSelector)
+		if err != nil {
+			return fmt.Errorf(updateErr, hnc.Name, err)
+		}
+		if !matchedNode {
+			return fmt.Errorf(updateErr, hnc.Name, fmt.Errorf("node selector does not match all nodes"))
+		}
 	}
-	if !matchedNode {
-		return fmt.Errorf(updateErr, hnc.Name, fmt.Errorf("node selector does not match all nodes"))
+
+	// the overlaps are checked last so that the warnings are only reported for the requests to be admitted
+	if err := v.validateOverlaps(hnc, isDryRun(req)); err != nil {
+		return fmt.Errorf(createErr, hnc.Name, err)
 	}
 
 	return nil
 }
 
-func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object) error {
+func (v *Validator) Update(req *admission.Request, oldObj, newObj runtime.Object) error {
 	oldhnc := oldObj.(*networkv1.HostNetworkConfig)
 	newhnc := newObj.(*networkv1.HostNetworkConfig)
 
@@ -142,22 +162,25 @@ func (v *Validator) Update(_ *admission.Request, oldObj, newObj runtime.Object)
 		return fmt.Errorf(updateErr, newhnc.Name, err)
 	}
 
-	if !newhnc.Spec.Underlay {
-		return nil
-	}
+	if newhnc.Spec.Underlay {
+		//vlan interface chosen as underlay must have vlanconfig spanning all nodes
+		if err := v.checkVCSpansAllNodes(newhnc.Spec.ClusterNetwork); err != nil {
+			return fmt.Errorf(updateErr, newhnc.Name, err)
+		}
 
-	//vlan interface chosen as underlay must have vlanconfig spanning all nodes
-	if err := v.checkVCSpansAllNodes(newhnc.Spec.ClusterNetwork); err != nil {
-		return fmt.Errorf(updateErr, newhnc.Name, err)
+		matchedNode, err := v.nodeSelectorMatchesAllNodes(newhnc.Spec.NodeSelector)
+		if err != nil {
+			return fmt.Errorf(updateErr, newhnc.Name, err)
+		}
+		if !matchedNode {
+			return fmt.Errorf(updateErr, newhnc.Name, fmt.Errorf("node selector does not match all nodes"))
+		}
 	}
 
-	matchedNode, err := v.nodeSelectorMatchesAllNodes(newhnc.Spec.NodeSelector)
-	if err != nil {
+	// the overlaps are checked last so that the warnings are only reported for the requests to be admitted
+	if err := v.validateOverlaps(newhnc, isDryRun(req)); err != nil {
 		return fmt.Errorf(updateErr, newhnc.Name, err)
 	}
-	if !matchedNode {
-		return fmt.Errorf(updateErr, newhnc.Name, fmt.Errorf("node selector does not match all nodes"))
-	}
 
 	return nil
 }
@@ -233,24 +256,54 @@ func sameSubnet(cidrs []string) (bool, error) {
 	return true, nil
 }
 
-func validateStaticIPsForAllNodes(nodes []*v1.Node, hostIPs map[string]networkv1.IPAddr) error {
-	cidrs := make([]string, 0, len(hostIPs))
-	for _, node := range nodes {
-		if _, ok := hostIPs[node.Name]; !ok {
-			return fmt.Errorf("static IP not found for node %s", node.Name)
+// ipFamily is the address family of the static IPs, it's validated separately for a dual-stack host network
+type ipFamily string
+
+const (
+	familyIPv4 ipFamily = "IP"
+	familyIPv6 ipFamily = "IPv6"
+)
+
+func (f ipFamily) contains(ip net.IP) bool {
+	if f == familyIPv6 {
+		return ip.To4() == nil
+	}
+	return ip.To4() != nil
+}
+
+// validateSameSubnet checks that the static IPs belong to the family and are in the same subnet
+func validateSameSubnet(cidrs []string, family ipFamily) error {
+	for _, c := range cidrs {
+		ip, _, err := net.ParseCIDR(c)
+		if err != nil {
+			return err
+		}
+		if !family.contains(ip) {
+			return fmt.Errorf("static %s %s is of another address family", family, c)
 		}
-		cidrs = append(cidrs, string(hostIPs[node.Name]))
 	}
 
 	//check if all static IPs are in the same subnet
 	if ok, err := sameSubnet(cidrs); !ok {
-		return fmt.Errorf("static IPs are not in the same subnet: %v", err)
+		return fmt.Errorf("static %ss are not in the same subnet: %v", family, err)
 	}
 
 	return nil
 }
 
-func validateStaticIPsForNodeWithLabel(nodes []*v1.Node, hostIPs map[string]networkv1.IPAddr, selector labels.Selector) error {
+func validateStaticIPsForAllNodes(nodes []*v1.Node, hostIPs map[string]networkv1.IPAddr, family ipFamily) error {
+	cidrs := make([]string, 0, len(hostIPs))
+	for _, node := range nodes {
+		if _, ok := hostIPs[node.Name]; !ok {
+			return fmt.Errorf("static %s not found for node %s", family, node.Name)
+		}
+		cidrs = append(cidrs, string(hostIPs[node.Name]))
+	}
+
+	return validateSameSubnet(cidrs, family)
+}
+
+func validateStaticIPsForNodeWithLabel(nodes []*v1.Node, hostIPs map[string]networkv1.IPAddr, selector labels.Selector, family ipFamily) error {
 	cidrs := make([]string, 0, len(hostIPs))
 	for _, node := range nodes {
 		matched, err := matchNode(node, selector)
@@ -260,34 +313,29 @@ func validateStaticIPsForNodeWithLabel(nodes []*v1.Node, hostIPs map[string]netw
 		if matched {
 			_, ok := hostIPs[node.Name]
 			if !ok {
-				return fmt.Errorf("static IP not found for node %s", node.Name)
+				return fmt.Errorf("static %s not found for node %s", family, node.Name)
 			}
 			cidrs = append(cidrs, string(hostIPs[node.Name]))
 		}
 	}
 
-	//check if all static IPs are in the same subnet
-	if ok, err := sameSubnet(cidrs); !ok {
-		return fmt.Errorf("static IPs are not in the same subnet: %v", err)
-	}
-
-	return nil
+	return validateSameSubnet(cidrs, family)
 }
 
-func (v *Validator) validateStaticIPs(hostIPs map[string]networkv1.IPAddr, nodeSelector *metav1.LabelSelector) error {
+func (v *Validator) validateStaticIPs(hostIPs map[string]networkv1.IPAddr, nodeSelector *metav1.LabelSelector, family ipFamily) error {
 	nodes, err := v.nodeCache.List(labels.Everything())
 	if err != nil {
 		return err
 	}
 
 	if nodeSelector == nil {
-		return validateStaticIPsForAllNodes(nodes, hostIPs)
+		return validateStaticIPsForAllNodes(nodes, hostIPs, family)
 	} else {
 		selector, err := metav1.LabelSelectorAsSelector(nodeSelector)
 		if err != nil {
 			return err
 		}
-		return validateStaticIPsForNodeWithLabel(nodes, hostIPs, selector)
+		return validateStaticIPsForNodeWithLabel(nodes, hostIPs, selector, family)
 	}
 }
 
@@ -317,11 +365,167 @@ func (v *Validator) validateHostNetworkConfig(newhnc *networkv1.HostNetworkConfi
 	}
 
 	if newhnc.Spec.Mode == IPModeStatic {
-		if err := v.validateStaticIPs(newhnc.Spec.HostIPs, newhnc.Spec.NodeSelector); err != nil {
+		if err := v.validateStaticIPs(newhnc.Spec.HostIPs, newhnc.Spec.NodeSelector, familyIPv4); err != nil {
+			return err
+		}
+	}
+
+	if newhnc.Spec.IPv6Mode == IPModeStatic {
+		if err := v.validateStaticIPs(newhnc.Spec.HostIPv6s, newhnc.Spec.NodeSelector, familyIPv6); err != nil {
+			return err
+		}
+	}
+
+	if newhnc.Spec.Mode == IPModePool {
+		if err := v.validatePool(newhnc.Spec.Pool, newhnc.Spec.NodeSelector); err != nil {
 			return err
 		}
 	}
 
+	if err := validateRoutes(&newhnc.Spec); err != nil {
+		return err
+	}
+
+	return validateDNS(newhnc.Spec.DNS)
+}
+
+// validateOverlaps rejects the host network overlapping the cluster networks, the risky overlaps are allowed but
+// logged as the admission framework can't return warnings to the client. Nothing is reported for the dry-run requests.
+func (v *Validator) validateOverlaps(hnc *networkv1.HostNetworkConfig, dryRun bool) error {
+	warnings, err := v.checkOverlaps(hnc)
+	if err != nil || dryRun {
+		return err
+	}
+
+	// the admission response carries no warnings, the risky overlaps are reported as the events of the
+	// hostnetworkconfig
+	for _, warning := range warnings {
+		logrus.Warnf("hostnetworkconfig %s: %s", hnc.Name, warning)
+		if v.recorder != nil {
+			v.recorder.Event(hnc, v1.EventTypeWarning, reasonSubnetOverlap, warning)
+		}
+	}
+
+	return nil
+}
+
+// isDryRun returns true if the request won't be persisted, the side effects of the validation must be skipped
+func isDryRun(req *admission.Request) bool {
+	return req != nil && req.Request != nil && req.DryRun != nil && *req.DryRun
+}
+
+// validatePool checks that the pool is large enough to allocate an IP to each selected node
+func (v *Validator) validatePool(pool *networkv1.IPPool, nodeSelector *metav1.LabelSelector) error {
+	ipPool, err := utils.NewIPPool(pool)
+	if err != nil {
+		return err
+	}
+
+	selector := labels.Everything()
+	if nodeSelector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(nodeSelector); err != nil {
+			return err
+		}
+	}
+
+	nodes, err := v.nodeCache.List(selector)
+	if err != nil {
+		return err
+	}
+
+	return validatePoolSize(ipPool, nodes)
+}
+
+func validatePoolSize(pool *utils.IPPool, nodes []*v1.Node) error {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+
+	if _, err := pool.Allocate(nil, names); err != nil {
+		return fmt.Errorf("pool is too small for the %d selected nodes: %w", len(names), err)
+	}
+
+	return nil
+}
+
+func familyOf(ip net.IP) ipFamily {
+	if ip.To4() != nil {
+		return familyIPv4
+	}
+	return familyIPv6
+}
+
+// staticSubnet returns the subnet of the static IPs, they have been validated to be in the same subnet
+func staticSubnet(hostIPs map[string]networkv1.IPAddr) *net.IPNet {
+	for _, ip := range hostIPs {
+		if _, subnet, err := net.ParseCIDR(string(ip)); err == nil {
+			return subnet
+		}
+	}
+	return nil
+}
+
+// validateRoutes checks that the route destinations and gateways are of the same address family which is enabled on
+// the host network, and the gateways are reachable from the static IPs
+func validateRoutes(spec *networkv1.HostNetworkConfigSpec) error {
+	for _, route := range spec.Routes {
+		_, dst, err := net.ParseCIDR(string(route.Destination))
+		if err != nil {
+			return fmt.Errorf("invalid route destination %s: %w", route.Destination, err)
+		}
+
+		family := familyOf(dst.IP)
+		mode, hostIPs := spec.Mode, spec.HostIPs
+		if family == familyIPv6 {
+			mode, hostIPs = spec.IPv6Mode, spec.HostIPv6s
+		}
+		if mode == "" {
+			return fmt.Errorf("route %s requires the %s mode to be set", route.Destination, family)
+		}
+		// the default route of the node in the main table is replaced by the default route of the same metric
+		if ones, _ := dst.Mask.Size(); ones == 0 && route.Metric == 0 && !spec.PolicyRouting {
+			return fmt.Errorf("default route %s conflicts with the default route of the node, set a metric or enable policy routing", route.Destination)
+		}
+
+		if route.Gateway == "" {
+			continue
+		}
+		gw := net.ParseIP(route.Gateway)
+		if gw == nil {
+			return fmt.Errorf("invalid gateway %s of route %s", route.Gateway, route.Destination)
+		}
+		if !family.contains(gw) {
+			return fmt.Errorf("gateway %s of route %s is of another address family", route.Gateway, route.Destination)
+		}
+		var subnet *net.IPNet
+		switch mode {
+		case IPModeStatic:
+			subnet = staticSubnet(hostIPs)
+		case IPModePool:
+			if spec.Pool != nil {
+				_, subnet, _ = net.ParseCIDR(string(spec.Pool.CIDR))
+			}
+		}
+		if subnet != nil && !subnet.Contains(gw) {
+			return fmt.Errorf("gateway %s of route %s is not in the subnet %s of the %s %ss", route.Gateway, route.Destination, subnet, mode, family)
+		}
+	}
+
+	return nil
+}
+
+func validateDNS(dns *networkv1.DNSConfig) error {
+	if dns == nil {
+		return nil
+	}
+
+	for _, ns := range dns.Nameservers {
+		if net.ParseIP(ns) == nil {
+			return fmt.Errorf("invalid nameserver %s", ns)
+		}
+	}
+
 	return nil
 }
 
@@ -4,10 +4,12 @@ import (
 	"strings"
 	"testing"
 
+	kubeovnv1 "github.com/kubeovn/kube-ovn/pkg/apis/kubeovn/v1"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	cniv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
@@ -657,7 +659,7 @@ func TestCreateHostNetworkConfig(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache)
+			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache, nil, ClusterCIDRs{}, nil)
 
 			err := validator.Create(nil, tc.newHostNetworkConfig)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -1041,7 +1043,7 @@ func TestUpdateHostNetworkConfig(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache)
+			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache, nil, ClusterCIDRs{}, nil)
 
 			err := validator.Update(nil, tc.currentHostNetworkConfig, tc.newHostNetworkConfig)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -1115,7 +1117,7 @@ func TestDeleteHostNetworkConfig(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache)
+			validator := NewHostNetworkConfigValidator(nadCache, cnCache, hncCache, vcCache, vsCache, nodeCache, vmCache, nil, ClusterCIDRs{}, nil)
 
 			err := validator.Delete(nil, tc.currentHostNetworkConfig)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -1164,6 +1166,18 @@ func TestSameSubnet(t *testing.T) {
 			wantOK:  false,
 			wantErr: false,
 		},
+		{
+			name:    "same ipv6 networks",
+			cidrs:   []string{"fd00:10::2/64", "fd00:10::3/64"},
+			wantOK:  true,
+			wantErr: false,
+		},
+		{
+			name:    "different ipv6 networks",
+			cidrs:   []string{"fd00:10::2/64", "fd00:20::2/64"},
+			wantOK:  false,
+			wantErr: false,
+		},
 		{
 			name:    "invalid first cidr",
 			cidrs:   []string{"not-a-cidr"},
@@ -1190,3 +1204,452 @@ func TestSameSubnet(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStaticIPsDualStack(t *testing.T) {
+	nodes := []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+	}
+
+	tests := []struct {
+		name    string
+		hostIPs map[string]networkv1.IPAddr
+		family  ipFamily
+		errKey  string
+	}{
+		{
+			name:    "ipv4 addresses in the same subnet",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "192.168.1.100/24", "node2": "192.168.1.101/24"},
+			family:  familyIPv4,
+		},
+		{
+			name:    "ipv6 addresses in the same subnet",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "fd00:10::100/64", "node2": "fd00:10::101/64"},
+			family:  familyIPv6,
+		},
+		{
+			name:    "ipv6 addresses not in the same subnet",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "fd00:10::100/64", "node2": "fd00:20::101/64"},
+			family:  familyIPv6,
+			errKey:  "static IPv6s are not in the same subnet",
+		},
+		{
+			name:    "ipv6 address missing for a node",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "fd00:10::100/64"},
+			family:  familyIPv6,
+			errKey:  "static IPv6 not found for node node2",
+		},
+		{
+			name:    "ipv6 address in the ipv4 addresses",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "192.168.1.100/24", "node2": "fd00:10::101/64"},
+			family:  familyIPv4,
+			errKey:  "is of another address family",
+		},
+		{
+			name:    "ipv4 address in the ipv6 addresses",
+			hostIPs: map[string]networkv1.IPAddr{"node1": "192.168.1.100/24", "node2": "fd00:10::101/64"},
+			family:  familyIPv6,
+			errKey:  "is of another address family",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateStaticIPsForAllNodes(nodes, tc.hostIPs, tc.family)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
+
+func TestValidateRoutesAndDNS(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   networkv1.HostNetworkConfigSpec
+		errKey string
+	}{
+		{
+			name: "static routes via the gateways in the subnets",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:      IPModeStatic,
+				HostIPs:   map[string]networkv1.IPAddr{"node1": "192.168.1.100/24"},
+				IPv6Mode:  IPModeStatic,
+				HostIPv6s: map[string]networkv1.IPAddr{"node1": "fd00:10::100/64"},
+				Routes: []networkv1.Route{
+					{Destination: "10.0.0.0/8", Gateway: "192.168.1.1"},
+					{Destination: "fd00:20::/64", Gateway: "fd00:10::1", Metric: 100},
+					{Destination: "192.168.2.0/24"},
+				},
+				DNS: &networkv1.DNSConfig{Nameservers: []string{"192.168.1.53", "fd00:10::53"}, Searches: []string{"storage.local"}},
+			},
+		},
+		{
+			name: "dhcp route via a gateway out of the static subnet",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:   IPModeDHCP,
+				Routes: []networkv1.Route{{Destination: "10.0.0.0/8", Gateway: "172.16.0.1"}},
+			},
+		},
+		{
+			name: "invalid destination",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:   IPModeDHCP,
+				Routes: []networkv1.Route{{Destination: "10.0.0.0"}},
+			},
+			errKey: "invalid route destination",
+		},
+		{
+			name: "ipv6 route without ipv6 mode",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:   IPModeDHCP,
+				Routes: []networkv1.Route{{Destination: "fd00:20::/64", Gateway: "fd00:10::1"}},
+			},
+			errKey: "requires the IPv6 mode to be set",
+		},
+		{
+			name: "gateway of another address family",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:     IPModeDHCP,
+				IPv6Mode: "slaac",
+				Routes:   []networkv1.Route{{Destination: "10.0.0.0/8", Gateway: "fd00:10::1"}},
+			},
+			errKey: "is of another address family",
+		},
+		{
+			name: "gateway out of the static subnet",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:    IPModeStatic,
+				HostIPs: map[string]networkv1.IPAddr{"node1": "192.168.1.100/24"},
+				Routes:  []networkv1.Route{{Destination: "10.0.0.0/8", Gateway: "192.168.2.1"}},
+			},
+			errKey: "is not in the subnet 192.168.1.0/24 of the static IPs",
+		},
+		{
+			name: "pool route via a gateway out of the pool subnet",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:   IPModePool,
+				Pool:   &networkv1.IPPool{CIDR: "172.16.0.0/24"},
+				Routes: []networkv1.Route{{Destination: "10.0.0.0/8", Gateway: "172.16.1.1"}},
+			},
+			errKey: "is not in the subnet 172.16.0.0/24 of the pool IPs",
+		},
+		{
+			name: "default routes with a metric or policy routing",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:          IPModeStatic,
+				HostIPs:       map[string]networkv1.IPAddr{"node1": "192.168.1.100/24"},
+				IPv6Mode:      "slaac",
+				PolicyRouting: true,
+				Routes: []networkv1.Route{
+					{Destination: "0.0.0.0/0", Gateway: "192.168.1.1"},
+					{Destination: "::/0", Gateway: "fd00:10::1", Metric: 2048},
+				},
+			},
+		},
+		{
+			name: "ipv4 default route conflicting with the node",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:   IPModeDHCP,
+				Routes: []networkv1.Route{{Destination: "0.0.0.0/0", Gateway: "172.16.0.1"}},
+			},
+			errKey: "default route 0.0.0.0/0 conflicts with the default route of the node",
+		},
+		{
+			name: "ipv6 default route conflicting with the node",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode:     IPModeDHCP,
+				IPv6Mode: "slaac",
+				Routes:   []networkv1.Route{{Destination: "::/0", Gateway: "fd00:10::1"}},
+			},
+			errKey: "default route ::/0 conflicts with the default route of the node",
+		},
+		{
+			name: "invalid nameserver",
+			spec: networkv1.HostNetworkConfigSpec{
+				Mode: IPModeDHCP,
+				DNS:  &networkv1.DNSConfig{Nameservers: []string{"dns.local"}},
+			},
+			errKey: "invalid nameserver dns.local",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRoutes(&tc.spec)
+			if err == nil {
+				err = validateDNS(tc.spec.DNS)
+			}
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
+
+func TestValidatePoolSize(t *testing.T) {
+	nodes := []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3"}},
+	}
+
+	tests := []struct {
+		name   string
+		pool   *networkv1.IPPool
+		errKey string
+	}{
+		{
+			name: "pool large enough for the nodes",
+			pool: &networkv1.IPPool{CIDR: "172.16.0.0/29"},
+		},
+		{
+			name:   "pool smaller than the nodes",
+			pool:   &networkv1.IPPool{CIDR: "172.16.0.0/30"},
+			errKey: "pool is too small for the 3 selected nodes",
+		},
+		{
+			name: "excludes make the pool too small",
+			pool: &networkv1.IPPool{
+				CIDR:       "172.16.0.0/24",
+				RangeStart: "172.16.0.10",
+				RangeEnd:   "172.16.0.13",
+				Exclude:    []string{"172.16.0.11", "172.16.0.12"},
+			},
+			errKey: "pool is too small for the 3 selected nodes",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pool, err := utils.NewIPPool(tc.pool)
+			assert.NoError(t, err)
+
+			err = validatePoolSize(pool, nodes)
+			if tc.errKey == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+		})
+	}
+}
+
+func TestCheckOverlaps(t *testing.T) {
+	const (
+		testNadConfigVlan100 = "{\"cniVersion\":\"0.3.1\",\"name\":\"vlan100\",\"type\":\"bridge\",\"bridge\":\"test-cn-br\",\"promiscMode\":true,\"vlan\":100,\"ipam\":{}}"
+		testNadRouteCIDR     = "{\"mode\":\"manual\",\"cidr\":\"172.16.0.0/24\",\"gateway\":\"172.16.0.1\"}"
+	)
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec:       v1.NodeSpec{PodCIDRs: []string{"10.42.1.0/24"}},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.0.10"}},
+		},
+	}
+	otherHNC := &networkv1.HostNetworkConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec: networkv1.HostNetworkConfigSpec{
+			ClusterNetwork: testCnName,
+			VlanID:         200,
+			Mode:           IPModeDHCP,
+		},
+		Status: networkv1.HostNetworkConfigStatus{
+			NodeStatus: map[string]networkv1.HostNetworkConfigNodeStatus{
+				"node1": {IPv4Addresses: []string{"172.17.0.5/24"}},
+			},
+		},
+	}
+	nad := &cniv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "vlan100",
+			Namespace:   testNamespace,
+			Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+			Annotations: map[string]string{utils.KeyNetworkRoute: testNadRouteCIDR},
+		},
+		Spec: cniv1.NetworkAttachmentDefinitionSpec{Config: testNadConfigVlan100},
+	}
+
+	staticHNC := func(vlanID uint16, cidr string) *networkv1.HostNetworkConfig {
+		return &networkv1.HostNetworkConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: currentHostNetworkName},
+			Spec: networkv1.HostNetworkConfigSpec{
+				ClusterNetwork: testCnName,
+				VlanID:         vlanID,
+				Mode:           IPModeStatic,
+				HostIPs:        map[string]networkv1.IPAddr{"node1": networkv1.IPAddr(cidr)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		hnc        *networkv1.HostNetworkConfig
+		ovnSubnets []*kubeovnv1.Subnet
+		errKey     string
+		warnKeys   []string
+	}{
+		{
+			name: "unknown subnet of dhcp mode is not checked",
+			hnc: &networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: currentHostNetworkName},
+				Spec:       networkv1.HostNetworkConfigSpec{ClusterNetwork: testCnName, VlanID: 300, Mode: IPModeDHCP},
+			},
+		},
+		{
+			name: "separate subnet",
+			hnc:  staticHNC(300, "172.18.0.10/24"),
+		},
+		{
+			name:   "subnet overlaps the service CIDR",
+			hnc:    staticHNC(300, "10.53.0.10/24"),
+			errKey: "overlaps the service CIDR 10.53.0.0/16 of the cluster",
+		},
+		{
+			name:   "subnet overlaps the pod CIDR",
+			hnc:    staticHNC(300, "10.52.0.10/8"),
+			errKey: "overlaps the pod CIDR 10.52.0.0/16 of the cluster",
+		},
+		{
+			name: "pool overlaps the pod CIDR of a node",
+			hnc: &networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: currentHostNetworkName},
+				Spec: networkv1.HostNetworkConfigSpec{
+					ClusterNetwork: testCnName,
+					VlanID:         300,
+					Mode:           IPModePool,
+					Pool:           &networkv1.IPPool{CIDR: "10.42.1.128/25"},
+				},
+			},
+			errKey: "overlaps the pod CIDR 10.42.1.0/24 of node node1",
+		},
+		{
+			name:   "subnet overlaps the leased addresses of another host network",
+			hnc:    staticHNC(300, "172.17.0.10/16"),
+			errKey: "overlaps the subnet 172.17.0.0/24 of hostnetworkconfig other",
+		},
+		{
+			name:   "static IP is the internal IP of a node",
+			hnc:    staticHNC(300, "192.168.0.10/24"),
+			errKey: "static IP 192.168.0.10 of node node1 is the internal IP of node node1",
+		},
+		{
+			name: "pool contains the internal IP of a node",
+			hnc: &networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: currentHostNetworkName},
+				Spec: networkv1.HostNetworkConfigSpec{
+					ClusterNetwork: testCnName,
+					VlanID:         300,
+					Mode:           IPModePool,
+					Pool:           &networkv1.IPPool{CIDR: "192.168.0.0/24"},
+				},
+			},
+			errKey: "pool 192.168.0.0/24 contains the internal IP 192.168.0.10 of node node1",
+		},
+		{
+			name: "pool excluding the internal IP of a node shares the subnet",
+			hnc: &networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: currentHostNetworkName},
+				Spec: networkv1.HostNetworkConfigSpec{
+					ClusterNetwork: testCnName,
+					VlanID:         300,
+					Mode:           IPModePool,
+					Pool:           &networkv1.IPPool{CIDR: "192.168.0.0/24", RangeStart: "192.168.0.100"},
+				},
+			},
+			warnKeys: []string{"contains the internal IP 192.168.0.10 of node node1"},
+		},
+		{
+			name: "subnet overlaps a kube-ovn subnet of the default vpc",
+			hnc:  staticHNC(300, "172.20.0.10/24"),
+			ovnSubnets: []*kubeovnv1.Subnet{{
+				ObjectMeta: metav1.ObjectMeta{Name: "vswitch1"},
+				Spec:       kubeovnv1.SubnetSpec{Vpc: "ovn-cluster", CIDRBlock: "172.20.0.0/16"},
+			}},
+			errKey: "overlaps the CIDR 172.20.0.0/16 of kube-ovn subnet vswitch1",
+		},
+		{
+			name: "subnet overlaps a kube-ovn subnet of a custom vpc",
+			hnc:  staticHNC(300, "172.20.0.10/24"),
+			ovnSubnets: []*kubeovnv1.Subnet{{
+				ObjectMeta: metav1.ObjectMeta{Name: "vswitch2"},
+				Spec:       kubeovnv1.SubnetSpec{Vpc: "vpc1", CIDRBlock: "10.0.0.0/24,172.20.0.0/24"},
+			}},
+			warnKeys: []string{"overlaps the CIDR 172.20.0.0/24 of kube-ovn subnet vswitch2 in vpc vpc1"},
+		},
+		{
+			name:     "subnet overlaps the CIDR of a VM network on another vlan",
+			hnc:      staticHNC(300, "172.16.0.10/24"),
+			warnKeys: []string{"overlaps the CIDR 172.16.0.0/24 of nad test/vlan100 on vlan 100"},
+		},
+		{
+			name: "subnet shares the CIDR of the VM network on the same vlan",
+			hnc:  staticHNC(100, "172.16.0.10/24"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			nadCache := fakeclients.NetworkAttachmentDefinitionCache(nchclientset.K8sCniCncfIoV1().NetworkAttachmentDefinitions)
+			hncCache := fakeclients.HostNetworkConfigCache(nchclientset.NetworkV1beta1().HostNetworkConfigs)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
+			subnetCache := fakeclients.SubnetCache(nchclientset.KubeovnV1().Subnets)
+
+			_, err := fakeclients.NodeClient(nchclientset.CoreV1().Nodes).Create(node)
+			assert.NoError(t, err)
+			_, err = fakeclients.HostNetworkConfigClient(nchclientset.NetworkV1beta1().HostNetworkConfigs).Create(otherHNC)
+			assert.NoError(t, err)
+			nadGvr := schema.GroupVersionResource{
+				Group:    "k8s.cni.cncf.io",
+				Version:  "v1",
+				Resource: "network-attachment-definitions",
+			}
+			assert.NoError(t, nchclientset.Tracker().Create(nadGvr, nad.DeepCopy(), nad.Namespace))
+			for _, ovnSubnet := range tc.ovnSubnets {
+				assert.NoError(t, nchclientset.Tracker().Add(ovnSubnet))
+			}
+
+			recorder := record.NewFakeRecorder(len(tc.warnKeys) + 1)
+			validator := NewHostNetworkConfigValidator(nadCache, nil, hncCache, nil, nil, nodeCache, nil, subnetCache, ClusterCIDRs{
+				PodCIDRs:     []string{"10.52.0.0/16"},
+				ServiceCIDRs: []string{"10.53.0.0/16"},
+			}, recorder)
+
+			warnings, err := validator.checkOverlaps(tc.hnc)
+			if tc.errKey != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.errKey)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, warnings, len(tc.warnKeys))
+			for i, key := range tc.warnKeys {
+				if i < len(warnings) {
+					assert.Contains(t, warnings[i], key)
+				}
+			}
+
+			// the warnings are reported as the events of the hostnetworkconfig except for the dry-run requests
+			assert.NoError(t, validator.validateOverlaps(tc.hnc, true))
+			assert.Len(t, recorder.Events, 0)
+			assert.NoError(t, validator.validateOverlaps(tc.hnc, false))
+			assert.Len(t, recorder.Events, len(tc.warnKeys))
+			for _, key := range tc.warnKeys {
+				assert.Contains(t, <-recorder.Events, key)
+			}
+		})
+	}
+}
@@ -246,14 +246,12 @@ func (m *Mutator) patchMTU(nad *cniv1.NetworkAttachmentDefinition) (admission.Pa
 	getMtu := false
 
 	// get MTU from clusternetwork
-	if mtuStr, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
-		mtu, err := utils.GetMTUFromString(mtuStr)
-		if err != nil {
-			return nil, fmt.Errorf("nad's host cluster network %v has invalid MTU annotation %v/%v %w", cn.Name, utils.KeyUplinkMTU, mtuStr, err)
-		}
-		if mtu != 0 {
-			targetMTU = mtu
-		}
+	mtu, err := utils.GetMTUFromClusterNetwork(cn)
+	if err != nil {
+		return nil, fmt.Errorf("nad's host %w", err)
+	}
+	if mtu != 0 {
+		targetMTU = mtu
 		getMtu = true
 	}
 
@@ -235,20 +235,22 @@ func (v *Validator) checkNadConfig(nadConf *utils.NetConf, nad *cniv1.NetworkAtt
 		return fmt.Errorf("nad refers to a none-existing cluster network %s or error %w", cnName, err)
 	}
 
+	if err := utils.CheckVlansAllowedOnClusterNetwork(cn, nadConf); err != nil {
+		return err
+	}
+
 	// for new NAD, the mutator patchs the MTU
 	// for updated NAD, the MTU should keep same with cluster network
 	targetMTU := utils.DefaultMTU
 	getMtu := false
 
 	// get MTU from clusternetwork
-	if mtuStr, ok := cn.Annotations[utils.KeyUplinkMTU]; ok {
-		mtu, err := utils.GetMTUFromString(mtuStr)
-		if err != nil {
-			return fmt.Errorf("nad's host cluster network %v has invalid MTU annotation %v/%v %w", cn.Name, utils.KeyUplinkMTU, mtuStr, err)
-		}
-		if mtu != 0 {
-			targetMTU = mtu
-		}
+	mtu, err := utils.GetMTUFromClusterNetwork(cn)
+	if err != nil {
+		return fmt.Errorf("nad's host %w", err)
+	}
+	if mtu != 0 {
+		targetMTU = mtu
 		getMtu = true
 	}
 
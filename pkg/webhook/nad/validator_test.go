@@ -78,6 +78,52 @@ func TestCreateNAD(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "NAD can't be created as its vlan is not allowed on the cluster network",
+			returnErr: true,
+			errKey:    "not in the allowed vlan ranges",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 200}},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: testNadConfig,
+				},
+			},
+		},
+		{
+			name:      "NAD can be created as its vlan is allowed on the cluster network",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testCnName,
+				},
+				Spec: networkv1.ClusterNetworkSpec{
+					AllowedVlanRanges: []networkv1.VlanRange{{Start: 100, End: 200}, {Start: 300}},
+				},
+			},
+			newNAD: &cniv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testNadName,
+					Namespace: testNamespace,
+					Labels:    map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: cniv1.NetworkAttachmentDefinitionSpec{
+					Config: testNadConfig,
+				},
+			},
+		},
 		{
 			name:      "valid NAD can be created when it does not have label",
 			returnErr: false,
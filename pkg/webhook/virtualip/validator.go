@@ -0,0 +1,139 @@
+package virtualip
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/harvester/webhook/pkg/server/admission"
+	admissionregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	ctlnetworkv1 "github.com/harvester/harvester-network-controller/pkg/generated/controllers/network.harvesterhci.io/v1beta1"
+)
+
+const (
+	createErr    = "can't create virtual ip %s because %w"
+	updateErr    = "can't update virtual ip %s because %w"
+	ipModeStatic = "static"
+)
+
+type Validator struct {
+	admission.DefaultValidator
+
+	hncCache ctlnetworkv1.HostNetworkConfigCache
+	vipCache ctlnetworkv1.VirtualIPCache
+}
+
+func NewVirtualIPValidator(hncCache ctlnetworkv1.HostNetworkConfigCache, vipCache ctlnetworkv1.VirtualIPCache) *Validator {
+	return &Validator{
+		hncCache: hncCache,
+		vipCache: vipCache,
+	}
+}
+
+var _ admission.Validator = &Validator{}
+
+func (v *Validator) Create(_ *admission.Request, newObj runtime.Object) error {
+	vip := newObj.(*networkv1.VirtualIP)
+
+	if err := v.validate(vip); err != nil {
+		return fmt.Errorf(createErr, vip.Name, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) Update(_ *admission.Request, _, newObj runtime.Object) error {
+	vip := newObj.(*networkv1.VirtualIP)
+
+	if vip.DeletionTimestamp != nil {
+		return nil
+	}
+
+	if err := v.validate(vip); err != nil {
+		return fmt.Errorf(updateErr, vip.Name, err)
+	}
+
+	return nil
+}
+
+func (v *Validator) validate(vip *networkv1.VirtualIP) error {
+	ip, ipNet, err := net.ParseCIDR(string(vip.Spec.Address))
+	if err != nil {
+		return fmt.Errorf("invalid address %s, error: %w", vip.Spec.Address, err)
+	}
+	// gratuitous ARP only announces IPv4 addresses
+	if ip.To4() == nil {
+		return fmt.Errorf("address %s is not IPv4", vip.Spec.Address)
+	}
+
+	if vip.Spec.NodeSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(vip.Spec.NodeSelector); err != nil {
+			return fmt.Errorf("invalid node selector, error: %w", err)
+		}
+	}
+
+	hnc, err := v.hncCache.Get(vip.Spec.HostNetworkConfig)
+	if err != nil {
+		return fmt.Errorf("it refers to a none-existing host network config %s or error %w", vip.Spec.HostNetworkConfig, err)
+	}
+	if hnc.Spec.Mode == ipModeStatic {
+		if err := checkStaticHostIPs(ip, ipNet, hnc); err != nil {
+			return err
+		}
+	}
+
+	vips, err := v.vipCache.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, other := range vips {
+		if other.Name == vip.Name || other.DeletionTimestamp != nil {
+			continue
+		}
+		otherIP, _, err := net.ParseCIDR(string(other.Spec.Address))
+		if err != nil {
+			continue
+		}
+		if otherIP.Equal(ip) {
+			return fmt.Errorf("address %s is used by virtual ip %s", ip, other.Name)
+		}
+	}
+
+	return nil
+}
+
+// checkStaticHostIPs checks that the virtual IP is in the same subnet as the static host IPs, and it isn't one of them
+func checkStaticHostIPs(ip net.IP, ipNet *net.IPNet, hnc *networkv1.HostNetworkConfig) error {
+	for node, hostIP := range hnc.Spec.HostIPs {
+		addr, hostNet, err := net.ParseCIDR(string(hostIP))
+		if err != nil {
+			continue
+		}
+		if addr.Equal(ip) {
+			return fmt.Errorf("address %s is the host IP of node %s in host network config %s", ip, node, hnc.Name)
+		}
+		if hostNet.String() != ipNet.String() {
+			return fmt.Errorf("address %s is not in the subnet %s of host network config %s", ipNet, hostNet, hnc.Name)
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) Resource() admission.Resource {
+	return admission.Resource{
+		Names:      []string{"virtualips"},
+		Scope:      admissionregv1.ClusterScope,
+		APIGroup:   networkv1.SchemeGroupVersion.Group,
+		APIVersion: networkv1.SchemeGroupVersion.Version,
+		ObjectType: &networkv1.VirtualIP{},
+		OperationTypes: []admissionregv1.OperationType{
+			admissionregv1.Create,
+			admissionregv1.Update,
+		},
+	}
+}
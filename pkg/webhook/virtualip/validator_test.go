@@ -0,0 +1,146 @@
+package virtualip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	networkv1 "github.com/harvester/harvester-network-controller/pkg/apis/network.harvesterhci.io/v1beta1"
+	"github.com/harvester/harvester-network-controller/pkg/generated/clientset/versioned/fake"
+	"github.com/harvester/harvester-network-controller/pkg/utils/fakeclients"
+)
+
+const (
+	testHncName    = "storage"
+	testDhcpHnc    = "backup"
+	testVipName    = "storage-vip"
+	testOtherVip   = "other-vip"
+	testCnName     = "test-cn"
+	testNodeName   = "node1"
+	testVlanID     = 100
+	testVipAddress = "172.16.100.10/24"
+)
+
+func newVirtualIP(name, hnc, address string) *networkv1.VirtualIP {
+	return &networkv1.VirtualIP{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: networkv1.VirtualIPSpec{
+			HostNetworkConfig: hnc,
+			Address:           networkv1.IPAddr(address),
+		},
+	}
+}
+
+func TestValidateVirtualIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		vip       *networkv1.VirtualIP
+		returnErr bool
+		errKey    string
+	}{
+		{
+			name: "valid virtual ip on a static host network",
+			vip:  newVirtualIP(testVipName, testHncName, testVipAddress),
+		},
+		{
+			name: "valid virtual ip on a dhcp host network",
+			vip:  newVirtualIP(testVipName, testDhcpHnc, "172.16.200.10/24"),
+		},
+		{
+			name:      "invalid address",
+			vip:       newVirtualIP(testVipName, testHncName, "172.16.100.10"),
+			returnErr: true,
+			errKey:    "invalid address",
+		},
+		{
+			name:      "IPv6 address is not supported",
+			vip:       newVirtualIP(testVipName, testHncName, "fd00::10/64"),
+			returnErr: true,
+			errKey:    "is not IPv4",
+		},
+		{
+			name:      "host network config doesn't exist",
+			vip:       newVirtualIP(testVipName, "absent", testVipAddress),
+			returnErr: true,
+			errKey:    "none-existing host network config",
+		},
+		{
+			name:      "address is a static host IP",
+			vip:       newVirtualIP(testVipName, testHncName, "172.16.100.2/24"),
+			returnErr: true,
+			errKey:    "is the host IP of node",
+		},
+		{
+			name:      "address is out of the subnet of static host IPs",
+			vip:       newVirtualIP(testVipName, testHncName, "172.16.101.10/24"),
+			returnErr: true,
+			errKey:    "is not in the subnet",
+		},
+		{
+			name:      "address is used by another virtual ip",
+			vip:       newVirtualIP(testVipName, testHncName, "172.16.100.20/24"),
+			returnErr: true,
+			errKey:    "is used by virtual ip",
+		},
+		{
+			name: "invalid node selector",
+			vip: func() *networkv1.VirtualIP {
+				vip := newVirtualIP(testVipName, testHncName, testVipAddress)
+				vip.Spec.NodeSelector = &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "zone", Operator: "Invalid"}},
+				}
+				return vip
+			}(),
+			returnErr: true,
+			errKey:    "invalid node selector",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			nchclientset := fake.NewSimpleClientset()
+			hncClient := fakeclients.HostNetworkConfigClient(nchclientset.NetworkV1beta1().HostNetworkConfigs)
+			vipClient := fakeclients.VirtualIPClient(nchclientset.NetworkV1beta1().VirtualIPs)
+			hncCache := fakeclients.HostNetworkConfigCache(nchclientset.NetworkV1beta1().HostNetworkConfigs)
+			vipCache := fakeclients.VirtualIPCache(nchclientset.NetworkV1beta1().VirtualIPs)
+
+			_, err := hncClient.Create(&networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testHncName},
+				Spec: networkv1.HostNetworkConfigSpec{
+					ClusterNetwork: testCnName,
+					VlanID:         testVlanID,
+					Mode:           ipModeStatic,
+					HostIPs:        map[string]networkv1.IPAddr{testNodeName: "172.16.100.2/24"},
+				},
+			})
+			assert.NoError(t, err)
+			_, err = hncClient.Create(&networkv1.HostNetworkConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testDhcpHnc},
+				Spec: networkv1.HostNetworkConfigSpec{
+					ClusterNetwork: testCnName,
+					VlanID:         testVlanID + 1,
+					Mode:           "dhcp",
+				},
+			})
+			assert.NoError(t, err)
+			_, err = vipClient.Create(newVirtualIP(testOtherVip, testHncName, "172.16.100.20/24"))
+			assert.NoError(t, err)
+
+			validator := NewVirtualIPValidator(hncCache, vipCache)
+
+			err = validator.Create(nil, tc.vip)
+			assert.Equal(t, tc.returnErr, err != nil)
+			if tc.returnErr && err != nil {
+				assert.Contains(t, err.Error(), tc.errKey)
+			}
+
+			// the virtual ip itself is skipped when checking the duplicated address
+			if !tc.returnErr {
+				_, err = vipClient.Create(tc.vip)
+				assert.NoError(t, err)
+				assert.NoError(t, validator.Update(nil, tc.vip, tc.vip))
+			}
+		})
+	}
+}
@@ -3,11 +3,14 @@ package vlanconfig
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
+	"slices"
 	"strings"
 
 	mapset "github.com/deckarep/golang-set/v2"
 	"github.com/harvester/webhook/pkg/server/admission"
+	ctlcorev1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	admissionregv1 "k8s.io/api/admissionregistration/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
@@ -24,16 +27,20 @@ const (
 	createErr = "can't create vlanConfig %s because %w"
 	updateErr = "can't update vlanConfig %s because %w"
 	deleteErr = "can't delete vlanConfig %s because %w"
+
+	maxBondArpIPTargets = 16
 )
 
 type Validator struct {
 	admission.DefaultValidator
 
-	nadCache ctlcniv1.NetworkAttachmentDefinitionCache
-	vcCache  ctlnetworkv1.VlanConfigCache
-	vsCache  ctlnetworkv1.VlanStatusCache
-	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache
-	cnCache  ctlnetworkv1.ClusterNetworkCache
+	nadCache  ctlcniv1.NetworkAttachmentDefinitionCache
+	vcCache   ctlnetworkv1.VlanConfigCache
+	vsCache   ctlnetworkv1.VlanStatusCache
+	vmiCache  ctlkubevirtv1.VirtualMachineInstanceCache
+	cnCache   ctlnetworkv1.ClusterNetworkCache
+	lmCache   ctlnetworkv1.LinkMonitorCache
+	nodeCache ctlcorev1.NodeCache
 }
 
 func NewVlanConfigValidator(
@@ -42,13 +49,17 @@ func NewVlanConfigValidator(
 	vsCache ctlnetworkv1.VlanStatusCache,
 	vmiCache ctlkubevirtv1.VirtualMachineInstanceCache,
 	cnCache ctlnetworkv1.ClusterNetworkCache,
+	lmCache ctlnetworkv1.LinkMonitorCache,
+	nodeCache ctlcorev1.NodeCache,
 ) *Validator {
 	return &Validator{
-		nadCache: nadCache,
-		vcCache:  vcCache,
-		vsCache:  vsCache,
-		vmiCache: vmiCache,
-		cnCache:  cnCache,
+		nadCache:  nadCache,
+		vcCache:   vcCache,
+		vsCache:   vsCache,
+		vmiCache:  vmiCache,
+		cnCache:   cnCache,
+		lmCache:   lmCache,
+		nodeCache: nodeCache,
 	}
 }
 
@@ -76,6 +87,10 @@ func (v *Validator) Create(req *admission.Request, newObj runtime.Object) error
 		return fmt.Errorf(createErr, vc.Name, err)
 	}
 
+	if err := validateUplinks(vc); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
 	// note: the mutator has patched the Annotations[utils.KeyMatchedNodes] if selector is set and exclude the witness-node
 	nodes, err := getMatchNodes(vc)
 	if err != nil {
@@ -86,6 +101,10 @@ func (v *Validator) Create(req *admission.Request, newObj runtime.Object) error
 		return fmt.Errorf(createErr, vc.Name, err)
 	}
 
+	if err := v.checkNICSelector(vc, nodes); err != nil {
+		return fmt.Errorf(createErr, vc.Name, err)
+	}
+
 	return nil
 }
 
@@ -118,6 +137,10 @@ func (v *Validator) Update(req *admission.Request, oldObj, newObj runtime.Object
 		return fmt.Errorf(updateErr, newVc.Name, err)
 	}
 
+	if err := validateUplinks(newVc); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
 	// note: the mutator has patched the Annotations[utils.KeyMatchedNodes] if selector is set and exclude the witness-node
 	newNodes, err := getMatchNodes(newVc)
 	if err != nil {
@@ -128,6 +151,10 @@ func (v *Validator) Update(req *admission.Request, oldObj, newObj runtime.Object
 		return fmt.Errorf(updateErr, newVc.Name, err)
 	}
 
+	if err := v.checkNICSelector(newVc, newNodes); err != nil {
+		return fmt.Errorf(updateErr, newVc.Name, err)
+	}
+
 	oldNodes, err := getMatchNodes(oldVc)
 	if err != nil {
 		return fmt.Errorf(updateErr, oldVc.Name, err)
@@ -150,7 +177,8 @@ func (v *Validator) Update(req *admission.Request, oldObj, newObj runtime.Object
 
 func getAffectedNodes(oldVc, newVc *networkv1.VlanConfig, oldNodes, newNodes mapset.Set[string]) mapset.Set[string] {
 	// when vlanconfig's MTU/uplink/... is changed, all oldNodes are always affected, all vmis on them should be stopped
-	if (oldVc.Spec.ClusterNetwork != newVc.Spec.ClusterNetwork) || !reflect.DeepEqual(oldVc.Spec.Uplink, newVc.Spec.Uplink) {
+	if (oldVc.Spec.ClusterNetwork != newVc.Spec.ClusterNetwork) || !reflect.DeepEqual(oldVc.Spec.Uplink, newVc.Spec.Uplink) ||
+		!reflect.DeepEqual(oldVc.Spec.NodeOverrides, newVc.Spec.NodeOverrides) {
 		return oldNodes
 	}
 
@@ -291,6 +319,213 @@ func (v *Validator) validateMTU(current *networkv1.VlanConfig) error {
 	return nil
 }
 
+// checkNICSelector confirms the nic selector of the effective uplink matches NICs on each node according to the nic link monitor
+// the nodes which have not reported their NICs are skipped
+func (v *Validator) checkNICSelector(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
+	if !hasNICSelector(vc) || nodes == nil || nodes.Cardinality() == 0 {
+		return nil
+	}
+
+	lm, err := v.lmCache.Get(utils.NICLinkMonitorName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	unmatchedNodes := make([]string, 0)
+	for nodeName := range nodes.Iter() {
+		linkStatusList, ok := lm.Status.LinkStatus[nodeName]
+		if !ok {
+			continue
+		}
+		uplink := &vc.Spec.Uplink
+		if len(vc.Spec.NodeOverrides) > 0 {
+			node, err := v.nodeCache.Get(nodeName)
+			if err != nil {
+				return err
+			}
+			uplink, _ = utils.GetEffectiveUplink(vc, node)
+		}
+		if uplink.NICSelector == nil {
+			continue
+		}
+		nics := make([]utils.NICInfo, len(linkStatusList))
+		for i := range linkStatusList {
+			nics[i] = utils.NewNICInfoFromLinkStatus(&linkStatusList[i])
+		}
+		matched, err := utils.MatchNICs(uplink.NICSelector, nics)
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			unmatchedNodes = append(unmatchedNodes, nodeName)
+		}
+	}
+
+	if len(unmatchedNodes) > 0 {
+		slices.Sort(unmatchedNodes)
+		return fmt.Errorf("nicSelector matches no NICs on node(s) %v", unmatchedNodes)
+	}
+
+	return nil
+}
+
+func hasNICSelector(vc *networkv1.VlanConfig) bool {
+	if vc.Spec.Uplink.NICSelector != nil {
+		return true
+	}
+	for _, o := range vc.Spec.NodeOverrides {
+		if o.NICSelector != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateUplinks validates the uplink and the uplink merged with each node override
+func validateUplinks(vc *networkv1.VlanConfig) error {
+	if vc.Spec.ClusterNetwork == utils.ManagementClusterNetworkName {
+		return nil
+	}
+	if err := validateUplink(&vc.Spec.Uplink); err != nil {
+		return err
+	}
+
+	mtu := utils.GetMTUFromVlanConfig(vc)
+	names := mapset.NewSet[string]()
+	for i := range vc.Spec.NodeOverrides {
+		o := &vc.Spec.NodeOverrides[i]
+		if o.Name == "" {
+			return fmt.Errorf("node override %d has no name", i)
+		}
+		if !names.Add(o.Name) {
+			return fmt.Errorf("node override name %s is duplicated", o.Name)
+		}
+		if (o.NodeName == "") == (len(o.NodeSelector) == 0) {
+			return fmt.Errorf("node override %s must set either nodeName or nodeSelector", o.Name)
+		}
+		if len(o.NICs) > 0 && o.NICSelector != nil {
+			return fmt.Errorf("node override %s can't set nics and nicSelector together", o.Name)
+		}
+		if o.LinkAttrs != nil && !utils.AreEqualMTUs(o.LinkAttrs.MTU, mtu) {
+			return fmt.Errorf("node override %s MTU %v is different with the uplink MTU %v", o.Name, o.LinkAttrs.MTU, mtu)
+		}
+		if err := validateUplink(utils.MergeUplink(&vc.Spec.Uplink, o)); err != nil {
+			return fmt.Errorf("node override %s is invalid, error: %w", o.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func validateUplink(uplink *networkv1.Uplink) error {
+	if len(uplink.NICs) > 0 && uplink.NICSelector != nil {
+		return fmt.Errorf("nics and nicSelector can't be set together")
+	}
+	if err := utils.ValidateNICSelector(uplink.NICSelector); err != nil {
+		return err
+	}
+	return validateBondOptions(uplink)
+}
+
+// validateBondOptions rejects the bonding options which don't work together
+// reference: https://www.kernel.org/doc/Documentation/networking/bonding.txt
+func validateBondOptions(uplink *networkv1.Uplink) error {
+	opts := uplink.BondOptions
+	if opts == nil {
+		return nil
+	}
+
+	mode := opts.Mode
+	if mode == "" {
+		mode = networkv1.BondMoDeActiveBackup
+	}
+	modeIn := func(modes ...networkv1.BondMode) bool {
+		return slices.Contains(modes, mode)
+	}
+
+	if opts.LacpRate != "" && mode != networkv1.BondMode8023AD {
+		return fmt.Errorf("lacpRate is only supported in %s mode", networkv1.BondMode8023AD)
+	}
+	if opts.AdSelect != "" && mode != networkv1.BondMode8023AD {
+		return fmt.Errorf("adSelect is only supported in %s mode", networkv1.BondMode8023AD)
+	}
+	if opts.XmitHashPolicy != "" && !modeIn(networkv1.BondModeBalanceXor, networkv1.BondMode8023AD, networkv1.BondModeBalanceTlb) {
+		return fmt.Errorf("xmitHashPolicy is not supported in %s mode", mode)
+	}
+	if opts.FailOverMac != "" && mode != networkv1.BondMoDeActiveBackup {
+		return fmt.Errorf("failOverMac is only supported in %s mode", networkv1.BondMoDeActiveBackup)
+	}
+
+	if opts.Primary != "" {
+		if !modeIn(networkv1.BondMoDeActiveBackup, networkv1.BondModeBalanceTlb, networkv1.BondModeBalanceAlb) {
+			return fmt.Errorf("primary is not supported in %s mode", mode)
+		}
+		// the NICs selected by the nic selector are only known on each node
+		if uplink.NICSelector == nil && !slices.Contains(uplink.NICs, opts.Primary) {
+			return fmt.Errorf("primary %s is not one of the uplink NICs %v", opts.Primary, uplink.NICs)
+		}
+	} else if opts.PrimaryReselect != "" {
+		return fmt.Errorf("primaryReselect requires primary to be set")
+	}
+
+	if opts.ArpInterval > 0 {
+		return validateBondArpMonitoring(opts, mode)
+	}
+	if len(opts.ArpIPTargets) > 0 {
+		return fmt.Errorf("arpIPTargets requires arpInterval to be set")
+	}
+
+	// updelay and downdelay work with MII monitoring and are rounded down to a multiple of miimon by the kernel
+	miimon := opts.Miimon
+	if miimon == -1 {
+		miimon = utils.DefaultValueMiimon
+	}
+	if err := checkBondDelay("updelay", opts.UpDelay, miimon); err != nil {
+		return err
+	}
+	return checkBondDelay("downdelay", opts.DownDelay, miimon)
+}
+
+func checkBondDelay(name string, delay, miimon int) error {
+	if delay == 0 {
+		return nil
+	}
+	if miimon == 0 {
+		return fmt.Errorf("%s requires miimon to be set", name)
+	}
+	if delay%miimon != 0 {
+		return fmt.Errorf("%s %v is not a multiple of miimon %v", name, delay, miimon)
+	}
+	return nil
+}
+
+func validateBondArpMonitoring(opts *networkv1.BondOptions, mode networkv1.BondMode) error {
+	if opts.Miimon > 0 {
+		return fmt.Errorf("arpInterval can't be used together with miimon")
+	}
+	if mode == networkv1.BondMode8023AD || mode == networkv1.BondModeBalanceTlb || mode == networkv1.BondModeBalanceAlb {
+		return fmt.Errorf("arpInterval is not supported in %s mode", mode)
+	}
+	if opts.UpDelay > 0 || opts.DownDelay > 0 {
+		return fmt.Errorf("updelay and downdelay can't be used together with arpInterval")
+	}
+	if len(opts.ArpIPTargets) == 0 {
+		return fmt.Errorf("arpIPTargets must be set when arpInterval is set")
+	}
+	if len(opts.ArpIPTargets) > maxBondArpIPTargets {
+		return fmt.Errorf("arpIPTargets can have at most %v addresses", maxBondArpIPTargets)
+	}
+	for _, target := range opts.ArpIPTargets {
+		if ip := net.ParseIP(target); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("arpIPTarget %s is not a valid IPv4 address", target)
+		}
+	}
+	return nil
+}
+
 // checkNetworkNadsAttached checks if storage network or rwx network nads are still attached
 func (v *Validator) checkNetworkNadsAttached(vc *networkv1.VlanConfig, nodes mapset.Set[string]) error {
 	if nodes == nil || nodes.Cardinality() == 0 {
@@ -1,6 +1,7 @@
 package vlanconfig
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -39,6 +40,7 @@ func TestCreateVlanConfig(t *testing.T) {
 		currentVC  *networkv1.VlanConfig
 		currentVS  *networkv1.VlanStatus
 		currentNAD *cniv1.NetworkAttachmentDefinition
+		currentLM  *networkv1.LinkMonitor
 		newVC      *networkv1.VlanConfig
 		userReq    bool
 	}{
@@ -279,6 +281,167 @@ func TestCreateVlanConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "VlanConfig can be created as nic selector matches NICs on all nodes",
+			returnErr: false,
+			errKey:    "",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentLM: &networkv1.LinkMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.NICLinkMonitorName,
+				},
+				Status: networkv1.LinkMonitorStatus{
+					LinkStatus: map[string][]networkv1.LinkStatus{
+						"node1": {
+							{Name: "ens3f0", Type: "device", MAC: "52:54:00:00:00:01", PCIAddress: "0000:41:00.0", Driver: "ixgbe"},
+							{Name: "ens3f1", Type: "device", MAC: "52:54:00:00:00:02", PCIAddress: "0000:41:00.1", Driver: "ixgbe"},
+						},
+						"node2": {
+							{Name: "enp65s0f0", Type: "device", MAC: "52:54:00:00:00:03", PCIAddress: "0000:41:00.0", Driver: "ice"},
+						},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICSelector: &networkv1.NICSelector{PCIAddresses: []string{"0000:41:00.0"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as nic selector matches no NICs on a node",
+			returnErr: true,
+			errKey:    "node2",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentLM: &networkv1.LinkMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.NICLinkMonitorName,
+				},
+				Status: networkv1.LinkMonitorStatus{
+					LinkStatus: map[string][]networkv1.LinkStatus{
+						"node1": {
+							{Name: "ens3f0", Type: "device", MAC: "52:54:00:00:00:01", PCIAddress: "0000:41:00.0", Driver: "ixgbe"},
+							{Name: "ens3f1", Type: "device", MAC: "52:54:00:00:00:02", PCIAddress: "0000:41:00.1", Driver: "ixgbe"},
+						},
+						"node2": {
+							{Name: "enp65s0f0", Type: "device", MAC: "52:54:00:00:00:03", PCIAddress: "0000:41:00.0", Driver: "ice"},
+						},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICSelector: &networkv1.NICSelector{Drivers: []string{"ixgbe"}, MaxCount: 1},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as nics and nic selector are both set",
+			returnErr: true,
+			errKey:    "together",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentLM: &networkv1.LinkMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.NICLinkMonitorName,
+				},
+				Status: networkv1.LinkMonitorStatus{
+					LinkStatus: map[string][]networkv1.LinkStatus{
+						"node1": {
+							{Name: "ens3f0", Type: "device", MAC: "52:54:00:00:00:01", PCIAddress: "0000:41:00.0", Driver: "ixgbe"},
+							{Name: "ens3f1", Type: "device", MAC: "52:54:00:00:00:02", PCIAddress: "0000:41:00.1", Driver: "ixgbe"},
+						},
+						"node2": {
+							{Name: "enp65s0f0", Type: "device", MAC: "52:54:00:00:00:03", PCIAddress: "0000:41:00.0", Driver: "ice"},
+						},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:        []string{"ens3f0"},
+						NICSelector: &networkv1.NICSelector{Drivers: []string{"ixgbe"}},
+					},
+				},
+			},
+		},
+		{
+			name:      "VlanConfig can't be created as nic selector has an invalid regex",
+			returnErr: true,
+			errKey:    "regex",
+			currentCN: &networkv1.ClusterNetwork{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testCnName,
+					Annotations: map[string]string{"test": "test"},
+				},
+			},
+			currentLM: &networkv1.LinkMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.NICLinkMonitorName,
+				},
+				Status: networkv1.LinkMonitorStatus{
+					LinkStatus: map[string][]networkv1.LinkStatus{
+						"node1": {
+							{Name: "ens3f0", Type: "device", MAC: "52:54:00:00:00:01", PCIAddress: "0000:41:00.0", Driver: "ixgbe"},
+							{Name: "ens3f1", Type: "device", MAC: "52:54:00:00:00:02", PCIAddress: "0000:41:00.1", Driver: "ixgbe"},
+						},
+						"node2": {
+							{Name: "enp65s0f0", Type: "device", MAC: "52:54:00:00:00:03", PCIAddress: "0000:41:00.0", Driver: "ice"},
+						},
+					},
+				},
+			},
+			newVC: &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testNewVCName,
+					Annotations: map[string]string{utils.KeyMatchedNodes: "[\"node1\",\"node2\"]"},
+					Labels:      map[string]string{utils.KeyClusterNetworkLabel: testCnName},
+				},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICSelector: &networkv1.NICSelector{NameRegex: "ens3f[0-"},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -295,6 +458,8 @@ func TestCreateVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -313,7 +478,11 @@ func TestCreateVlanConfig(t *testing.T) {
 				_, err := vsClient.Create(tc.currentVS)
 				assert.NoError(t, err)
 			}
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			if tc.currentLM != nil {
+				_, err := nchclientset.NetworkV1beta1().LinkMonitors().Create(context.TODO(), tc.currentLM, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, lmCache, nodeCache)
 
 			var username string
 			if tc.userReq {
@@ -684,6 +853,8 @@ func TestUpdateVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -729,7 +900,7 @@ func TestUpdateVlanConfig(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, lmCache, nodeCache)
 
 			err := validator.Update(nil, tc.oldVC, tc.newVC)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -752,12 +923,14 @@ func TestUpdateVlanConfig_NoMatchedNodesAnnotationDoesNotPanic(t *testing.T) {
 	vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 	vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 	cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+	lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+	nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 	cnClient := fakeclients.ClusterNetworkClient(nchclientset.NetworkV1beta1().ClusterNetworks)
 	_, err := cnClient.Create(&networkv1.ClusterNetwork{ObjectMeta: metav1.ObjectMeta{Name: testCnName}})
 	assert.NoError(t, err)
 
-	validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+	validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, lmCache, nodeCache)
 
 	oldVC := &networkv1.VlanConfig{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1002,6 +1175,8 @@ func TestDeleteVlanConfig(t *testing.T) {
 			vcCache := fakeclients.VlanConfigCache(nchclientset.NetworkV1beta1().VlanConfigs)
 			vsCache := fakeclients.VlanStatusCache(nchclientset.NetworkV1beta1().VlanStatuses)
 			cnCache := fakeclients.ClusterNetworkCache(nchclientset.NetworkV1beta1().ClusterNetworks)
+			lmCache := fakeclients.LinkMonitorCache(nchclientset.NetworkV1beta1().LinkMonitors)
+			nodeCache := fakeclients.NodeCache(nchclientset.CoreV1().Nodes)
 
 			// client to inject test data
 			vcClient := fakeclients.VlanConfigClient(nchclientset.NetworkV1beta1().VlanConfigs)
@@ -1039,7 +1214,7 @@ func TestDeleteVlanConfig(t *testing.T) {
 				_, err := hncClient.Create(tc.currentHostNetworkConfig)
 				assert.NoError(t, err)
 			}
-			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache)
+			validator := NewVlanConfigValidator(nadCache, vcCache, vsCache, vmiCache, cnCache, lmCache, nodeCache)
 
 			err := validator.Delete(nil, tc.currentVC)
 			assert.True(t, tc.returnErr == (err != nil))
@@ -1114,3 +1289,192 @@ func TestGetMatchNodes(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBondOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		nics   []string
+		opts   *networkv1.BondOptions
+		errKey string // empty means no error
+	}{
+		{
+			name: "nil bond options are valid",
+		},
+		{
+			name: "802.3ad options are valid in 802.3ad mode",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, LacpRate: "fast", AdSelect: "bandwidth",
+				XmitHashPolicy: "layer3+4", MinLinks: 1, UpDelay: 200, DownDelay: 100},
+		},
+		{
+			name:   "lacpRate is invalid outside 802.3ad mode",
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1, LacpRate: "fast"},
+			errKey: "lacpRate",
+		},
+		{
+			name:   "adSelect is invalid in default mode",
+			opts:   &networkv1.BondOptions{Miimon: -1, AdSelect: "count"},
+			errKey: "adSelect",
+		},
+		{
+			name:   "xmitHashPolicy is invalid in balance-rr mode",
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondModeBalanceRr, Miimon: -1, XmitHashPolicy: "layer2+3"},
+			errKey: "xmitHashPolicy",
+		},
+		{
+			name:   "failOverMac is invalid outside active-backup mode",
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondModeBalanceXor, Miimon: -1, FailOverMac: "active"},
+			errKey: "failOverMac",
+		},
+		{
+			name: "primary is valid in active-backup mode",
+			nics: []string{"eth0", "eth1"},
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1, Primary: "eth1", PrimaryReselect: "better", FailOverMac: "active"},
+		},
+		{
+			name:   "primary must be one of the NICs",
+			nics:   []string{"eth0", "eth1"},
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1, Primary: "eth2"},
+			errKey: "not one of the uplink NICs",
+		},
+		{
+			name:   "primary is invalid in 802.3ad mode",
+			nics:   []string{"eth0", "eth1"},
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, Primary: "eth0"},
+			errKey: "primary",
+		},
+		{
+			name:   "primaryReselect requires primary",
+			opts:   &networkv1.BondOptions{Miimon: -1, PrimaryReselect: "failure"},
+			errKey: "primaryReselect",
+		},
+		{
+			name: "arp monitoring is valid without miimon",
+			opts: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1, ArpInterval: 1000, ArpIPTargets: []string{"192.168.0.1", "192.168.0.2"}},
+		},
+		{
+			name:   "arpInterval can't be used together with miimon",
+			opts:   &networkv1.BondOptions{Miimon: 100, ArpInterval: 1000, ArpIPTargets: []string{"192.168.0.1"}},
+			errKey: "miimon",
+		},
+		{
+			name:   "arpInterval is invalid in 802.3ad mode",
+			opts:   &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, ArpInterval: 1000, ArpIPTargets: []string{"192.168.0.1"}},
+			errKey: "arpInterval",
+		},
+		{
+			name:   "arpInterval requires arpIPTargets",
+			opts:   &networkv1.BondOptions{Miimon: -1, ArpInterval: 1000},
+			errKey: "arpIPTargets",
+		},
+		{
+			name:   "arpIPTargets requires arpInterval",
+			opts:   &networkv1.BondOptions{Miimon: -1, ArpIPTargets: []string{"192.168.0.1"}},
+			errKey: "arpInterval",
+		},
+		{
+			name:   "arpIPTargets must be IPv4 addresses",
+			opts:   &networkv1.BondOptions{Miimon: -1, ArpInterval: 1000, ArpIPTargets: []string{"fd00::1"}},
+			errKey: "IPv4",
+		},
+		{
+			name:   "updelay must be a multiple of miimon",
+			opts:   &networkv1.BondOptions{Miimon: -1, UpDelay: 150},
+			errKey: "updelay",
+		},
+		{
+			name:   "downdelay requires miimon",
+			opts:   &networkv1.BondOptions{Miimon: 0, DownDelay: 100},
+			errKey: "downdelay",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			uplink := &networkv1.Uplink{
+				NICs:        tc.nics,
+				BondOptions: tc.opts,
+			}
+			err := validateBondOptions(uplink)
+			if tc.errKey == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}
+
+func TestValidateUplinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []networkv1.NodeOverride
+		errKey    string // empty means no error
+	}{
+		{
+			name: "node overrides by node name and node selector are valid",
+			overrides: []networkv1.NodeOverride{
+				{Name: "node1", NodeName: "node1", NICs: []string{"enp65s0f0"}},
+				{Name: "intel", NodeSelector: map[string]string{"nic": "intel"}, NICSelector: &networkv1.NICSelector{Drivers: []string{"ixgbe"}},
+					BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMode8023AD, Miimon: -1, LacpRate: "fast"}},
+			},
+		},
+		{
+			name:      "node override must have a name",
+			overrides: []networkv1.NodeOverride{{NodeName: "node1"}},
+			errKey:    "no name",
+		},
+		{
+			name: "node override name must be unique",
+			overrides: []networkv1.NodeOverride{
+				{Name: "o1", NodeName: "node1"},
+				{Name: "o1", NodeName: "node2"},
+			},
+			errKey: "duplicated",
+		},
+		{
+			name:      "node override must select nodes",
+			overrides: []networkv1.NodeOverride{{Name: "o1"}},
+			errKey:    "either nodeName or nodeSelector",
+		},
+		{
+			name:      "node override can't set both node name and node selector",
+			overrides: []networkv1.NodeOverride{{Name: "o1", NodeName: "node1", NodeSelector: map[string]string{"nic": "intel"}}},
+			errKey:    "either nodeName or nodeSelector",
+		},
+		{
+			name:      "node override MTU must be the same as the uplink",
+			overrides: []networkv1.NodeOverride{{Name: "o1", NodeName: "node1", LinkAttrs: &networkv1.LinkAttrs{MTU: 9000}}},
+			errKey:    "MTU",
+		},
+		{
+			name: "merged bond options are validated",
+			overrides: []networkv1.NodeOverride{{Name: "o1", NodeName: "node1",
+				BondOptions: &networkv1.BondOptions{Mode: networkv1.BondMoDeActiveBackup, Miimon: -1, Primary: "eth0"}}},
+			errKey: "not one of the uplink NICs",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vc := &networkv1.VlanConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: testNewVCName},
+				Spec: networkv1.VlanConfigSpec{
+					ClusterNetwork: testCnName,
+					Uplink: networkv1.Uplink{
+						NICs:      []string{"ens3f0"},
+						LinkAttrs: &networkv1.LinkAttrs{MTU: utils.DefaultMTU},
+					},
+					NodeOverrides: tc.overrides,
+				},
+			}
+			err := validateUplinks(vc)
+			if tc.errKey == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+				assert.True(t, strings.Contains(err.Error(), tc.errKey), err.Error())
+			}
+		})
+	}
+}